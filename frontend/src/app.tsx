@@ -9,12 +9,14 @@ import { SettingsSidebar } from "./components/SettingsSidebar";
 import { usePreferences } from "./hooks/usePreferences";
 import { useStats } from "./hooks/useStats";
 import { useFileProcessing } from "./hooks/useFileProcessing";
+import { useAppEvents } from "./hooks/useAppEvents";
 
 function App() {
   // Initialize hooks
   usePreferences();
   useStats();
   useFileProcessing();
+  useAppEvents();
 
   return (
     <div className="min-h-screen bg-bg-primary text-text-primary font-nunito">
@@ -6,6 +6,12 @@ import { CompressionOption } from "../types/app";
 
 export const CompressionLevelSelector = () => {
   const compressionOptions: CompressionOption[] = [
+    {
+      level: "auto",
+      icon: "✨",
+      name: "Auto",
+      desc: "Picks settings for each file",
+    },
     {
       level: "good_enough",
       icon: "✅",
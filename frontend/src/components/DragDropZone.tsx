@@ -1,8 +1,10 @@
 import { useFileProcessing } from "../hooks/useFileProcessing";
+import { acceptedExtensions } from "../utils/fileUtils";
 
 export const DragDropZone = () => {
   const {
     processing,
+    officeAvailable,
     dragOver,
     handleDrop,
     handleDragOver,
@@ -34,7 +36,7 @@ export const DragDropZone = () => {
         📁
       </div>
       <div className="text-base font-medium mb-2 text-text-primary">
-        {processing.value ? "Processing..." : "Drag & Drop your PDF files here"}
+        {processing.value ? "Processing..." : "Drag & Drop your PDF or image files here"}
       </div>
       <div className="text-sm text-text-secondary">
         {processing.value
@@ -45,7 +47,7 @@ export const DragDropZone = () => {
         type="file"
         id="fileInput"
         multiple
-        accept=".pdf"
+        accept={acceptedExtensions(officeAvailable.value).join(",")}
         className="hidden"
         onChange={handleFileSelect}
         disabled={processing.value}
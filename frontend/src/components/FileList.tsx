@@ -30,6 +30,13 @@ export const FileList = () => {
                   ({file.compression_ratio.toFixed(1)}% smaller)
                 </span>
               </div>
+              {file.auto_choice && (
+                <div className="text-xs mt-1 text-text-secondary">
+                  Auto chose {file.auto_choice.level.replace("_", " ")} at{" "}
+                  {file.auto_choice.image_dpi} dpi:{" "}
+                  {file.auto_choice.reasons.join("; ")}
+                </div>
+              )}
             </div>
             <div className="flex gap-2 items-center sm:shrink-0">
               <button
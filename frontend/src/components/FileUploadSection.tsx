@@ -1,5 +1,6 @@
 import { processing } from "../hooks/useFileProcessing";
 import { DragDropZone } from "./DragDropZone";
+import { UrlConverter } from "./UrlConverter";
 import { ProgressBar } from "./ProgressBar";
 import { FileList } from "./FileList";
 
@@ -14,6 +15,7 @@ export const FileUploadSection = () => {
       </p>
 
       <DragDropZone />
+      <UrlConverter />
 
       {processing.value && <ProgressBar />}
 
@@ -0,0 +1,48 @@
+import { useState } from "preact/hooks";
+import { ConvertURLToPDF } from "../../wailsjs/go/app/App";
+import { useFileProcessing } from "../hooks/useFileProcessing";
+
+export const UrlConverter = () => {
+  const { processing, handleFiles } = useFileProcessing();
+  const [url, setUrl] = useState<string>("");
+  const [converting, setConverting] = useState<boolean>(false);
+
+  const busy = processing.value || converting;
+
+  const handleConvert = async (e: Event): Promise<void> => {
+    e.preventDefault();
+    if (busy || !url.trim()) return;
+
+    setConverting(true);
+    try {
+      const page = await ConvertURLToPDF(url.trim());
+      setUrl("");
+      await handleFiles([page.path]);
+    } catch (error) {
+      console.error("Error converting web page:", error);
+      alert("Error converting web page: " + String(error));
+    } finally {
+      setConverting(false);
+    }
+  };
+
+  return (
+    <form className="flex gap-2 mt-4" onSubmit={handleConvert}>
+      <input
+        type="url"
+        placeholder="Or enter a web page address (https://...)"
+        value={url}
+        onInput={(e) => setUrl((e.target as HTMLInputElement).value)}
+        disabled={busy}
+        className="flex-1 rounded-lg border border-border-light bg-bg-tertiary px-3 py-2 text-sm text-text-primary"
+      />
+      <button
+        type="submit"
+        disabled={busy || !url.trim()}
+        className="rounded-lg px-4 py-2 text-sm font-semibold text-white bg-pdf-red disabled:opacity-60"
+      >
+        {converting ? "Converting..." : "Convert"}
+      </button>
+    </form>
+  );
+};
@@ -0,0 +1,109 @@
+import { useEffect } from "preact/hooks";
+import { EventsOn } from "../../wailsjs/runtime/runtime";
+import {
+  ConfirmAutomationRequest,
+  DiscardSession,
+  RestoreSession,
+  TakeOpenedFiles,
+} from "../../wailsjs/go/app/App";
+import {
+  AutomationCompressEvent,
+  AutomationConfirmEvent,
+  AutomationErrorEvent,
+  FilesOpenedEvent,
+  SessionRestorableEvent,
+} from "../types/app";
+import {
+  compressFiles,
+  files,
+  processing,
+  progress,
+} from "./useFileProcessing";
+
+// Handles events the backend sends without the frontend asking, such as
+// files opened from the OS and compressions requested by kleinpdf:// URLs
+export const useAppEvents = () => {
+  useEffect(() => {
+    const unsubscribeOpened = EventsOn(
+      "files:opened",
+      (data: FilesOpenedEvent) => {
+        compressFiles(data.files);
+      }
+    );
+
+    // Files opened before this listener was registered
+    TakeOpenedFiles()
+      .then((opened) => {
+        if (opened && opened.length > 0) compressFiles(opened);
+      })
+      .catch((error) => console.error("Error reading opened files:", error));
+
+    const unsubscribeSession = EventsOn(
+      "session:restorable",
+      async (data: SessionRestorableEvent) => {
+        const resume = confirm(
+          `KleinPDF was closed while compressing ${data.total_files} file(s). Resume them?`
+        );
+        try {
+          if (!resume) {
+            await DiscardSession();
+            return;
+          }
+          processing.value = true;
+          const responses = await RestoreSession();
+          files.value = responses.flatMap((response) => response.files || []);
+        } catch (error) {
+          console.error("Error restoring session:", error);
+          alert("Error restoring session: " + (error as Error).message);
+        } finally {
+          processing.value = false;
+        }
+      }
+    );
+
+    const unsubscribeAutomationCompress = EventsOn(
+      "automation:compress",
+      (data: AutomationCompressEvent) => {
+        files.value = [];
+        progress.value = {
+          percent: 0,
+          current: 0,
+          total: data.files.length,
+          file: "Compressing files from a link...",
+        };
+      }
+    );
+
+    const unsubscribeAutomationError = EventsOn(
+      "automation:error",
+      (data: AutomationErrorEvent) => {
+        alert(
+          `KleinPDF could not use the link ${data.url}:\n\n${data.error}`
+        );
+      }
+    );
+
+    const unsubscribeAutomation = EventsOn(
+      "automation:confirm",
+      (data: AutomationConfirmEvent) => {
+        const output = data.output_dir || "next to the originals";
+        const approved = confirm(
+          `A link asked KleinPDF to compress ${data.files.length} file(s):\n\n` +
+            data.files.join("\n") +
+            `\n\nLevel: ${data.compression_level || "default"}\nOutput: ${output}\n\nCompress them?`
+        );
+        ConfirmAutomationRequest(data.request_id, approved).catch((error) =>
+          console.error("Error answering automation request:", error)
+        );
+      }
+    );
+
+    return () => {
+      unsubscribeOpened();
+      unsubscribeSession();
+      unsubscribeAutomationCompress();
+      unsubscribeAutomationError();
+      unsubscribeAutomation();
+    };
+  }, []);
+};
@@ -3,17 +3,22 @@ import { signal } from "@preact/signals";
 import { EventsOn } from "../../wailsjs/runtime/runtime";
 import {
   CompressPDF,
-  ProcessFileData,
+  GetAppStatus,
   OpenFileDialog,
 } from "../../wailsjs/go/app/App";
 import * as wailsModels from "../../wailsjs/go/models";
-import { ProgressData, CompressionProgressEvent } from "../types/app";
-import { readFileData } from "../utils/fileUtils";
+import {
+  ProgressData,
+  CompressionProgressEvent,
+  BatchStartedEvent,
+} from "../types/app";
+import { isAcceptedFile, uploadFiles } from "../utils/fileUtils";
 import { selectedCompressionLevel, advancedOptions } from "./usePreferences";
 
 // Global state for file processing
 export const files = signal<wailsModels.app.FileResult[]>([]);
 export const processing = signal<boolean>(false);
+export const officeAvailable = signal<boolean>(false);
 export const progress = signal<ProgressData>({
   percent: 0,
   current: 0,
@@ -21,10 +26,82 @@ export const progress = signal<ProgressData>({
   file: "",
 });
 
+// compressFiles compresses files already on disk with the current settings
+export const compressFiles = async (filePaths: string[]): Promise<void> => {
+  if (processing.value) return;
+
+  processing.value = true;
+  progress.value = {
+    percent: 0,
+    current: 0,
+    total: filePaths.length,
+    file: "Starting...",
+  };
+  files.value = [];
+
+  try {
+    const compressionOptions = new wailsModels.compression.CompressionOptions(
+      {
+        image_dpi: advancedOptions.value.imageDpi,
+        image_quality: advancedOptions.value.imageQuality,
+        pdf_version: advancedOptions.value.pdfVersion,
+        remove_metadata: advancedOptions.value.removeMetadata,
+        embed_fonts: advancedOptions.value.embedFonts,
+        generate_thumbnails: advancedOptions.value.generateThumbnails,
+        convert_to_grayscale: advancedOptions.value.convertToGrayscale,
+      }
+    );
+
+    const compressionRequest = new wailsModels.app.CompressionRequest({
+      files: filePaths,
+      compressionLevel: selectedCompressionLevel.value,
+      advancedOptions: compressionOptions,
+    });
+
+    const results: wailsModels.app.CompressionResponse = await CompressPDF(
+      compressionRequest
+    );
+
+    if (results.success) {
+      files.value = results.files;
+    } else {
+      throw new Error(results.error);
+    }
+  } catch (error) {
+    console.error("Error compressing PDFs:", error);
+    alert("Error compressing PDFs: " + (error as Error).message);
+  } finally {
+    processing.value = false;
+    setTimeout(() => {
+      progress.value = { percent: 0, current: 0, total: 0, file: "" };
+    }, 2000);
+  }
+};
+
 export const useFileProcessing = () => {
   const [dragOver, setDragOver] = useState<boolean>(false);
 
   useEffect(() => {
+    // Documents are only accepted when LibreOffice can convert them
+    GetAppStatus()
+      .then((status) => {
+        officeAvailable.value = Boolean(status.office_available);
+      })
+      .catch((error) => console.error("Error reading app status:", error));
+
+    // Reset progress when a batch starts; percent is weighted by pages
+    const unsubscribeStarted = EventsOn(
+      "batch:started",
+      (data: BatchStartedEvent) => {
+        progress.value = {
+          percent: 0,
+          current: 0,
+          total: data.files.length,
+          file: "",
+        };
+      }
+    );
+
     // Set up event listener for progress updates
     const unsubscribeProgress = EventsOn(
       "compression:progress",
@@ -34,6 +111,7 @@ export const useFileProcessing = () => {
     );
 
     return () => {
+      unsubscribeStarted();
       unsubscribeProgress();
     };
   }, []);
@@ -46,22 +124,26 @@ export const useFileProcessing = () => {
     if (!e.dataTransfer?.files) return;
 
     const droppedFiles = Array.from(e.dataTransfer.files);
-    const pdfFiles = droppedFiles.filter((file) =>
-      file.name.toLowerCase().endsWith(".pdf")
+    const acceptedFiles = droppedFiles.filter((file) =>
+      isAcceptedFile(file.name, officeAvailable.value)
     );
 
-    if (pdfFiles.length === 0) {
-      alert("Please drop PDF files only");
+    if (acceptedFiles.length === 0) {
+      alert(
+        officeAvailable.value
+          ? "Please drop PDF, image or document files only"
+          : "Please drop PDF or image files only"
+      );
       return;
     }
 
-    if (pdfFiles.length !== droppedFiles.length) {
+    if (acceptedFiles.length !== droppedFiles.length) {
       alert(
-        `Only ${pdfFiles.length} PDF files were found. Non-PDF files were ignored.`
+        `Only ${acceptedFiles.length} supported files were found. Other files were ignored.`
       );
     }
 
-    await handleDroppedFiles(pdfFiles);
+    await handleDroppedFiles(acceptedFiles);
   };
 
   const handleDragOver = (e: DragEvent): void => {
@@ -97,89 +179,31 @@ export const useFileProcessing = () => {
       percent: 0,
       current: 0,
       total: fileList.length,
-      file: "Reading files...",
+      file: "Uploading files...",
     };
     files.value = [];
 
+    let filePaths: string[];
     try {
-      const fileDataPromises = fileList.map((file) => readFileData(file));
-      const fileDataArray = await Promise.all(fileDataPromises);
-
-      const results: wailsModels.app.CompressionResponse =
-        await ProcessFileData(fileDataArray);
-
-      if (results.success) {
-        files.value = results.files;
-      } else {
-        throw new Error(results.error);
-      }
+      const uploaded = await uploadFiles(fileList);
+      filePaths = uploaded.map((file) => file.path);
     } catch (error) {
-      console.error("Error compressing PDFs:", error);
-      alert("Error compressing PDFs: " + (error as Error).message);
+      console.error("Error uploading PDFs:", error);
+      alert("Error uploading PDFs: " + (error as Error).message);
+      progress.value = { percent: 0, current: 0, total: 0, file: "" };
+      return;
     } finally {
       processing.value = false;
-      setTimeout(() => {
-        progress.value = { percent: 0, current: 0, total: 0, file: "" };
-      }, 2000);
     }
-  };
-
-  const handleFiles = async (filePaths: string[]): Promise<void> => {
-    if (processing.value) return;
 
-    processing.value = true;
-    progress.value = {
-      percent: 0,
-      current: 0,
-      total: filePaths.length,
-      file: "Starting...",
-    };
-    files.value = [];
-
-    try {
-      const compressionOptions = new wailsModels.compression.CompressionOptions(
-        {
-          image_dpi: advancedOptions.value.imageDpi,
-          image_quality: advancedOptions.value.imageQuality,
-          pdf_version: advancedOptions.value.pdfVersion,
-          remove_metadata: advancedOptions.value.removeMetadata,
-          embed_fonts: advancedOptions.value.embedFonts,
-          generate_thumbnails: advancedOptions.value.generateThumbnails,
-          convert_to_grayscale: advancedOptions.value.convertToGrayscale,
-        }
-      );
-
-      const compressionRequest = new wailsModels.app.CompressionRequest({
-        files: filePaths,
-        compressionLevel: selectedCompressionLevel.value,
-        advancedOptions: compressionOptions,
-      });
-
-      const results: wailsModels.app.CompressionResponse = await CompressPDF(
-        compressionRequest
-      );
-
-      if (results.success) {
-        files.value = results.files;
-      } else {
-        throw new Error(results.error);
-      }
-    } catch (error) {
-      console.error("Error compressing PDFs:", error);
-      alert("Error compressing PDFs: " + (error as Error).message);
-    } finally {
-      processing.value = false;
-      setTimeout(() => {
-        progress.value = { percent: 0, current: 0, total: 0, file: "" };
-      }, 2000);
-    }
+    await compressFiles(filePaths);
   };
 
   const handleBrowseFiles = async (): Promise<void> => {
     try {
       const selectedFiles: string[] = await OpenFileDialog();
       if (selectedFiles && selectedFiles.length > 0) {
-        handleFiles(selectedFiles);
+        compressFiles(selectedFiles);
       }
     } catch (error) {
       console.error("Error opening file dialog:", error);
@@ -190,6 +214,7 @@ export const useFileProcessing = () => {
     files,
     processing,
     progress,
+    officeAvailable,
     dragOver,
     setDragOver,
     handleDrop,
@@ -197,5 +222,6 @@ export const useFileProcessing = () => {
     handleDragLeave,
     handleFileSelect,
     handleBrowseFiles,
+    handleFiles: compressFiles,
   };
 };
@@ -23,7 +23,19 @@ export interface FileUploadData {
   size: number;
 }
 
-export type CompressionLevel = 'good_enough' | 'aggressive' | 'ultra';
+export interface UploadedFile {
+  name: string;
+  path: string;
+  size: number;
+}
+
+export interface UploadResponse {
+  files: UploadedFile[];
+  error?: string;
+  error_code?: string;
+}
+
+export type CompressionLevel = 'auto' | 'good_enough' | 'aggressive' | 'ultra';
 
 export interface CompressionOption {
   level: CompressionLevel;
@@ -38,6 +50,42 @@ export interface CompressionProgressEvent {
   current: number;
   total: number;
   file: string;
+  pages?: number;
+  pages_done?: number;
+  total_pages?: number;
+}
+
+export interface BatchStartedEvent {
+  batch_id: string;
+  files: { file: string; page_count: number }[];
+  total_pages: number;
+}
+
+export interface AutomationConfirmEvent {
+  request_id: string;
+  files: string[];
+  compression_level: string;
+  output_dir: string;
+}
+
+export interface FilesOpenedEvent {
+  files: string[];
+}
+
+export interface SessionRestorableEvent {
+  batches: { batch_id: string; pending_files: string[] }[];
+  total_files: number;
+}
+
+export interface AutomationCompressEvent {
+  files: string[];
+  compression_level: string;
+  output_dir: string;
+}
+
+export interface AutomationErrorEvent {
+  url: string;
+  error: string;
 }
 
 export interface StatsUpdateEvent {
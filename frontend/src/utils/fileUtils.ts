@@ -1,36 +1,60 @@
 import * as wailsModels from "../../wailsjs/go/models";
+import { UploadedFile, UploadResponse } from "../types/app";
 
-export const readFileData = (
-  file: File
-): Promise<wailsModels.app.FileUpload> => {
-  return new Promise((resolve, reject) => {
-    const reader = new FileReader();
+// Extensions of the image formats the backend converts to PDF
+export const IMAGE_EXTENSIONS = [
+  ".jpg",
+  ".jpeg",
+  ".png",
+  ".tif",
+  ".tiff",
+  ".webp",
+];
 
-    reader.onload = (e) => {
-      if (!e.target?.result) {
-        reject(new Error(`Failed to read file: ${file.name}`));
-        return;
-      }
+// Extensions of the document formats the backend converts with LibreOffice
+export const OFFICE_EXTENSIONS = [
+  ".docx",
+  ".xlsx",
+  ".pptx",
+  ".doc",
+  ".rtf",
+  ".odt",
+  ".ods",
+  ".odp",
+];
 
-      const arrayBuffer = e.target.result as ArrayBuffer;
-      const uint8Array = new Uint8Array(arrayBuffer);
-      const dataArray = Array.from(uint8Array); // Convert to regular array for JSON serialization
+// Extensions accepted from drag and drop and the file input; documents only
+// when LibreOffice is installed
+export const acceptedExtensions = (officeAvailable: boolean): string[] => [
+  ".pdf",
+  ...IMAGE_EXTENSIONS,
+  ...(officeAvailable ? OFFICE_EXTENSIONS : []),
+];
 
-      resolve(
-        new wailsModels.app.FileUpload({
-          name: file.name,
-          data: dataArray,
-          size: file.size,
-        })
-      );
-    };
+export const isAcceptedFile = (
+  name: string,
+  officeAvailable: boolean
+): boolean =>
+  acceptedExtensions(officeAvailable).some((ext) =>
+    name.toLowerCase().endsWith(ext)
+  );
 
-    reader.onerror = () => {
-      reject(new Error(`Failed to read file: ${file.name}`));
-    };
+// Streams files to the backend's upload handler, which writes them to disk in
+// chunks instead of passing the whole file through the Wails bridge
+export const uploadFiles = async (
+  fileList: File[]
+): Promise<UploadedFile[]> => {
+  const formData = new FormData();
+  fileList.forEach((file) => formData.append("files", file, file.name));
 
-    reader.readAsArrayBuffer(file);
-  });
+  const response = await fetch("/upload", { method: "POST", body: formData });
+  const result: UploadResponse = await response.json();
+  if (!response.ok || result.error) {
+    throw new Error(
+      result.error || `Upload failed with status ${response.status}`
+    );
+  }
+  return result.files;
 };
 
 export const downloadFile = async (
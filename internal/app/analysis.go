@@ -0,0 +1,16 @@
+package app
+
+import (
+	"kleinpdf/internal/pdfops"
+)
+
+// AnalyzePDF inspects a document so the frontend can recommend settings
+func (a *App) AnalyzePDF(path string) (*pdfops.Analysis, error) {
+	analysis, err := a.pdfops.Analyze(path)
+	if err != nil {
+		a.config.Logger.Error("Failed to analyze PDF", "file", path, "error", err)
+		return nil, err
+	}
+
+	return analysis, nil
+}
@@ -1,24 +1,32 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/panjf2000/ants/v2"
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
 	"kleinpdf/internal/common"
 	"kleinpdf/internal/compression"
 	"kleinpdf/internal/database"
+	"kleinpdf/internal/pdfops"
 )
 
 // NewApp creates a new application instance
 func NewApp() *App {
-	return &App{}
+	return &App{
+		batches:            make(map[string]*batch),
+		passwordRequests:   make(map[string]chan string),
+		automationRequests: make(map[string]*CompressionRequest),
+	}
 }
 
 // OnStartup is called when the app context is ready
@@ -35,17 +43,65 @@ func (a *App) OnStartup(ctx context.Context) {
 		return
 	}
 	a.db = db
+	db.SetLevelValidator(compression.IsKnownLevel)
+	for _, warning := range db.Warnings() {
+		a.config.Logger.Warn("Database opened with warnings", "warning", warning)
+	}
+
+	// Passwords belong in the system keychain, not in the database
+	a.migrateLegacySecrets()
 
 	// Initialize compressor
 	a.compressor = compression.NewCompressor(a.config.GhostscriptPath, a.config.Logger)
 
+	// Initialize page operations processor
+	a.pdfops = pdfops.NewProcessor(a.config.GhostscriptPath, a.config.Logger)
+
+	// Apply preferences that configure external tools
+	a.applyStoredPreferences()
+
+	// Drop original copies and uploads past their retention period, and
+	// intermediate files left by a crash
+	a.pruneBackups()
+	a.pruneUploads()
+	a.cleanTempFiles()
+
+	// Verify the Ghostscript binary works before the user needs it
+	a.checkGhostscriptHealth()
+
 	// Initialize stats
-	a.stats = &AppStats{}
+	a.stats = NewStatsManager(a.db, a.config.Logger)
+
+	// Report batches interrupted by the last quit or crash
+	if pending, err := a.GetPendingBatches(); err == nil && len(pending) > 0 {
+		a.config.Logger.Info("Found interrupted batches", "count", len(pending))
+	}
+
+	// Accept PDFs from the Finder Services menu
+	a.setupServices()
+	a.setupAutomation()
+
+	// Mirror progress events to event stream clients, and serve them when
+	// the event stream is turned on
+	a.streamEvents()
+	if prefs, err := a.db.GetPreferences(); err == nil && prefs.EventStreamPort != 0 {
+		if err := a.applyEventStreamPort(prefs.EventStreamPort); err != nil {
+			a.config.Logger.Warn("Failed to start event stream server", "port", prefs.EventStreamPort, "error", err)
+		}
+	}
+
+	// A PDF double-clicked or a kleinpdf:// URL opened on Windows arrives as a launch argument
+	wd, _ := os.Getwd()
+	a.queueOpenedFiles(openedFileArgs(os.Args[1:], wd))
+	for _, rawURL := range automationURLArgs(os.Args[1:]) {
+		a.OnUrlOpen(rawURL)
+	}
 
 	a.config.Logger.Info("Wails app initialized successfully")
 	a.config.Logger.Info("Application configuration",
 		"database_path", a.config.DatabasePath,
-		"ghostscript_available", a.compressor.IsAvailable())
+		"ghostscript_available", a.compressor.IsAvailable(),
+		"compression_backend", a.compressor.DefaultBackend())
 }
 
 // CompressPDF handles PDF compression requests
@@ -54,8 +110,29 @@ func (a *App) CompressPDF(request CompressionRequest) CompressionResponse {
 	if len(request.Files) == 0 {
 		a.config.Logger.Error("Compression request validation failed", "error", "no files provided")
 		return CompressionResponse{
-			Success: false,
-			Error:   "no files provided",
+			Success:   false,
+			Error:     "no files provided",
+			ErrorCode: common.ErrInvalidRequest,
+		}
+	}
+
+	// Expand directories into the PDFs they contain
+	files, subdirs := a.expandInputs(request.Files, request.MaxDepth, request.ExcludePatterns)
+	request.Files = files
+	if request.PreserveStructure {
+		if request.OutputSubdirs == nil {
+			request.OutputSubdirs = make(map[string]string)
+		}
+		for file, subdir := range subdirs {
+			request.OutputSubdirs[file] = subdir
+		}
+	}
+	if len(request.Files) == 0 {
+		a.config.Logger.Error("Compression request validation failed", "error", "no PDF files found")
+		return CompressionResponse{
+			Success:   false,
+			Error:     "no PDF files found",
+			ErrorCode: common.ErrInvalidRequest,
 		}
 	}
 
@@ -64,85 +141,293 @@ func (a *App) CompressPDF(request CompressionRequest) CompressionResponse {
 	if err != nil {
 		a.config.Logger.Error("Failed to resolve compression level", "error", err)
 		return CompressionResponse{
-			Success: false,
-			Error:   fmt.Sprintf("failed to resolve compression level: %v", err),
+			Success:   false,
+			Error:     fmt.Sprintf("failed to resolve compression level: %v", err),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	// Reject an unknown per-file level before any file is compressed
+	for file, override := range request.FileOverrides {
+		if override.CompressionLevel != "" && !compression.IsKnownLevel(override.CompressionLevel) {
+			return CompressionResponse{
+				Success:   false,
+				Error:     fmt.Sprintf("unknown compression level %q for %s", override.CompressionLevel, filepath.Base(file)),
+				ErrorCode: common.ErrInvalidRequest,
+			}
+		}
+	}
+
+	advancedOptions := a.resolveAdvancedOptions(request.AdvancedOptions)
+
+	// Per-file overrides replace only the options they set
+	overrideOptions, err := mergeFileOverrides(advancedOptions, request.FileOverrides)
+	if err != nil {
+		return CompressionResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	// Load the signing certificate once so a bad one fails the whole batch
+	if _, err := a.signingIdentity(); err != nil {
+		return CompressionResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
 		}
 	}
 
-	// Calculate optimal worker count
-	maxConcurrency := runtime.NumCPU()
-	if maxConcurrency > common.MaxConcurrencyLimit {
-		maxConcurrency = common.MaxConcurrencyLimit
+	// Decide what happens when an output file already exists
+	collisionStrategy, err := a.resolveCollisionStrategy(request.CollisionStrategy)
+	if err != nil {
+		return CompressionResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	// Make sure an explicit output directory exists before starting
+	if request.OutputDir != "" {
+		if err := os.MkdirAll(request.OutputDir, common.DefaultFilePermissions); err != nil {
+			a.config.Logger.Error("Failed to create output directory", "dir", request.OutputDir, "error", err)
+			return CompressionResponse{
+				Success:   false,
+				Error:     fmt.Sprintf("failed to create output directory: %v", err),
+				ErrorCode: common.ErrorCodeOf(err),
+			}
+		}
 	}
 
+	// Look up the upload destination before doing any work
+	var dest *database.Destination
+	if request.DestinationID != 0 {
+		dest, err = a.loadDestination(request.DestinationID)
+		if err != nil {
+			return CompressionResponse{
+				Success:   false,
+				Error:     fmt.Sprintf("upload destination unavailable: %v", err),
+				ErrorCode: common.ErrorCodeOf(err),
+			}
+		}
+	}
+
+	batchStart := time.Now()
+
+	// Register the batch so it can be cancelled from the frontend
+	batchID := request.BatchID
+	if batchID == "" {
+		batchID = common.GenerateUUID()
+	}
+	batchCtx, cancel := context.WithCancel(a.ctx)
+	activeBatch, err := a.registerBatch(batchID, cancel)
+	if err != nil {
+		cancel()
+		return CompressionResponse{
+			Success:   false,
+			BatchID:   batchID,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+	defer a.unregisterBatch(batchID)
+
+	// Keep the temp file cleaner away from this batch's intermediate files,
+	// and sweep up leftovers once it ends
+	defer a.cleanTempFiles()
+	defer a.lockBatch(batchID)()
+
+	// Persist the queue so an interrupted batch can be resumed
+	a.persistBatch(batchID, request)
+	defer a.finishPersistedBatch(batchID)
+
+	// Size the worker pool from the batch files and the concurrency preference
+	maxWorkers := 0
+	if prefs, err := a.db.GetPreferences(); err == nil && prefs != nil {
+		maxWorkers = prefs.MaxConcurrency
+	}
+	scheduler := newBatchScheduler(request.Files, maxWorkers)
+
 	// Create ants pool
-	pool, err := ants.NewPool(maxConcurrency)
+	pool, err := ants.NewPool(scheduler.workers)
 	if err != nil {
 		a.config.Logger.Error("Failed to create worker pool", "error", err)
 		return CompressionResponse{
-			Success: false,
-			Error:   fmt.Sprintf("failed to create worker pool: %v", err),
+			Success:   false,
+			Error:     fmt.Sprintf("failed to create worker pool: %v", err),
+			ErrorCode: common.ErrorCodeOf(err),
 		}
 	}
 	defer pool.Release()
 
+	// Report worker counters while the batch runs
+	telemetryCtx, stopTelemetry := context.WithCancel(batchCtx)
+	defer stopTelemetry()
+	go a.emitWorkerStats(telemetryCtx)
+
 	// Prepare for concurrent processing
 	totalFiles := len(request.Files)
 	results := make([]*FileResult, totalFiles)
+	var finished, finishedPages atomic.Int64
 	var wg sync.WaitGroup
+
+	// Read page counts up front so the frontend can show them and progress
+	// is weighted by pages rather than files
+	infos, pageCounts, totalPages := a.batchPageCounts(batchID, request.Files, scheduler.workers)
 	
-	// Process files concurrently using ants
-	for i, filePath := range request.Files {
-		wg.Add(1)
-		
-		// Capture variables for goroutine
-		index := i
-		file := filePath
-		
+	// submit hands a file to the pool; release frees its large-file slot
+	submit := func(index int, release func()) {
+		file := request.Files[index]
 		err := pool.Submit(func() {
 			defer wg.Done()
+			defer release()
+			defer func() {
+				// Cancelled files stay pending in the persisted queue
+				if result := results[index]; result != nil && result.Status != "cancelled" {
+					a.markBatchFile(batchID, file, result.Status)
+				}
+
+				current := finished.Add(1)
+				pagesDone := finishedPages.Add(int64(pageCounts[index]))
+				wailsruntime.EventsEmit(a.ctx, "compression:progress", map[string]interface{}{
+					"batch_id":    batchID,
+					"percent":     float64(pagesDone) / float64(totalPages) * 100,
+					"current":     current,
+					"total":       totalFiles,
+					"file":        filepath.Base(file),
+					"pages":       pageCounts[index],
+					"pages_done":  pagesDone,
+					"total_pages": totalPages,
+				})
+			}()
+			defer a.recoverPanic("compression worker", func(err error) {
+				results[index] = &FileResult{
+					FileID:           common.GenerateUUID(),
+					OriginalFilename: filepath.Base(file),
+					OriginalPath:     file,
+					Status:           "error",
+					Error:            err.Error(),
+					ErrorCode:        common.ErrInternal,
+				}
+			})
 			
+			fileID := common.GenerateUUID()
+
+			// Hold queued files while the batch is paused
+			if err := activeBatch.waitIfPaused(batchCtx); err != nil {
+				a.config.Logger.Info("Compression cancelled while paused", "file", file)
+				results[index] = cancelledResult(fileID, file)
+				return
+			}
+
 			// Check for context cancellation
 			select {
-			case <-a.ctx.Done():
+			case <-batchCtx.Done():
 				a.config.Logger.Info("Compression cancelled by context", "file", file)
+				results[index] = cancelledResult(fileID, file)
 				return
 			default:
 			}
 
-			fileID := common.GenerateUUID()
-			result, err := a.processSingleFile(fileID, file, compressionLevel, request.AdvancedOptions, index)
+			// Apply per-file overrides on top of the batch settings
+			fileLevel, fileOptions := compressionLevel, advancedOptions
+			if override, ok := request.FileOverrides[file]; ok && override.CompressionLevel != "" {
+				fileLevel = override.CompressionLevel
+			}
+			if options, ok := overrideOptions[file]; ok {
+				fileOptions = options
+			}
+
+			// Recreate the source folder structure under the output directory
+			fileOutputDir := request.OutputDir
+			if subdir, ok := request.OutputSubdirs[file]; ok && request.PreserveStructure && fileOutputDir != "" {
+				fileOutputDir = filepath.Join(fileOutputDir, subdir)
+				if err := os.MkdirAll(fileOutputDir, common.DefaultFilePermissions); err != nil {
+					a.config.Logger.Warn("Failed to create mirrored output directory", "dir", fileOutputDir, "error", err)
+					fileOutputDir = request.OutputDir
+				}
+			}
+
+			// Deferred so a recovered panic does not leave the worker busy
+			workerID := a.telemetry.start(file)
+			fileStart := time.Now()
+			defer func() { a.telemetry.finish(workerID, time.Since(fileStart)) }()
+			result, err := a.processSingleFile(batchCtx, batchID, fileID, file, fileOutputDir, collisionStrategy, fileLevel, fileOptions, infos[index], workerID)
 			
-			if err != nil {
-				a.config.Logger.Error("Error processing file", "file", file, "worker_id", index, "error", err)
+			if err != nil && batchCtx.Err() != nil {
+				a.config.Logger.Info("Compression cancelled", "file", file, "worker_id", workerID)
+				results[index] = cancelledResult(fileID, file)
+			} else if err != nil {
+				a.config.Logger.Error("Error processing file", "file", file, "worker_id", workerID, "error", err)
 				// Create error result
 				results[index] = &FileResult{
 					FileID:           fileID,
 					OriginalFilename: filepath.Base(file),
+					OriginalPath:     file,
+					CompressionLevel: fileLevel,
 					Status:           "error",
 					Error:            err.Error(),
+					ErrorCode:        common.ErrorCodeOf(err),
 				}
 			} else {
-				result.Status = "completed"
+				if result.Status == "" {
+					result.Status = "completed"
+				}
 				results[index] = result
 			}
 		})
 		
 		if err != nil {
+			release()
 			wg.Done() // Decrement since Submit failed
-			a.config.Logger.Error("Failed to submit task", "file", filePath, "error", err)
-			results[i] = &FileResult{
+			a.config.Logger.Error("Failed to submit task", "file", file, "error", err)
+			results[index] = &FileResult{
 				FileID:           common.GenerateUUID(),
-				OriginalFilename: filepath.Base(filePath),
+				OriginalFilename: filepath.Base(file),
+				OriginalPath:     file,
 				Status:           "error",
 				Error:            err.Error(),
+				ErrorCode:        common.ErrorCodeOf(err),
 			}
 		}
 	}
 
+	// Large files wait for a slot before they are submitted, so a waiting
+	// file never holds a pool worker that a small file could use
+	wg.Add(len(request.Files))
+	var large, small []int
+	for i, file := range request.Files {
+		if scheduler.isLarge(file) {
+			large = append(large, i)
+		} else {
+			small = append(small, i)
+		}
+	}
+	go func() {
+		for _, index := range large {
+			release, err := scheduler.acquire(batchCtx, request.Files[index])
+			if err != nil {
+				results[index] = cancelledResult(common.GenerateUUID(), request.Files[index])
+				wg.Done()
+				continue
+			}
+			submit(index, release)
+		}
+	}()
+	for _, index := range small {
+		submit(index, func() {})
+	}
+
 	// Wait for all tasks to complete
 	wg.Wait()
 
+	// Copy the outputs to the remote destination
+	if dest != nil && batchCtx.Err() == nil {
+		a.uploadResults(batchCtx, batchID, dest, results)
+	}
+
 	// Collect and aggregate results
 	var finalResults []FileResult
 	var totalOriginalSize, totalCompressedSize int64
@@ -151,11 +436,11 @@ func (a *App) CompressPDF(request CompressionRequest) CompressionResponse {
 	for _, result := range results {
 		if result != nil {
 			finalResults = append(finalResults, *result)
-			if result.Status == "completed" {
+			if result.Status == "completed" || result.Status == "skipped_larger" {
 				totalOriginalSize += result.OriginalSize
 				totalCompressedSize += result.CompressedSize
+				completed++
 			}
-			completed++
 		}
 	}
 
@@ -167,13 +452,43 @@ func (a *App) CompressPDF(request CompressionRequest) CompressionResponse {
 
 	// Update statistics
 	dataSaved := totalOriginalSize - totalCompressedSize
-	a.stats.SessionFilesCompressed += completed
-	a.stats.SessionDataSaved += dataSaved
-	a.stats.TotalFilesCompressed += int64(completed)
-	a.stats.TotalDataSaved += dataSaved
+	a.stats.UpdateStats(completed, dataSaved)
+
+	// Record the batch so users can look back at timings and results
+	a.recordHistory(batchID, finalResults)
+
+	// Package the outputs into a single archive if requested
+	var archivePath string
+	if request.ZipOutput {
+		var subdirs map[string]string
+		if request.PreserveStructure {
+			subdirs = request.OutputSubdirs
+		}
+		archivePath, err = a.createBatchArchive(finalResults, request.OutputDir, subdirs)
+		if err != nil {
+			a.config.Logger.Error("Failed to create batch archive", "batch_id", batchID, "error", err)
+		}
+	}
+
+	batchDuration := time.Since(batchStart).Seconds()
+
+	wailsruntime.EventsEmit(a.ctx, "batch:summary", map[string]interface{}{
+		"batch_id":                  batchID,
+		"total_files":               len(finalResults),
+		"total_original_size":       totalOriginalSize,
+		"total_compressed_size":     totalCompressedSize,
+		"overall_compression_ratio": overallCompressionRatio,
+		"concurrency":               scheduler.workers,
+		"duration_seconds":          batchDuration,
+	})
 
 	return CompressionResponse{
 		Success:                 true,
+		BatchID:                 batchID,
+		Concurrency:             scheduler.workers,
+		ArchivePath:             archivePath,
+		DurationSeconds:         batchDuration,
+		Cancelled:               batchCtx.Err() != nil && a.ctx.Err() == nil,
 		Files:                   finalResults,
 		TotalFiles:              len(finalResults),
 		TotalOriginalSize:       totalOriginalSize,
@@ -183,19 +498,34 @@ func (a *App) CompressPDF(request CompressionRequest) CompressionResponse {
 	}
 }
 
-// ProcessFileData handles file data uploads
+// ProcessFileData handles file data uploads. Files with data are written to the
+// upload directory first; large files should be posted to the upload handler
+// instead, which streams them to disk rather than holding them in memory.
 func (a *App) ProcessFileData(fileData []FileUpload) CompressionResponse {
 	if len(fileData) == 0 {
 		return CompressionResponse{
-			Success: false,
-			Error:   "no files provided",
+			Success:   false,
+			Error:     "no files provided",
+			ErrorCode: common.ErrInvalidRequest,
 		}
 	}
 
-	// Extract file paths
+	// Extract file paths, saving uploaded content to disk
 	var filePaths []string
 	for _, file := range fileData {
-		filePaths = append(filePaths, file.Name)
+		if len(file.Data) == 0 {
+			filePaths = append(filePaths, file.Name)
+			continue
+		}
+		uploaded, err := a.saveUpload(file.Name, bytes.NewReader(file.Data))
+		if err != nil {
+			return CompressionResponse{
+				Success:   false,
+				Error:     err.Error(),
+				ErrorCode: common.ErrorCodeOf(err),
+			}
+		}
+		filePaths = append(filePaths, uploaded.Path)
 	}
 
 	// Create request
@@ -216,50 +546,188 @@ func (a *App) ProcessFileData(fileData []FileUpload) CompressionResponse {
 
 // GetAppStatus returns application status information
 func (a *App) GetAppStatus() map[string]interface{} {
+	health := a.ghostscriptHealth()
 	return map[string]interface{}{
 		"status":                "running",
 		"framework":             "Wails + Preact",
 		"app_name":              "KleinPDF",
 		"ghostscript_path":      a.compressor.GetGhostscriptPath(),
 		"ghostscript_available": a.compressor.IsAvailable(),
+		"ghostscript_version":   health.Version,
+		"ghostscript_healthy":   health.Healthy,
+		"ghostscript_error":     health.Error,
+		"compression_backend":   a.compressor.DefaultBackend(),
+		"compression_backends":  a.compressor.Backends(),
+		"ocr_available":         a.compressor.IsOCRAvailable(),
+		"ocr_tool":              a.compressor.GetOCRTool(),
+		"office_available":      a.compressor.IsOfficeAvailable(),
+		"html_available":        a.compressor.IsHTMLAvailable(),
 	}
 }
 
 // GetStats returns application statistics
 func (a *App) GetStats() *AppStats {
-	return a.stats
+	stats := a.stats.Snapshot()
+	stats.Workers = a.telemetry.snapshot()
+	return &stats
 }
 
+// GetEmailPresets returns the attachment-size presets usable as compression levels
+func (a *App) GetEmailPresets() []compression.EmailPreset {
+	return compression.EmailPresets
+}
 
-// processSingleFile processes a single PDF file
-func (a *App) processSingleFile(fileID, filePath, compressionLevel string, advancedOptions *compression.CompressionOptions, workerID int) (*FileResult, error) {
-	filename := filepath.Base(filePath)
+// GetCompressionBackends returns the registered compression backends and their capabilities
+func (a *App) GetCompressionBackends() []compression.BackendInfo {
+	return a.compressor.Backends()
+}
 
-	// Create timestamp-based filename for compressed file
-	timestamp := time.Now().UTC().Format("20060102_150405")
-	baseName := strings.TrimSuffix(filename, ".pdf")
-	compressedFilename := fmt.Sprintf("%s_%s_compressed.pdf", baseName, timestamp)
 
-	// Generate output path in the same directory as input
-	inputDir := filepath.Dir(filePath)
-	compressedPath := filepath.Join(inputDir, compressedFilename)
+// processSingleFile processes a single PDF file. info is the file's details
+// as read when the batch started, or nil if they could not be read then.
+func (a *App) processSingleFile(ctx context.Context, batchID, fileID, filePath, outputDir, collisionStrategy, compressionLevel string, advancedOptions *compression.CompressionOptions, info *pdfops.QuickInfo, workerID int) (*FileResult, error) {
+	start := time.Now()
+	filename := filepath.Base(filePath)
+	compressedFilename, compressedPath := buildOutputPath(filePath, outputDir, "compressed")
 
 	// Check for context cancellation before compression
 	select {
-	case <-a.ctx.Done():
-		return nil, a.ctx.Err()
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	default:
 	}
 
-	// Direct compression
-	err := a.compressor.CompressFile(filePath, compressedPath, compressionLevel, advancedOptions)
+	// Load the signing certificate before any work is done, and refuse
+	// output that would be encrypted, since it cannot be signed
+	identity, err := a.signingIdentity()
 	if err != nil {
-		a.config.Logger.Error("Error processing file",
-			"file", filePath,
-			"worker_id", workerID,
-			"error", err)
 		return nil, err
 	}
+	if identity != nil && (advancedOptions.OwnerPassword != "" || advancedOptions.UserPassword != "") {
+		return nil, common.NewError(common.ErrInvalidRequest, "encrypted output cannot be signed")
+	}
+
+	// Wait for any other batch working on the same input
+	releaseInput, err := a.inputLocks.acquire(ctx, lockKey(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer releaseInput()
+
+	// Apply the collision strategy if the output name is taken
+	compressedPath, collisionAction, releaseOutput, err := a.reserveOutputPath(compressedPath, collisionStrategy)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseOutput()
+	compressedFilename = filepath.Base(compressedPath)
+	if collisionAction == "skipped" {
+		a.config.Logger.Info("Output already exists, skipped", "file", filePath, "output", compressedPath)
+		result := skippedResult(fileID, filePath, compressedPath, compressionLevel, "skipped_exists")
+		result.CollisionAction = collisionAction
+		return result, nil
+	}
+
+	// Reject files that are not PDFs before handing them to a backend, and
+	// convert the ones that can be
+	sourcePath, removeSource, err := a.prepareInput(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer removeSource()
+
+	// Ask for a password if the input is encrypted
+	advancedOptions, err = a.resolveInputPassword(ctx, fileID, sourcePath, advancedOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// The details read at batch start cover the file as it is; converted and
+	// decrypted inputs are read again. Failing to read them is not fatal.
+	if info == nil || sourcePath != filePath || advancedOptions.InputPassword != "" {
+		info, err = a.pdfops.QuickInfo(sourcePath, advancedOptions.InputPassword)
+		if err != nil {
+			a.config.Logger.Debug("Could not read PDF info", "file", filePath, "error", err)
+			info = &pdfops.QuickInfo{}
+		}
+	}
+
+	// Rewriting a signed PDF breaks its signature, so warn or leave it alone
+	var warnings []string
+	signed, err := compression.IsSigned(sourcePath, advancedOptions.InputPassword)
+	if err != nil {
+		a.config.Logger.Debug("Could not check for signatures", "file", filePath, "error", err)
+	}
+	if signed {
+		warnings = append(warnings, WarningSignatureInvalidated)
+		if prefs, err := a.db.GetPreferences(); err == nil && prefs.SkipSignedPDFs {
+			a.config.Logger.Info("Signed PDF skipped", "file", filePath)
+			// No output is written, so there is no compressed path to report
+			result := skippedResult(fileID, filePath, "", compressionLevel, "skipped_signed")
+			result.PageCount = info.PageCount
+			result.PDFVersion = info.PDFVersion
+			result.IsScanned = info.IsScanned
+			result.Warnings = warnings
+			return result, nil
+		}
+		a.config.Logger.Warn("Compressing a signed PDF will invalidate its signature", "file", filePath)
+	}
+
+	// Flattening draws the stored appearances, which may not show the field
+	// values when the viewer is asked to draw them, so keep such forms
+	if advancedOptions.FlattenForms {
+		need, err := compression.NeedsAppearances(sourcePath, advancedOptions.InputPassword)
+		if err != nil {
+			a.config.Logger.Debug("Could not check form appearances", "file", filePath, "error", err)
+		}
+		if need {
+			a.config.Logger.Warn("Form fields need appearances, not flattened", "file", filePath)
+			warnings = append(warnings, WarningFormsNotFlattened)
+			keepForms := *advancedOptions
+			keepForms.FlattenForms = false
+			advancedOptions = &keepForms
+		}
+	}
+
+	// Keep a copy of the original so the compression can be undone
+	backupPath, err := a.backupOriginal(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up original: %v", err)
+	}
+
+	// Resolve the auto level to concrete settings, so the cache key, the
+	// history and the result all record what was actually used
+	var autoChoice *compression.AutoChoice
+	if compressionLevel == compression.AutoLevel {
+		autoChoice = a.compressor.ChooseAutoSettings(sourcePath, advancedOptions)
+		compressionLevel = autoChoice.Level
+		advancedOptions = autoChoice.Apply(advancedOptions)
+	}
+
+	// Reuse a previous output for identical input and settings
+	key, cacheable := a.compressionCacheKey(filePath, compressionLevel, advancedOptions)
+	cached := cacheable && a.restoreFromCache(key, compressedPath)
+
+	// Direct compression
+	if !cached {
+		err = a.compressor.CompressFile(ctx, sourcePath, compressedPath, compressionLevel, advancedOptions, func(page, totalPages int, percent float64) {
+			wailsruntime.EventsEmit(a.ctx, "file:progress", map[string]interface{}{
+				"batch_id":    batchID,
+				"file_id":     fileID,
+				"filename":    filename,
+				"page":        page,
+				"total_pages": totalPages,
+				"percent":     percent,
+			})
+		})
+		if err != nil {
+			a.config.Logger.Error("Error processing file",
+				"file", filePath,
+				"worker_id", workerID,
+				"error", err)
+			return nil, err
+		}
+	}
 
 	// Get file sizes for statistics
 	originalInfo, err := os.Stat(filePath)
@@ -274,22 +742,233 @@ func (a *App) processSingleFile(fileID, filePath, compressionLevel string, advan
 
 	originalSize := originalInfo.Size()
 	compressedSize := compressedInfo.Size()
-	compressionRatio := float64(originalSize-compressedSize) / float64(originalSize) * 100
+
+	// Keep the original bytes when compression would make the file bigger,
+	// unless the options asked for changes the original does not have
+	status := ""
+	if compressedSize >= originalSize && advancedOptions.ChangesContent() {
+		a.config.Logger.Warn("Output larger than original, kept for the requested changes", "file", filePath)
+		warnings = append(warnings, WarningOutputLarger)
+	} else if compressedSize >= originalSize {
+		if err := common.CopyFile(sourcePath, compressedPath); err != nil {
+			return nil, err
+		}
+		compressedSize = originalSize
+		if sourcePath != filePath {
+			// A converted input keeps the size of its PDF, not the image
+			if info, err := os.Stat(sourcePath); err == nil {
+				compressedSize = info.Size()
+			}
+		}
+		status = "skipped_larger"
+		a.config.Logger.Info("Compressed output not smaller, kept original", "file", filePath)
+	}
+
+	// A signed output is rewritten below, so it would never match its hash
+	if cacheable && !cached && identity == nil {
+		a.storeInCache(key, compressedPath, compressedSize)
+	}
+
+	// Sign last, since any later change to the content breaks the signature
+	if identity != nil {
+		if err := a.signOutput(identity, compressedPath); err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(compressedPath); err == nil {
+			compressedSize = info.Size()
+		}
+	}
+
+	// Carry over the original's modification time and tags
+	a.preserveFileMetadata(filePath, compressedPath)
+
+	// Compare the selectable text when asked; a kept original needs no check
+	var textIntegrity *compression.TextIntegrity
+	if advancedOptions.VerifyText && status == "" {
+		textIntegrity, err = a.compressor.VerifyText(ctx, sourcePath, compressedPath, advancedOptions)
+		if err != nil {
+			a.config.Logger.Warn("Text integrity check failed", "file", filePath, "error", err)
+		} else if textIntegrity.Warning != "" {
+			a.config.Logger.Warn("Compression lost selectable text", "file", filePath, "warning", textIntegrity.Warning)
+		}
+	}
+
+	// Score the visual quality of sample pages when asked
+	var quality *compression.QualityScore
+	if advancedOptions.MeasureQuality && status == "" {
+		quality, err = a.compressor.MeasureQuality(ctx, sourcePath, compressedPath, info.PageCount, advancedOptions)
+		if err != nil {
+			a.config.Logger.Warn("Quality measurement failed", "file", filePath, "error", err)
+		} else if quality.Warning != "" {
+			a.config.Logger.Warn("Compression reduced visual quality", "file", filePath, "ssim", quality.SSIM, "warning", quality.Warning)
+		}
+	}
+
+	var compressionRatio float64
+	if originalSize > 0 {
+		compressionRatio = float64(originalSize-compressedSize) / float64(originalSize) * 100
+	}
+
+	duration := time.Since(start).Seconds()
 
 	return &FileResult{
 		FileID:             fileID,
 		OriginalFilename:   filename,
+		OriginalPath:       filePath,
 		CompressedFilename: compressedFilename,
 		OriginalSize:       originalSize,
 		CompressedSize:     compressedSize,
 		CompressionRatio:   compressionRatio,
 		CompressedPath:     compressedPath,
+		PageCount:          info.PageCount,
+		PDFVersion:         info.PDFVersion,
+		IsScanned:          info.IsScanned,
+		CompressionLevel:   compressionLevel,
+		Cached:             cached,
+		DurationSeconds:    duration,
+		ThroughputMBps:     throughputMBps(originalSize, duration),
+		Status:             status,
+		CollisionAction:    collisionAction,
+		TextIntegrity:      textIntegrity,
+		Quality:            quality,
+		AutoChoice:         autoChoice,
+		Warnings:           warnings,
+		options:            advancedOptions,
+		inputHash:          key.inputHash,
+		backupPath:         backupPath,
 	}, nil
 }
 
+// batchPageCounts reads the details of every file in a batch and emits their
+// page counts as batch:started. It returns the details, nil where they could
+// not be read, for the workers to reuse. Up to workers files are read at
+// once. Files whose pages cannot be read without converting or decrypting
+// them count as one page.
+func (a *App) batchPageCounts(batchID string, files []string, workers int) ([]*pdfops.QuickInfo, []int, int64) {
+	infos := make([]*pdfops.QuickInfo, len(files))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(workers, 1))
+	for i, file := range files {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if info, err := a.pdfops.QuickInfo(file, ""); err == nil {
+				infos[i] = info
+			}
+		}()
+	}
+	wg.Wait()
+
+	counts := make([]int, len(files))
+	events := make([]map[string]interface{}, len(files))
+	var total int64
+	for i, file := range files {
+		counts[i] = 1
+		if infos[i] != nil && infos[i].PageCount > 0 {
+			counts[i] = infos[i].PageCount
+		}
+		total += int64(counts[i])
+		events[i] = map[string]interface{}{
+			"file":       file,
+			"page_count": counts[i],
+		}
+	}
+
+	wailsruntime.EventsEmit(a.ctx, "batch:started", map[string]interface{}{
+		"batch_id":    batchID,
+		"files":       events,
+		"total_pages": total,
+	})
+	return infos, counts, total
+}
+
+// skippedResult describes an input that was left uncompressed with the given
+// status. compressedPath is empty when no output exists.
+func skippedResult(fileID, filePath, compressedPath, compressionLevel, status string) *FileResult {
+	result := &FileResult{
+		FileID:             fileID,
+		OriginalFilename:   filepath.Base(filePath),
+		OriginalPath:       filePath,
+		CompressedPath:     compressedPath,
+		CompressionLevel:   compressionLevel,
+		Status:             status,
+	}
+	if compressedPath != "" {
+		result.CompressedFilename = filepath.Base(compressedPath)
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		result.OriginalSize = info.Size()
+	}
+	return result
+}
+
+// buildOutputPath creates a timestamp-based output filename with the given suffix
+// in outputDir, or in the same directory as the input when outputDir is empty
+func buildOutputPath(filePath, outputDir, suffix string) (string, string) {
+	timestamp := time.Now().UTC().Format("20060102_150405")
+	baseName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	outputFilename := fmt.Sprintf("%s_%s_%s.pdf", baseName, timestamp, suffix)
+	if outputDir == "" {
+		outputDir = filepath.Dir(filePath)
+	}
+	return outputFilename, filepath.Join(outputDir, outputFilename)
+}
+
+// throughputMBps returns the rate at which size bytes were processed in seconds
+func throughputMBps(size int64, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(size) / (1024 * 1024) / seconds
+}
+
+// resolveAdvancedOptions returns a per-batch copy of the request options with
+// the preferred compression backend filled in when none was requested
+func (a *App) resolveAdvancedOptions(requested *compression.CompressionOptions) *compression.CompressionOptions {
+	options := compression.DefaultCompressionOptions()
+	if requested != nil {
+		options = *requested
+	}
+
+	if options.Backend == "" {
+		prefs, err := a.db.GetPreferences()
+		if err == nil && prefs != nil {
+			options.Backend = prefs.CompressionBackend
+		}
+	}
+
+	return &options
+}
+
+// mergeFileOverrides returns, for each file with overridden options, a copy
+// of the batch options with only the overridden fields replaced
+func mergeFileOverrides(options *compression.CompressionOptions, overrides map[string]FileSettings) (map[string]*compression.CompressionOptions, error) {
+	merged := make(map[string]*compression.CompressionOptions)
+	for file, override := range overrides {
+		if len(override.AdvancedOptions) == 0 {
+			continue
+		}
+		data, err := json.Marshal(override.AdvancedOptions)
+		if err != nil {
+			return nil, common.WrapError(common.ErrInvalidRequest, "invalid options for "+filepath.Base(file), err)
+		}
+		fileOptions := *options
+		if err := json.Unmarshal(data, &fileOptions); err != nil {
+			return nil, common.WrapError(common.ErrInvalidRequest, "invalid options for "+filepath.Base(file), err)
+		}
+		merged[file] = &fileOptions
+	}
+	return merged, nil
+}
+
 // resolveCompressionLevel resolves the compression level from request or preferences
 func (a *App) resolveCompressionLevel(requestedLevel string) (string, error) {
 	if requestedLevel != "" {
+		if !compression.IsKnownLevel(requestedLevel) {
+			return "", common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown compression level %q", requestedLevel))
+		}
 		return requestedLevel, nil
 	}
 
@@ -304,5 +983,21 @@ func (a *App) resolveCompressionLevel(requestedLevel string) (string, error) {
 		return common.DefaultCompressionLevel, nil
 	}
 
+	if !compression.IsKnownLevel(prefs.DefaultCompressionLevel) {
+		a.config.Logger.Warn("Stored compression level unknown, using default", "level", prefs.DefaultCompressionLevel)
+		return common.DefaultCompressionLevel, nil
+	}
+
 	return prefs.DefaultCompressionLevel, nil
-}
\ No newline at end of file
+}
+
+// OnShutdown stops background Ghostscript workers and the event stream
+// server before the app exits
+func (a *App) OnShutdown(ctx context.Context) {
+	if a.compressor != nil {
+		a.compressor.Close()
+	}
+	a.eventServerMu.Lock()
+	a.stopEventServerLocked()
+	a.eventServerMu.Unlock()
+}
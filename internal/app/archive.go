@@ -0,0 +1,110 @@
+package app
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kleinpdf/internal/common"
+)
+
+// createBatchArchive packages the outputs of a batch into a timestamped ZIP in
+// outputDir, or the user's Downloads folder when outputDir is empty. Entries
+// are placed in their subdirs when the batch mirrors its folder structure.
+func (a *App) createBatchArchive(results []FileResult, outputDir string, subdirs map[string]string) (string, error) {
+	if outputDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		outputDir = filepath.Join(homeDir, "Downloads")
+	}
+	if err := os.MkdirAll(outputDir, common.DefaultFilePermissions); err != nil {
+		return "", err
+	}
+
+	timestamp := time.Now().UTC().Format("20060102_150405")
+	archivePath := filepath.Join(outputDir, fmt.Sprintf("kleinpdf_batch_%s.zip", timestamp))
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+
+	writer := zip.NewWriter(file)
+	used := make(map[string]bool)
+	for _, result := range results {
+		if result.CompressedPath == "" || (result.Status != "completed" && result.Status != "skipped_larger") {
+			continue
+		}
+
+		name := archiveEntryName(result, subdirs, used)
+		if err := addFileToArchive(writer, result.CompressedPath, name); err != nil {
+			writer.Close()
+			file.Close()
+			os.Remove(archivePath)
+			return "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		file.Close()
+		os.Remove(archivePath)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(archivePath)
+		return "", err
+	}
+
+	return archivePath, nil
+}
+
+// archiveEntryName picks a unique slash-separated name for a result inside the archive
+func archiveEntryName(result FileResult, subdirs map[string]string, used map[string]bool) string {
+	name := result.CompressedFilename
+	if subdir, ok := subdirs[result.OriginalPath]; ok {
+		name = filepath.ToSlash(filepath.Join(subdir, name))
+	}
+
+	unique := name
+	ext := filepath.Ext(name)
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	used[unique] = true
+	return unique
+}
+
+// addFileToArchive stores a file in the archive without recompressing it
+func addFileToArchive(writer *zip.Writer, path, name string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Store // PDF streams are already compressed
+
+	entry, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, source)
+	return err
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+
+	"kleinpdf/internal/pdfops"
+)
+
+// ExtractAttachments saves the files embedded in a document, which are often
+// why a PDF will not shrink. When outputDir is empty they are written to a
+// "<name>_attachments" folder next to the document.
+func (a *App) ExtractAttachments(path, outputDir string) ([]pdfops.ExtractedAttachment, error) {
+	if outputDir == "" {
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		outputDir = filepath.Join(filepath.Dir(path), base+"_attachments")
+	}
+
+	attachments, err := a.pdfops.ExtractAttachments(path, outputDir)
+	if err != nil {
+		a.config.Logger.Error("Failed to extract attachments", "file", path, "output_dir", outputDir, "error", err)
+		return nil, err
+	}
+
+	return attachments, nil
+}
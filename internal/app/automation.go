@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/database"
+	"kleinpdf/internal/platform"
+)
+
+// scriptResult is the "compression result" record returned to AppleScript.
+// Its json names are the cocoa keys in build/darwin/KleinPDF.sdef.
+type scriptResult struct {
+	Success          bool     `json:"success"`
+	CompressedFiles  []string `json:"compressedFiles"`
+	OriginalSize     float64  `json:"originalSize"`
+	CompressedSize   float64  `json:"compressedSize"`
+	CompressionRatio float64  `json:"compressionRatio"`
+	ErrorMessage     string   `json:"errorMessage"`
+}
+
+// setupAutomation handles the commands in the app's AppleScript dictionary,
+// which Shortcuts can also run through its Run AppleScript action
+func (a *App) setupAutomation() {
+	platform.SetAutomationHandler(a.runScriptCommand)
+}
+
+// runScriptCommand runs one AppleScript command
+func (a *App) runScriptCommand(command platform.ScriptCommand) (result interface{}, err error) {
+	defer a.recoverPanic("applescript", func(panicErr error) { err = panicErr })
+
+	a.config.Logger.Info("Running AppleScript command", "command", command.Name)
+	switch command.Name {
+	case "compress":
+		if len(command.Files) == 0 {
+			return nil, common.NewError(common.ErrInvalidRequest, "no files given")
+		}
+		response := a.CompressPDF(CompressionRequest{
+			Files:            command.Files,
+			CompressionLevel: command.Level,
+			OutputDir:        command.OutputFolder,
+			AdvancedOptions:  a.savedCompressionOptions(),
+		})
+		return responseScriptResult(response), nil
+
+	case "last compression result":
+		entries, err := a.db.GetLatestBatchHistory()
+		if err != nil {
+			return nil, err
+		}
+		if entries == nil {
+			return nil, common.NewError(common.ErrInvalidRequest, "no files have been compressed yet")
+		}
+		return historyScriptResult(entries), nil
+
+	case "set compression level":
+		return nil, a.UpdatePreferences(map[string]interface{}{
+			"default_compression_level": command.Text,
+		})
+
+	default:
+		return nil, fmt.Errorf("unknown command %q", command.Name)
+	}
+}
+
+// responseScriptResult summarises a compression for AppleScript
+func responseScriptResult(response CompressionResponse) scriptResult {
+	result := scriptResult{
+		Success:          response.Success,
+		CompressedFiles:  []string{},
+		OriginalSize:     float64(response.TotalOriginalSize),
+		CompressedSize:   float64(response.TotalCompressedSize),
+		CompressionRatio: response.OverallCompressionRatio,
+		ErrorMessage:     response.Error,
+	}
+	for _, file := range response.Files {
+		if file.Status == "error" {
+			result.Success = false
+			if result.ErrorMessage == "" {
+				result.ErrorMessage = fmt.Sprintf("%s: %s", file.OriginalFilename, file.Error)
+			}
+			continue
+		}
+		if file.CompressedPath != "" {
+			result.CompressedFiles = append(result.CompressedFiles, file.CompressedPath)
+		}
+	}
+	return result
+}
+
+// historyScriptResult summarises a recorded batch for AppleScript
+func historyScriptResult(entries []database.HistoryEntry) scriptResult {
+	result := scriptResult{
+		Success:         true,
+		CompressedFiles: []string{},
+	}
+	for _, entry := range entries {
+		if entry.Status == "error" {
+			result.Success = false
+			if result.ErrorMessage == "" {
+				result.ErrorMessage = fmt.Sprintf("%s: %s", entry.OriginalFilename, entry.Error)
+			}
+			continue
+		}
+		result.CompressedFiles = append(result.CompressedFiles, entry.CompressedPath)
+		result.OriginalSize += float64(entry.OriginalSize)
+		result.CompressedSize += float64(entry.CompressedSize)
+	}
+	if result.OriginalSize > 0 {
+		result.CompressionRatio = (result.OriginalSize - result.CompressedSize) / result.OriginalSize * 100
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kleinpdf/internal/common"
+)
+
+// backupDir holds copies of originals kept by the "keep original copy" mode
+func backupDir() string {
+	return filepath.Join(getAppDataDir(), "backups")
+}
+
+// backupOriginal copies filePath into the backup area when the user keeps
+// original copies, returning the backup path or "" when backups are off.
+// Backups are named by content hash so identical files share one copy.
+func (a *App) backupOriginal(filePath string) (string, error) {
+	prefs, err := a.db.GetPreferences()
+	if err != nil || !prefs.KeepOriginalBackups {
+		return "", nil
+	}
+
+	hash, err := common.HashFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	dir := backupDir()
+	if err := os.MkdirAll(dir, common.DefaultFilePermissions); err != nil {
+		return "", err
+	}
+
+	backupPath := filepath.Join(dir, hash+".pdf")
+	if _, err := os.Stat(backupPath); err == nil {
+		// Refresh the age so retention counts from the latest use
+		now := time.Now()
+		os.Chtimes(backupPath, now, now)
+		return backupPath, nil
+	}
+
+	// Copy under a temporary name so a partial copy is never mistaken for a backup
+	tempPath := backupPath + ".tmp"
+	if err := common.CopyFile(filePath, tempPath); err != nil {
+		common.RemoveTemp(tempPath)
+		return "", err
+	}
+	if err := os.Rename(tempPath, backupPath); err != nil {
+		common.RemoveTemp(tempPath)
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// pruneBackups deletes backups older than the retention period. A retention
+// of zero or less keeps backups forever.
+func (a *App) pruneBackups() {
+	prefs, err := a.db.GetPreferences()
+	if err != nil || prefs.BackupRetentionDays <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(backupDir())
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -prefs.BackupRetentionDays)
+	removed := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(backupDir(), entry.Name())); err == nil {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		a.config.Logger.Info("Pruned expired original backups", "count", removed)
+	}
+}
+
+// UndoCompression restores the original file of a history entry from its
+// backup and deletes the compressed output
+func (a *App) UndoCompression(entryID uint) error {
+	entry, err := a.db.GetHistoryEntry(entryID)
+	if err != nil {
+		return common.WrapError(common.ErrInvalidRequest, fmt.Sprintf("history entry %d not found", entryID), err)
+	}
+
+	if entry.BackupPath == "" {
+		return common.NewError(common.ErrInvalidRequest, "no original copy was kept for this file")
+	}
+	if _, err := os.Stat(entry.BackupPath); err != nil {
+		return common.WrapError(common.ErrInvalidRequest, "the original copy has expired or was deleted", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), common.DefaultFilePermissions); err != nil {
+		return err
+	}
+	if err := common.CopyFile(entry.BackupPath, entry.OriginalPath); err != nil {
+		return fmt.Errorf("failed to restore original: %v", err)
+	}
+
+	// The output is only removed once the original is safely back
+	if entry.CompressedPath != "" && !strings.EqualFold(entry.CompressedPath, entry.OriginalPath) {
+		// The output kept the original's metadata, the shared backup did not
+		if _, err := os.Stat(entry.CompressedPath); err == nil {
+			a.preserveFileMetadata(entry.CompressedPath, entry.OriginalPath)
+		}
+
+		if err := os.Remove(entry.CompressedPath); err != nil && !os.IsNotExist(err) {
+			a.config.Logger.Warn("Failed to remove compressed output", "path", entry.CompressedPath, "error", err)
+		}
+	}
+
+	if err := a.db.MarkHistoryUndone(entryID); err != nil {
+		a.config.Logger.Warn("Failed to mark history entry as undone", "entry_id", entryID, "error", err)
+	}
+
+	a.config.Logger.Info("Restored original file", "path", entry.OriginalPath, "entry_id", entryID)
+	return nil
+}
+
+// preserveFileMetadata copies the modification time, tags and other user
+// metadata of src onto dst when the preference is on. Failures are only logged.
+func (a *App) preserveFileMetadata(src, dst string) {
+	prefs, err := a.db.GetPreferences()
+	if err != nil || !prefs.PreserveFileMetadata {
+		return
+	}
+	if err := common.CopyFileMetadata(src, dst); err != nil {
+		a.config.Logger.Warn("Failed to preserve file metadata", "source", src, "destination", dst, "error", err)
+	}
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"kleinpdf/internal/common"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// batch tracks the control state of a running compression batch
+type batch struct {
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+}
+
+// pause stops new files from being dispatched; returns false if already paused
+func (b *batch) pause() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.paused {
+		return false
+	}
+	b.paused = true
+	b.resume = make(chan struct{})
+	return true
+}
+
+// unpause releases workers waiting on the batch; returns false if not paused
+func (b *batch) unpause() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.paused {
+		return false
+	}
+	b.paused = false
+	close(b.resume)
+	return true
+}
+
+// waitIfPaused blocks while the batch is paused or until ctx is done
+func (b *batch) waitIfPaused(ctx context.Context) error {
+	b.mu.Lock()
+	paused, resume := b.paused, b.resume
+	b.mu.Unlock()
+
+	if !paused {
+		return nil
+	}
+
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CancelCompression cancels a running batch and kills its Ghostscript processes
+func (a *App) CancelCompression(batchID string) error {
+	b, err := a.getBatch(batchID)
+	if err != nil {
+		return err
+	}
+
+	a.config.Logger.Info("Cancelling compression batch", "batch_id", batchID)
+	b.cancel()
+	return nil
+}
+
+// PauseBatch stops queued files of a batch from being dispatched. Files that are
+// already being compressed run to completion.
+func (a *App) PauseBatch(batchID string) error {
+	b, err := a.getBatch(batchID)
+	if err != nil {
+		return err
+	}
+
+	if b.pause() {
+		a.config.Logger.Info("Paused compression batch", "batch_id", batchID)
+		wailsruntime.EventsEmit(a.ctx, "batch:paused", map[string]interface{}{
+			"batch_id": batchID,
+		})
+	}
+	return nil
+}
+
+// ResumeBatch resumes dispatching files of a paused batch
+func (a *App) ResumeBatch(batchID string) error {
+	b, err := a.getBatch(batchID)
+	if err != nil {
+		return err
+	}
+
+	if b.unpause() {
+		a.config.Logger.Info("Resumed compression batch", "batch_id", batchID)
+		wailsruntime.EventsEmit(a.ctx, "batch:resumed", map[string]interface{}{
+			"batch_id": batchID,
+		})
+	}
+	return nil
+}
+
+// getBatch looks up a running batch by ID
+func (a *App) getBatch(batchID string) (*batch, error) {
+	a.batchesMu.Lock()
+	defer a.batchesMu.Unlock()
+	b, ok := a.batches[batchID]
+	if !ok {
+		return nil, fmt.Errorf("batch %s not found", batchID)
+	}
+	return b, nil
+}
+
+// registerBatch tracks a running batch. An ID already in use is rejected so
+// a second request cannot take over the first batch's controls.
+func (a *App) registerBatch(batchID string, cancel context.CancelFunc) (*batch, error) {
+	a.batchesMu.Lock()
+	defer a.batchesMu.Unlock()
+	if _, ok := a.batches[batchID]; ok {
+		return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("batch %s is already running", batchID))
+	}
+	b := &batch{cancel: cancel}
+	a.batches[batchID] = b
+	return b, nil
+}
+
+// unregisterBatch releases a finished batch
+func (a *App) unregisterBatch(batchID string) {
+	a.batchesMu.Lock()
+	defer a.batchesMu.Unlock()
+	if b, ok := a.batches[batchID]; ok {
+		b.cancel()
+		delete(a.batches, batchID)
+	}
+}
+
+// cancelledResult builds the result for a file that was not compressed because its batch was cancelled
+func cancelledResult(fileID, filePath string) *FileResult {
+	return &FileResult{
+		FileID:           fileID,
+		OriginalFilename: filepath.Base(filePath),
+		OriginalPath:     filePath,
+		Status:           "cancelled",
+		Error:            "compression cancelled",
+		ErrorCode:        common.ErrCancelled,
+	}
+}
@@ -0,0 +1,250 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+	"kleinpdf/internal/database"
+	"kleinpdf/internal/pdfops"
+)
+
+const (
+	// benchmarkSamplePages is the page count of each sample document
+	benchmarkSamplePages = 2
+
+	// benchmarkFilesPerWorker sizes each run so the largest pool is kept
+	// busy for more than one file per worker
+	benchmarkFilesPerWorker = 2
+
+	// benchmarkMinFiles keeps runs on small machines long enough to time
+	benchmarkMinFiles = 8
+
+	// benchmarkTolerance is how far below the best throughput a smaller pool
+	// may be and still be recommended; fewer workers leave the machine more
+	// responsive for the same work
+	benchmarkTolerance = 0.05
+)
+
+// RunBenchmark compresses copies of a generated sample document at several
+// worker counts and recommends the count with the best throughput. Every run
+// compresses the same files at the default level, so only the pool size
+// differs. When save is set the recommendation is stored as the
+// max_concurrency preference.
+func (a *App) RunBenchmark(save bool) BenchmarkResponse {
+	fail := func(err error) BenchmarkResponse {
+		a.config.Logger.Error("Benchmark failed", "error", err)
+		return BenchmarkResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	compressionLevel, err := a.resolveCompressionLevel("")
+	if err != nil {
+		return fail(err)
+	}
+	options := a.resolveAdvancedOptions(nil)
+
+	tempDir, err := os.MkdirTemp(a.compressor.WorkingDir(), "kleinpdf_benchmark_*")
+	if err != nil {
+		return fail(fmt.Errorf("failed to create temp directory: %v", err))
+	}
+	defer common.RemoveTempDir(tempDir)
+
+	samplePath := filepath.Join(tempDir, "sample.pdf")
+	if err := compression.WriteSamplePDF(samplePath, benchmarkSamplePages); err != nil {
+		return fail(err)
+	}
+	sampleInfo, err := os.Stat(samplePath)
+	if err != nil {
+		return fail(err)
+	}
+
+	counts := benchmarkWorkerCounts()
+	files := make([]string, max(counts[len(counts)-1]*benchmarkFilesPerWorker, benchmarkMinFiles))
+	for i := range files {
+		files[i] = filepath.Join(tempDir, fmt.Sprintf("sample_%d.pdf", i+1))
+		if err := common.CopyFile(samplePath, files[i]); err != nil {
+			return fail(err)
+		}
+	}
+
+	// An untimed file first, so the first run does not pay for cold caches
+	warmupOptions := *options
+	if err := a.compressor.CompressFile(a.ctx, samplePath, filepath.Join(tempDir, "warmup.pdf"), compressionLevel, &warmupOptions, nil); err != nil {
+		return fail(err)
+	}
+
+	totalSize := sampleInfo.Size() * int64(len(files))
+	runs := make([]BenchmarkRun, 0, len(counts))
+	for _, workers := range counts {
+		duration, err := a.benchmarkRun(tempDir, files, workers, compressionLevel, *options)
+		if err != nil {
+			return fail(err)
+		}
+		run := BenchmarkRun{
+			Workers:         workers,
+			Files:           len(files),
+			DurationSeconds: duration.Seconds(),
+			FilesPerSecond:  float64(len(files)) / duration.Seconds(),
+			ThroughputMBps:  throughputMBps(totalSize, duration.Seconds()),
+		}
+		runs = append(runs, run)
+		a.config.Logger.Info("Benchmark run finished", "workers", workers, "files", run.Files, "seconds", run.DurationSeconds, "mb_per_second", run.ThroughputMBps)
+	}
+
+	response := BenchmarkResponse{
+		Success:                true,
+		CPUCount:               runtime.NumCPU(),
+		CompressionLevel:       compressionLevel,
+		Runs:                   runs,
+		RecommendedConcurrency: recommendConcurrency(runs),
+	}
+
+	if save {
+		err := a.UpdatePreferences(map[string]interface{}{
+			"max_concurrency": float64(response.RecommendedConcurrency),
+		})
+		if err != nil {
+			return fail(fmt.Errorf("failed to save max_concurrency: %v", err))
+		}
+		response.Saved = true
+	}
+
+	a.config.Logger.Info("Benchmark finished", "recommended_concurrency", response.RecommendedConcurrency, "saved", response.Saved)
+	return response
+}
+
+// benchmarkRun compresses files with a pool of workers into their own output
+// directory and returns the wall-clock time taken
+func (a *App) benchmarkRun(tempDir string, files []string, workers int, compressionLevel string, options compression.CompressionOptions) (time.Duration, error) {
+	outputDir := filepath.Join(tempDir, fmt.Sprintf("workers_%d", workers))
+	if err := os.MkdirAll(outputDir, common.DefaultFilePermissions); err != nil {
+		return 0, err
+	}
+
+	pool, err := ants.NewPool(workers)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create worker pool: %v", err)
+	}
+	defer pool.Release()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	start := time.Now()
+	for _, file := range files {
+		wg.Add(1)
+		err := pool.Submit(func() {
+			defer wg.Done()
+			fileOptions := options
+			outputPath := filepath.Join(outputDir, filepath.Base(file))
+			if err := a.compressor.CompressFile(a.ctx, file, outputPath, compressionLevel, &fileOptions, nil); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		})
+		if err != nil {
+			wg.Done()
+			return 0, fmt.Errorf("failed to submit benchmark file: %v", err)
+		}
+	}
+	wg.Wait()
+	duration := time.Since(start)
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return duration, nil
+}
+
+// benchmarkWorkerCounts returns the pool sizes to try: powers of two up to
+// the most a batch would use on this machine, and that maximum itself
+func benchmarkWorkerCounts() []int {
+	limit := min(runtime.NumCPU(), common.MaxConcurrencyLimit)
+	var counts []int
+	for workers := 1; workers < limit; workers *= 2 {
+		counts = append(counts, workers)
+	}
+	return append(counts, limit)
+}
+
+// recommendConcurrency picks the smallest pool within benchmarkTolerance of
+// the best throughput
+func recommendConcurrency(runs []BenchmarkRun) int {
+	var best float64
+	for _, run := range runs {
+		best = max(best, run.ThroughputMBps)
+	}
+	for _, run := range runs {
+		if run.ThroughputMBps >= best*(1-benchmarkTolerance) {
+			return run.Workers
+		}
+	}
+	return 1
+}
+
+// RunBenchmarkCommand runs the benchmark without the window, for the
+// "benchmark" command line subcommand, and returns the process exit code
+func RunBenchmarkCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("benchmark", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	save := flags.Bool("save", false, "save the recommended worker count as the max_concurrency preference")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	a := NewApp()
+	a.ctx = context.Background()
+	a.config = NewConfig()
+
+	db, err := database.NewDatabase(a.config.DatabasePath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to open database: %v\n", err)
+		return 1
+	}
+	a.db = db
+	db.SetLevelValidator(compression.IsKnownLevel)
+	a.compressor = compression.NewCompressor(a.config.GhostscriptPath, a.config.Logger)
+	defer a.compressor.Close()
+	a.pdfops = pdfops.NewProcessor(a.config.GhostscriptPath, a.config.Logger)
+	a.applyStoredPreferences()
+
+	fmt.Fprintf(stdout, "Benchmarking compression on %d CPUs...\n", runtime.NumCPU())
+	response := a.RunBenchmark(*save)
+	if !response.Success {
+		fmt.Fprintf(stderr, "Benchmark failed: %s\n", response.Error)
+		return 1
+	}
+
+	table := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "Workers\tFiles\tSeconds\tFiles/s\tMB/s\t")
+	for _, run := range response.Runs {
+		fmt.Fprintf(table, "%d\t%d\t%.2f\t%.2f\t%.2f\t\n", run.Workers, run.Files, run.DurationSeconds, run.FilesPerSecond, run.ThroughputMBps)
+	}
+	table.Flush()
+
+	fmt.Fprintf(stdout, "Recommended max_concurrency: %d\n", response.RecommendedConcurrency)
+	if response.Saved {
+		fmt.Fprintln(stdout, "Saved to preferences.")
+	} else {
+		fmt.Fprintln(stdout, "Run with -save to store it in preferences.")
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"kleinpdf/internal/binary"
+	"kleinpdf/internal/common"
+)
+
+// bundleStampFile records which embedded bundle a resource directory was extracted from
+const bundleStampFile = ".bundle-sha256"
+
+// setupGhostscriptBundle extracts the embedded lib/ and share/ghostscript trees
+// next to the binary and registers the environment the binary needs to find them
+func (c *Config) setupGhostscriptBundle(resourceDir, gsPath string) {
+	if len(binary.GhostscriptBundle) == 0 {
+		return
+	}
+
+	sum := sha256.Sum256(binary.GhostscriptBundle)
+	stamp := hex.EncodeToString(sum[:])
+	stampPath := filepath.Join(resourceDir, bundleStampFile)
+
+	if existing, err := os.ReadFile(stampPath); err != nil || string(existing) != stamp {
+		c.Logger.Info("Extracting Ghostscript resources", "path", resourceDir)
+		os.RemoveAll(resourceDir)
+		if err := extractTarGz(binary.GhostscriptBundle, resourceDir); err != nil {
+			c.Logger.Error("Failed to extract Ghostscript resources", "error", err)
+			os.RemoveAll(resourceDir)
+			return
+		}
+		if err := os.WriteFile(stampPath, []byte(stamp), 0644); err != nil {
+			c.Logger.Warn("Failed to record Ghostscript resource version", "error", err)
+		}
+	}
+
+	common.SetGhostscriptEnv(gsPath, ghostscriptBundleEnv(resourceDir))
+}
+
+// ghostscriptBundleEnv points Ghostscript at the extracted resources and dylibs
+func ghostscriptBundleEnv(resourceDir string) []string {
+	var searchPath []string
+	for _, pattern := range []string{
+		"share/ghostscript/*/Resource/Init",
+		"share/ghostscript/*/lib",
+		"share/ghostscript/*/Resource/Font",
+		"share/ghostscript/fonts",
+	} {
+		matches, _ := filepath.Glob(filepath.Join(resourceDir, pattern))
+		searchPath = append(searchPath, matches...)
+	}
+
+	var env []string
+	if len(searchPath) > 0 {
+		env = append(env, "GS_LIB="+strings.Join(searchPath, string(os.PathListSeparator)))
+	}
+
+	libDir := filepath.Join(resourceDir, "lib")
+	if info, err := os.Stat(libDir); err == nil && info.IsDir() {
+		env = append(env, "DYLD_LIBRARY_PATH="+libDir, "LD_LIBRARY_PATH="+libDir)
+	}
+
+	// Windows loads gsdll64.dll from PATH when it is not next to the executable
+	binDir := filepath.Join(resourceDir, "bin")
+	if info, err := os.Stat(binDir); err == nil && info.IsDir() && runtime.GOOS == "windows" {
+		env = append(env, "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+
+	return env
+}
+
+// extractTarGz unpacks a gzipped tar archive into dir, rejecting entries that escape it
+func extractTarGz(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer gz.Close()
+
+	root := filepath.Clean(dir) + string(os.PathSeparator)
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		target := filepath.Join(dir, header.Name)
+		if !strings.HasPrefix(target, root) {
+			return fmt.Errorf("bundle entry %q escapes the extraction directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// Dylib version links must stay inside the bundle
+			linkTarget := filepath.Join(filepath.Dir(target), header.Linkname)
+			if filepath.IsAbs(header.Linkname) || !strings.HasPrefix(linkTarget, root) {
+				return fmt.Errorf("bundle link %q escapes the extraction directory", header.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode)&0755|0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, reader)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+	"kleinpdf/internal/database"
+)
+
+// cacheKey identifies a compression by input content and settings
+type cacheKey struct {
+	inputHash    string
+	settingsHash string
+}
+
+// compressionCacheKey hashes the input and settings, including the Ghostscript
+// version, so outputs of a replaced binary are not reused. It returns false
+// when the result must not be cached, e.g. when passwords are involved.
+func (a *App) compressionCacheKey(filePath, compressionLevel string, options *compression.CompressionOptions) (cacheKey, bool) {
+	if options != nil && (options.InputPassword != "" || options.OwnerPassword != "" || options.UserPassword != "") {
+		return cacheKey{}, false
+	}
+
+	inputHash, err := common.HashFile(filePath)
+	if err != nil {
+		a.config.Logger.Warn("Failed to hash input for cache", "file", filePath, "error", err)
+		return cacheKey{}, false
+	}
+
+	settings, err := json.Marshal(struct {
+		Level       string                          `json:"level"`
+		Options     *compression.CompressionOptions `json:"options"`
+		Ghostscript string                          `json:"ghostscript"`
+	}{compressionLevel, options, a.ghostscriptHealth().Version})
+	if err != nil {
+		return cacheKey{}, false
+	}
+	settingsSum := sha256.Sum256(settings)
+
+	return cacheKey{
+		inputHash:    inputHash,
+		settingsHash: hex.EncodeToString(settingsSum[:]),
+	}, true
+}
+
+// restoreFromCache copies a previous output to outputPath, returning false on a cache miss
+func (a *App) restoreFromCache(key cacheKey, outputPath string) bool {
+	entry, err := a.db.GetCachedCompression(key.inputHash, key.settingsHash)
+	if err != nil || entry == nil {
+		return false
+	}
+
+	// Drop entries whose output was moved, deleted or modified
+	info, err := os.Stat(entry.OutputPath)
+	if err != nil || info.Size() != entry.OutputSize {
+		a.db.DeleteCachedCompression(entry.ID)
+		return false
+	}
+	if outputHash, err := common.HashFile(entry.OutputPath); err != nil || outputHash != entry.OutputHash {
+		a.db.DeleteCachedCompression(entry.ID)
+		return false
+	}
+
+	if entry.OutputPath != outputPath {
+		if err := common.CopyFile(entry.OutputPath, outputPath); err != nil {
+			a.config.Logger.Warn("Failed to copy cached output", "path", entry.OutputPath, "error", err)
+			return false
+		}
+	}
+
+	a.config.Logger.Info("Reused cached compression output", "cached_path", entry.OutputPath, "output", outputPath)
+	return true
+}
+
+// storeInCache records outputPath as the latest output for key, with the
+// hash that later shows it is unchanged
+func (a *App) storeInCache(key cacheKey, outputPath string, outputSize int64) {
+	outputHash, err := common.HashFile(outputPath)
+	if err != nil {
+		a.config.Logger.Warn("Failed to hash output for cache", "file", outputPath, "error", err)
+		return
+	}
+	err = a.db.SaveCachedCompression(&database.CompressionCache{
+		InputHash:    key.inputHash,
+		SettingsHash: key.settingsHash,
+		OutputPath:   outputPath,
+		OutputSize:   outputSize,
+		OutputHash:   outputHash,
+	})
+	if err != nil {
+		a.config.Logger.Warn("Failed to update compression cache", "error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	// CollisionRename writes to the first free name with a counter appended
+	CollisionRename = "rename"
+	// CollisionOverwrite replaces the existing file
+	CollisionOverwrite = "overwrite"
+	// CollisionSkip leaves the existing file and skips the input
+	CollisionSkip = "skip"
+
+	// maxCollisionRenames bounds the search for a free name
+	maxCollisionRenames = 1000
+)
+
+// resolveCollisionStrategy returns the requested strategy, or the preference
+// when the request does not name one
+func (a *App) resolveCollisionStrategy(requested string) (string, error) {
+	strategy := requested
+	if strategy == "" {
+		strategy = CollisionRename
+		if prefs, err := a.db.GetPreferences(); err == nil && prefs.CollisionStrategy != "" {
+			strategy = prefs.CollisionStrategy
+		}
+	}
+
+	switch strategy {
+	case CollisionRename, CollisionOverwrite, CollisionSkip:
+		return strategy, nil
+	default:
+		return "", common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown collision strategy %q", strategy))
+	}
+}
+
+// reserveOutputPath applies the collision strategy to path. It returns the
+// path to write, the action taken ("" when there was no collision, or
+// renamed, overwritten or skipped) and a release function to call once the
+// file is written. Paths claimed by other files of running batches count as
+// taken, so two workers never pick the same name, even when they refer to
+// the folder through different paths.
+func (a *App) reserveOutputPath(path, strategy string) (string, string, func(), error) {
+	a.outputsMu.Lock()
+	defer a.outputsMu.Unlock()
+
+	taken := func(candidate string) bool {
+		if a.reservedOutputs[lockKey(candidate)] {
+			return true
+		}
+		_, err := os.Lstat(candidate)
+		return err == nil
+	}
+
+	action := ""
+	if taken(path) {
+		switch strategy {
+		case CollisionSkip:
+			return path, "skipped", func() {}, nil
+		case CollisionOverwrite:
+			action = "overwritten"
+		default:
+			ext := filepath.Ext(path)
+			base := strings.TrimSuffix(path, ext)
+			renamed := ""
+			for i := 1; i <= maxCollisionRenames; i++ {
+				candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+				if !taken(candidate) {
+					renamed = candidate
+					break
+				}
+			}
+			if renamed == "" {
+				return "", "", nil, fmt.Errorf("no free output name for %s", filepath.Base(path))
+			}
+			path, action = renamed, "renamed"
+		}
+	}
+
+	if a.reservedOutputs == nil {
+		a.reservedOutputs = make(map[string]bool)
+	}
+	key := lockKey(path)
+	a.reservedOutputs[key] = true
+	return path, action, func() {
+		a.outputsMu.Lock()
+		defer a.outputsMu.Unlock()
+		delete(a.reservedOutputs, key)
+	}, nil
+}
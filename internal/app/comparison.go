@@ -0,0 +1,166 @@
+package app
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// comparisonDPI is the resolution both sides of a comparison are rendered at
+const comparisonDPI = 110
+
+// RenderComparison renders the same page of the original and compressed files
+// at identical resolution so the frontend can compare quality side by side
+func (a *App) RenderComparison(original, compressed string, page int) ComparisonResponse {
+	tempDir, err := os.MkdirTemp(a.compressor.WorkingDir(), "kleinpdf_compare_*")
+	if err != nil {
+		return ComparisonResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("failed to create temp directory: %v", err),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+	defer common.RemoveTempDir(tempDir)
+
+	originalImage, err := a.renderPageDataURL(original, filepath.Join(tempDir, "original.png"), page)
+	if err != nil {
+		return a.comparisonError(original, page, err)
+	}
+
+	compressedImage, err := a.renderPageDataURL(compressed, filepath.Join(tempDir, "compressed.png"), page)
+	if err != nil {
+		return a.comparisonError(compressed, page, err)
+	}
+
+	return ComparisonResponse{
+		Success:         true,
+		Page:            page,
+		DPI:             comparisonDPI,
+		OriginalImage:   originalImage,
+		CompressedImage: compressedImage,
+	}
+}
+
+// comparisonError logs a failed render and builds the error response
+func (a *App) comparisonError(file string, page int, err error) ComparisonResponse {
+	a.config.Logger.Error("Failed to render comparison page", "file", file, "page", page, "error", err)
+	return ComparisonResponse{
+		Success:   false,
+		Page:      page,
+		Error:     err.Error(),
+		ErrorCode: common.ErrorCodeOf(err),
+	}
+}
+
+// renderPageDataURL renders a page to a PNG and returns it as a data URL
+func (a *App) renderPageDataURL(inputPath, imagePath string, page int) (string, error) {
+	if err := a.pdfops.RenderPage(a.ctx, inputPath, imagePath, page, comparisonDPI); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// comparisonSamplePage is the page rendered from each output of CompareLevels
+const comparisonSamplePage = 1
+
+// CompareLevels compresses a document at every built-in level at once and
+// renders the same page from each output, so the level for a large batch can
+// be picked by looking at real results. The outputs are discarded.
+func (a *App) CompareLevels(file string) LevelComparisonResponse {
+	originalInfo, err := os.Stat(file)
+	if err != nil {
+		return LevelComparisonResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	tempDir, err := os.MkdirTemp(a.compressor.WorkingDir(), "kleinpdf_levels_*")
+	if err != nil {
+		return LevelComparisonResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("failed to create temp directory: %v", err),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+	defer common.RemoveTempDir(tempDir)
+
+	originalImage, err := a.renderPageDataURL(file, filepath.Join(tempDir, "original.png"), comparisonSamplePage)
+	if err != nil {
+		a.config.Logger.Error("Failed to render comparison page", "file", file, "page", comparisonSamplePage, "error", err)
+		return LevelComparisonResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	options := a.resolveAdvancedOptions(nil)
+	levels := make([]LevelComparison, len(compression.CompressionLevels))
+	var wg sync.WaitGroup
+	for i, level := range compression.CompressionLevels {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			levels[i] = a.compareLevel(file, tempDir, level, originalInfo.Size(), *options)
+		}()
+	}
+	wg.Wait()
+
+	return LevelComparisonResponse{
+		Success:       true,
+		OriginalSize:  originalInfo.Size(),
+		Page:          comparisonSamplePage,
+		DPI:           comparisonDPI,
+		OriginalImage: originalImage,
+		Levels:        levels,
+	}
+}
+
+// compareLevel compresses file at one level into tempDir and renders the
+// sample page of the output
+func (a *App) compareLevel(file, tempDir, level string, originalSize int64, options compression.CompressionOptions) LevelComparison {
+	result := LevelComparison{Level: level}
+	fail := func(err error) LevelComparison {
+		a.config.Logger.Error("Failed to compare compression level", "file", file, "level", level, "error", err)
+		result.Error = err.Error()
+		result.ErrorCode = common.ErrorCodeOf(err)
+		return result
+	}
+
+	outputPath := filepath.Join(tempDir, level+".pdf")
+	start := time.Now()
+	if err := a.compressor.CompressFile(a.ctx, file, outputPath, level, &options, nil); err != nil {
+		return fail(err)
+	}
+	result.DurationSeconds = time.Since(start).Seconds()
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return fail(err)
+	}
+	result.CompressedSize = info.Size()
+	if originalSize > 0 {
+		result.CompressionRatio = float64(originalSize-result.CompressedSize) / float64(originalSize) * 100
+	}
+
+	result.SampleImage, err = a.renderPageDataURL(outputPath, filepath.Join(tempDir, level+".png"), comparisonSamplePage)
+	if err != nil {
+		return fail(err)
+	}
+
+	return result
+}
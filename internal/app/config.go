@@ -1,20 +1,27 @@
 package app
 
 import (
+	"context"
 	"fmt"
-	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
 	"kleinpdf/internal/binary"
+	"kleinpdf/internal/common"
 )
 
+// ghostscriptProbeTimeout bounds the `gs -h` check of a candidate binary
+const ghostscriptProbeTimeout = 10 * time.Second
+
 // NewConfig creates a new configuration instance
 func NewConfig() *Config {
-	cfg := &Config{
-		Logger: slog.Default(),
-	}
+	cfg := &Config{}
 
+	cfg.setupLogging()
 	cfg.setupDirectories()
 	cfg.setupGhostscriptPath()
 
@@ -34,37 +41,137 @@ func (c *Config) setupGhostscriptPath() {
 	// Use embedded binary directly in app data directory for persistence
 	appDataDir := getAppDataDir()
 	extractDir := filepath.Join(appDataDir, "bin")
-	gsPath := filepath.Join(extractDir, "ghostscript")
+	gsPath := filepath.Join(extractDir, ghostscriptBinaryName())
+
+	// Libraries and resources must be in place before the binary is validated
+	c.setupGhostscriptBundle(filepath.Join(extractDir, "ghostscript-resources"), gsPath)
 
 	// Check if already extracted and valid
-	if c.isValidGhostscriptBinary(gsPath) {
+	if c.isExtractedBinaryIntact(gsPath) && c.isValidGhostscriptBinary(gsPath) {
 		c.GhostscriptPath = gsPath
+		c.GhostscriptSetup = GhostscriptSetup{Source: "cached", Path: gsPath}
 		c.Logger.Info("Using cached Ghostscript", "path", gsPath)
 		return
 	}
 
+	// A leftover binary that fails validation is truncated, built for another
+	// architecture or blocked; delete it so extraction starts from scratch
+	repaired := false
+	if _, err := os.Stat(gsPath); err == nil {
+		c.Logger.Warn("Cached Ghostscript failed validation, re-extracting", "path", gsPath)
+		os.Remove(gsPath)
+		repaired = true
+	}
+
 	// Create directory and extract binary
 	os.MkdirAll(extractDir, 0755)
 	c.Logger.Info("Extracting embedded Ghostscript binary", "path", gsPath)
 
 	if err := c.extractGhostscriptBinary(gsPath); err != nil {
 		c.Logger.Error("Failed to extract Ghostscript binary", "error", err)
+		c.discoverGhostscript(repaired, err.Error())
 		return
 	}
 
 	if c.isValidGhostscriptBinary(gsPath) {
 		c.GhostscriptPath = gsPath
+		c.GhostscriptSetup = GhostscriptSetup{Source: "extracted", Path: gsPath, Repaired: repaired}
 		c.Logger.Info("Successfully setup embedded Ghostscript", "path", gsPath)
 	} else {
 		c.Logger.Error("Ghostscript binary setup failed")
 		os.Remove(gsPath)
+		c.discoverGhostscript(repaired, "embedded ghostscript failed to run")
+	}
+}
+
+// isExtractedBinaryIntact checks that an extracted binary matches the embedded
+// one in size. Payloads whose header omits the size skip the comparison.
+func (c *Config) isExtractedBinaryIntact(gsPath string) bool {
+	stat, err := os.Stat(gsPath)
+	if err != nil {
+		return false
 	}
+	expected := binary.GhostscriptBinarySize()
+	return expected < 0 || stat.Size() == expected
+}
+
+// ghostscriptSearchPaths are common install locations checked when gs is not on PATH
+var ghostscriptSearchPaths = []string{
+	"/opt/homebrew/bin/gs",
+	"/usr/local/bin/gs",
+	"/opt/local/bin/gs",
+	"/usr/bin/gs",
 }
 
-// isValidGhostscriptBinary checks if the Ghostscript binary exists and is executable
+// windowsGhostscriptPatterns match the versioned directories of the Windows installer
+var windowsGhostscriptPatterns = []string{
+	`C:\Program Files\gs\gs*\bin\gswin64c.exe`,
+	`C:\Program Files (x86)\gs\gs*\bin\gswin32c.exe`,
+}
+
+// ghostscriptBinaryName returns the file name the embedded binary is extracted as
+func ghostscriptBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "ghostscript.exe"
+	}
+	return "ghostscript"
+}
+
+// discoverGhostscript falls back to a system Ghostscript from PATH or a common
+// install location, recording why the embedded binary could not be used
+func (c *Config) discoverGhostscript(repaired bool, reason string) {
+	candidates := []string{}
+	for _, name := range []string{"gs", "gswin64c", "gswin32c"} {
+		if path, err := exec.LookPath(name); err == nil {
+			candidates = append(candidates, path)
+		}
+	}
+	if runtime.GOOS == "windows" {
+		for _, pattern := range windowsGhostscriptPatterns {
+			matches, _ := filepath.Glob(pattern)
+			// Prefer the newest installed version
+			for i := len(matches) - 1; i >= 0; i-- {
+				candidates = append(candidates, matches[i])
+			}
+		}
+	} else {
+		candidates = append(candidates, ghostscriptSearchPaths...)
+	}
+
+	for _, path := range candidates {
+		if c.isValidGhostscriptBinary(path) {
+			c.GhostscriptPath = path
+			c.GhostscriptSetup = GhostscriptSetup{Source: "system", Path: path, Repaired: repaired, Error: reason}
+			c.Logger.Info("Using system Ghostscript", "path", path)
+			return
+		}
+	}
+
+	c.GhostscriptSetup = GhostscriptSetup{Source: "none", Repaired: repaired, Error: reason}
+	c.Logger.Error("No Ghostscript found on PATH or in common install locations")
+}
+
+// isValidGhostscriptBinary checks if the Ghostscript binary exists and actually runs
 func (c *Config) isValidGhostscriptBinary(gsPath string) bool {
-	// Check if binary exists and is executable
-	if stat, err := os.Stat(gsPath); err != nil || stat.Mode()&0111 == 0 {
+	// Check if binary exists and is executable. Windows has no executable
+	// bit, so rely on the .exe extension there.
+	stat, err := os.Stat(gsPath)
+	if err != nil || stat.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		if !strings.EqualFold(filepath.Ext(gsPath), ".exe") {
+			return false
+		}
+	} else if stat.Mode()&0111 == 0 {
+		return false
+	}
+
+	// An executable bit says nothing about architecture or Gatekeeper, so run it
+	ctx, cancel := context.WithTimeout(context.Background(), ghostscriptProbeTimeout)
+	defer cancel()
+	if err := common.GhostscriptCommand(ctx, gsPath, "-h").Run(); err != nil {
+		c.Logger.Warn("Ghostscript binary failed to run", "path", gsPath, "error", err)
 		return false
 	}
 	return true
@@ -72,15 +179,14 @@ func (c *Config) isValidGhostscriptBinary(gsPath string) bool {
 
 // extractGhostscriptBinary extracts the embedded Ghostscript binary to the filesystem
 func (c *Config) extractGhostscriptBinary(gsPath string) error {
-	// Write the embedded binary directly to the filesystem
+	// Decompress the embedded binary to the filesystem
 	file, err := os.OpenFile(gsPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create binary file %s: %w", gsPath, err)
 	}
 	defer file.Close()
 
-	_, err = file.Write(binary.GhostscriptBinary)
-	if err != nil {
+	if err := binary.WriteGhostscriptBinary(file); err != nil {
 		return fmt.Errorf("failed to write binary data: %w", err)
 	}
 
@@ -88,6 +194,15 @@ func (c *Config) extractGhostscriptBinary(gsPath string) error {
 }
 
 func getAppDataDir() string {
+	// Windows roaming application data directory (%APPDATA%)
+	if runtime.GOOS == "windows" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, "KleinPDF")
+		}
+		homeDir, _ := os.UserHomeDir()
+		return filepath.Join(homeDir, "AppData", "Roaming", "KleinPDF")
+	}
+
 	// macOS application support directory
 	homeDir, _ := os.UserHomeDir()
 	return filepath.Join(homeDir, "Library", "Application Support", "KleinPDF")
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// formatNames are the user-facing names of sniffed formats
+var formatNames = map[string]string{
+	"jpeg": "JPEG image",
+	"png":  "PNG image",
+	"tiff": "TIFF image",
+	"webp": "WebP image",
+	"gif":  "GIF image",
+	"bmp":  "BMP image",
+	"heic": "HEIC image",
+	"docx": "Word document",
+	"doc":  "Office document",
+	"rtf":  "RTF document",
+	"xlsx": "Excel workbook",
+	"pptx": "PowerPoint presentation",
+	"odt":  "OpenDocument text",
+	"ods":  "OpenDocument spreadsheet",
+	"odp":  "OpenDocument presentation",
+}
+
+// prepareInput checks what an input really is and returns the PDF to
+// compress: the input itself, or a converted copy that the returned function
+// removes. Files that are neither fail with a not_a_pdf error naming the type.
+func (a *App) prepareInput(ctx context.Context, filePath string) (string, func(), error) {
+	fileType, err := compression.SniffFileType(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch fileType.Kind {
+	case compression.FileKindPDF:
+		return filePath, func() {}, nil
+	case compression.FileKindImage:
+		if !compression.ConvertibleImageFormats[fileType.Format] {
+			return "", nil, common.NewError(common.ErrNotAPDF, fmt.Sprintf("%s files are not supported; convert it to PNG or JPEG first", formatNames[fileType.Format]))
+		}
+		convertedPath, err := a.convertedInputPath(filePath)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := compression.ImageToPDF(filePath, convertedPath); err != nil {
+			common.RemoveTemp(convertedPath)
+			return "", nil, common.WrapError(common.ErrNotAPDF, "failed to convert image", err)
+		}
+		a.config.Logger.Info("Converted image to PDF", "file", filePath, "format", fileType.Format)
+		return convertedPath, func() { common.RemoveTemp(convertedPath) }, nil
+	case compression.FileKindOffice:
+		if !compression.ConvertibleOfficeFormats[fileType.Format] || !a.compressor.IsOfficeAvailable() {
+			return "", nil, common.NewError(common.ErrNotAPDF, fmt.Sprintf("this is a %s, not a PDF; install LibreOffice or export it as PDF first", formatNames[fileType.Format]))
+		}
+		convertedPath, err := a.convertedInputPath(filePath)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := a.compressor.ConvertOfficeToPDF(ctx, filePath, convertedPath); err != nil {
+			common.RemoveTemp(convertedPath)
+			if ctx.Err() != nil {
+				return "", nil, ctx.Err()
+			}
+			return "", nil, common.WrapError(common.ErrNotAPDF, "failed to convert document", err)
+		}
+		a.config.Logger.Info("Converted document to PDF", "file", filePath, "format", fileType.Format)
+		return convertedPath, func() { common.RemoveTemp(convertedPath) }, nil
+	case compression.FileKindHTML:
+		if !a.compressor.IsHTMLAvailable() {
+			return "", nil, common.NewError(common.ErrNotAPDF, "this is a web page, not a PDF; install Chrome or wkhtmltopdf to convert it")
+		}
+		convertedPath, err := a.convertedInputPath(filePath)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := a.compressor.ConvertHTMLToPDF(ctx, filePath, convertedPath); err != nil {
+			common.RemoveTemp(convertedPath)
+			if ctx.Err() != nil {
+				return "", nil, ctx.Err()
+			}
+			return "", nil, common.WrapError(common.ErrNotAPDF, "failed to convert web page", err)
+		}
+		a.config.Logger.Info("Converted web page to PDF", "file", filePath)
+		return convertedPath, func() { common.RemoveTemp(convertedPath) }, nil
+	default:
+		return "", nil, common.NewError(common.ErrNotAPDF, "file is not a PDF")
+	}
+}
+
+// convertedInputPath reserves a temp file for a converted input. The name
+// ends like other intermediate files so the temp cleaner recognizes it.
+func (a *App) convertedInputPath(filePath string) (string, error) {
+	baseName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	file, err := os.CreateTemp(a.compressor.WorkingDir(), baseName+"_*_converted_temp.pdf")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	file.Close()
+	return file.Name(), nil
+}
+
+// ConvertURLToPDF renders a web page to PDF and stores it with the uploads,
+// so the frontend can compress it like a dropped file
+func (a *App) ConvertURLToPDF(rawURL string) (*UploadedFile, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, common.NewError(common.ErrInvalidRequest, "enter an http or https address")
+	}
+	if !a.compressor.IsHTMLAvailable() {
+		return nil, common.NewError(common.ErrInvalidRequest, "converting web pages needs Chrome, Chromium, Edge or wkhtmltopdf")
+	}
+
+	renderedPath, err := a.convertedInputPath(pageFilename(parsed))
+	if err != nil {
+		return nil, err
+	}
+	defer common.RemoveTemp(renderedPath)
+
+	if err := a.compressor.ConvertHTMLToPDF(a.ctx, parsed.String(), renderedPath); err != nil {
+		a.config.Logger.Error("Web page conversion failed", "url", parsed.Redacted(), "error", err)
+		return nil, common.WrapError(common.ErrorCodeOf(err), "failed to convert web page", err)
+	}
+
+	rendered, err := os.Open(renderedPath)
+	if err != nil {
+		return nil, err
+	}
+	defer rendered.Close()
+
+	a.config.Logger.Info("Converted web page to PDF", "url", parsed.Redacted())
+	return a.saveUpload(pageFilename(parsed), rendered)
+}
+
+// pageFilename names a rendered page after its host and last path segment
+func pageFilename(page *url.URL) string {
+	name := page.Hostname()
+	if segment := path.Base(strings.TrimSuffix(page.Path, "/")); segment != "." && segment != "/" && segment != "" {
+		name += "-" + strings.TrimSuffix(segment, path.Ext(segment))
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '-'
+		}
+		return r
+	}, name)
+	return name + ".pdf"
+}
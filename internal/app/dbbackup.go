@@ -0,0 +1,40 @@
+package app
+
+import (
+	"kleinpdf/internal/common"
+)
+
+// BackupDatabase writes a copy of the settings, history and statistics to path
+func (a *App) BackupDatabase(path string) error {
+	if path == "" {
+		return common.NewError(common.ErrInvalidRequest, "no backup path given")
+	}
+
+	if err := a.db.Backup(path); err != nil {
+		a.config.Logger.Error("Failed to back up database", "path", path, "error", err)
+		return err
+	}
+
+	a.config.Logger.Info("Backed up database", "path", path)
+	return nil
+}
+
+// RestoreDatabase replaces the settings, history and statistics with a backup
+// made by BackupDatabase, then applies the restored preferences
+func (a *App) RestoreDatabase(path string) error {
+	if path == "" {
+		return common.NewError(common.ErrInvalidRequest, "no backup path given")
+	}
+
+	if err := a.db.Restore(path); err != nil {
+		a.config.Logger.Error("Failed to restore database", "path", path, "error", err)
+		return err
+	}
+
+	a.applyStoredPreferences()
+	a.checkGhostscriptHealth()
+	a.stats.Reload()
+
+	a.config.Logger.Info("Restored database", "path", path)
+	return nil
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/database"
+	"kleinpdf/internal/destination"
+	"kleinpdf/internal/platform"
+)
+
+// destinationTestTimeout bounds a connection test from the preferences screen
+const destinationTestTimeout = 30 * time.Second
+
+// GetDestinations lists the SFTP and WebDAV upload destinations. Passwords
+// are never sent back to the frontend.
+func (a *App) GetDestinations() ([]database.Destination, error) {
+	destinations, err := a.db.ListDestinations()
+	if err != nil {
+		return nil, err
+	}
+	for i := range destinations {
+		destinations[i].Password = ""
+	}
+	return destinations, nil
+}
+
+// SaveDestination creates or updates an upload destination. The password is
+// kept in the system keychain, never in the database; an empty password on an
+// existing destination keeps the stored one.
+func (a *App) SaveDestination(dest database.Destination) (*database.Destination, error) {
+	password := dest.Password
+	if dest.ID != 0 && password == "" {
+		existing, err := a.loadDestination(dest.ID)
+		if err != nil {
+			return nil, err
+		}
+		dest.Password = existing.Password
+	}
+	if err := destinationConfig(&dest).Validate(); err != nil {
+		return nil, err
+	}
+
+	isNew := dest.ID == 0
+	if err := a.db.SaveDestination(&dest); err != nil {
+		return nil, err
+	}
+	if password != "" {
+		if err := platform.SetSecret(destinationSecretKey(dest.ID), password); err != nil {
+			if isNew {
+				a.db.DeleteDestination(dest.ID)
+			}
+			return nil, fmt.Errorf("failed to save the password: %v", err)
+		}
+	}
+	dest.Password = ""
+	return &dest, nil
+}
+
+// DeleteDestination removes an upload destination and its password
+func (a *App) DeleteDestination(id uint) error {
+	if err := a.db.DeleteDestination(id); err != nil {
+		return err
+	}
+	if err := platform.DeleteSecret(destinationSecretKey(id)); err != nil {
+		a.config.Logger.Warn("Failed to remove destination password from the keychain", "destination_id", id, "error", err)
+	}
+	return nil
+}
+
+// TestDestination connects to a destination and creates its remote folder
+func (a *App) TestDestination(id uint) error {
+	dest, err := a.loadDestination(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, destinationTestTimeout)
+	defer cancel()
+	uploader, err := destination.Open(ctx, destinationConfig(dest))
+	if err != nil {
+		return err
+	}
+	return uploader.Close()
+}
+
+// loadDestination reads a destination with its password from the keychain
+func (a *App) loadDestination(id uint) (*database.Destination, error) {
+	dest, err := a.db.GetDestination(id)
+	if err != nil {
+		return nil, common.WrapError(common.ErrInvalidRequest, "destination not found", err)
+	}
+	dest.Password, err = readSecret(destinationSecretKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the destination password: %v", err)
+	}
+	return dest, nil
+}
+
+// destinationConfig converts a stored destination into connection settings
+func destinationConfig(dest *database.Destination) destination.Config {
+	return destination.Config{
+		Protocol:           dest.Protocol,
+		Host:               dest.Host,
+		Port:               dest.Port,
+		URL:                dest.URL,
+		Username:           dest.Username,
+		Password:           dest.Password,
+		KeyPath:            dest.KeyPath,
+		HostKeyFingerprint: dest.HostKeyFingerprint,
+		RemoteDir:          dest.RemoteDir,
+		AllowInsecureHTTP:  dest.AllowInsecureHTTP,
+	}
+}
+
+// uploadResults copies the compressed files of a batch to a destination. The
+// local copies are kept, and a failed upload is reported on the file result
+// without failing the compression.
+func (a *App) uploadResults(ctx context.Context, batchID string, dest *database.Destination, results []*FileResult) {
+	var uploads []*FileResult
+	for _, result := range results {
+		if result != nil && (result.Status == "completed" || result.Status == "skipped_larger") {
+			uploads = append(uploads, result)
+		}
+	}
+	if len(uploads) == 0 {
+		return
+	}
+
+	uploader, err := destination.Open(ctx, destinationConfig(dest))
+	if err != nil {
+		a.config.Logger.Error("Failed to connect to destination", "destination", dest.Name, "error", err)
+		for _, result := range uploads {
+			result.UploadError = err.Error()
+		}
+		return
+	}
+	defer uploader.Close()
+
+	for _, result := range uploads {
+		remotePath, err := uploader.Upload(ctx, result.CompressedPath, result.CompressedFilename)
+		if err != nil {
+			a.config.Logger.Error("Upload failed", "file", result.CompressedPath, "destination", dest.Name, "error", err)
+			result.UploadError = err.Error()
+		} else {
+			result.RemotePath = remotePath
+		}
+
+		wailsruntime.EventsEmit(a.ctx, "file:uploaded", map[string]interface{}{
+			"batch_id":    batchID,
+			"file_id":     result.FileID,
+			"destination": dest.Name,
+			"remote_path": result.RemotePath,
+			"error":       result.UploadError,
+		})
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
@@ -1,7 +1,13 @@
 package app
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+
 	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/platform"
 )
 
 // OpenFileDialog opens a file selection dialog for PDF files
@@ -13,6 +19,18 @@ func (a *App) OpenFileDialog() ([]string, error) {
 				DisplayName: "PDF Files (*.pdf)",
 				Pattern:     "*.pdf",
 			},
+			{
+				DisplayName: "Office Documents (*.docx, *.xlsx, *.pptx, *.odt)",
+				Pattern:     "*.docx;*.xlsx;*.pptx;*.doc;*.rtf;*.odt;*.ods;*.odp",
+			},
+			{
+				DisplayName: "Images (*.jpg, *.png, *.tiff, *.webp)",
+				Pattern:     "*.jpg;*.jpeg;*.png;*.tif;*.tiff;*.webp",
+			},
+			{
+				DisplayName: "Web Pages (*.html, *.htm)",
+				Pattern:     "*.html;*.htm",
+			},
 		},
 	})
 
@@ -33,6 +51,13 @@ func (a *App) OpenDirectoryDialog() (string, error) {
 		return "", err
 	}
 
+	// Automation URLs may only write to folders picked here
+	if selection != "" {
+		if err := a.db.RememberOutputFolder(filepath.Clean(selection)); err != nil {
+			a.config.Logger.Warn("Failed to remember output folder", "dir", selection, "error", err)
+		}
+	}
+
 	return selection, nil
 }
 
@@ -60,4 +85,30 @@ func (a *App) ShowSaveDialog(filename string) (string, error) {
 func (a *App) OpenFile(filePath string) error {
 	wailsruntime.BrowserOpenURL(a.ctx, "file://"+filePath)
 	return nil
-}
\ No newline at end of file
+}
+
+// RevealInFinder shows a file selected in Finder, or in Explorer on Windows
+func (a *App) RevealInFinder(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return common.WrapError(common.ErrInvalidRequest, "file not found", err)
+	}
+	return platform.RevealInFileManager(path)
+}
+
+// MoveToTrash moves a PDF to the Trash, where it can be put back from Finder.
+// Only PDF files are accepted so originals are never deleted outright.
+func (a *App) MoveToTrash(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return common.WrapError(common.ErrInvalidRequest, "file not found", err)
+	}
+	if info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".pdf") {
+		return common.NewError(common.ErrInvalidRequest, "only PDF files can be moved to the Trash")
+	}
+
+	if err := platform.MoveToTrash(path); err != nil {
+		return common.WrapError(common.ErrUnknown, "failed to move file to the Trash", err)
+	}
+	a.config.Logger.Info("Moved file to the Trash", "file", path)
+	return nil
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/email"
+	"kleinpdf/internal/platform"
+)
+
+const (
+	// EmailMethodDraft opens a draft in the user's mail client
+	EmailMethodDraft = "draft"
+	// EmailMethodSMTP sends directly through the SMTP server in the preferences
+	EmailMethodSMTP = "smtp"
+
+	// emailAttachmentLimit matches the largest email preset, the limit most
+	// mail providers put on a message
+	emailAttachmentLimit = 25 * 1000 * 1000
+)
+
+// EmailRequest asks for compressed files to be emailed
+type EmailRequest struct {
+	Files   []string `json:"files"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+	Method  string   `json:"method"` // draft or smtp
+}
+
+// EmailCompressedFiles attaches compressed files to an email, either as a
+// draft in the default mail client or sent straight through SMTP. Where no
+// mail client accepts attachments, a mailto: draft is opened and the file is
+// shown in the file manager to be attached by hand.
+func (a *App) EmailCompressedFiles(request EmailRequest) error {
+	if len(request.Files) == 0 {
+		return common.NewError(common.ErrInvalidRequest, "no files given")
+	}
+
+	var total int64
+	for _, file := range request.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return common.WrapError(common.ErrInvalidRequest, "file not found", err)
+		}
+		total += info.Size()
+	}
+	if total > emailAttachmentLimit {
+		return common.NewError(common.ErrInvalidRequest, fmt.Sprintf(
+			"attachments total %.1f MB, more than the %d MB most mail servers accept",
+			float64(total)/(1000*1000), emailAttachmentLimit/(1000*1000)))
+	}
+
+	subject := request.Subject
+	if subject == "" {
+		subject = filepath.Base(request.Files[0])
+		if len(request.Files) > 1 {
+			subject = fmt.Sprintf("%d PDFs", len(request.Files))
+		}
+	}
+
+	switch request.Method {
+	case EmailMethodSMTP:
+		return a.sendEmail(request, subject)
+	case "", EmailMethodDraft:
+		err := platform.ComposeEmail(request.To, subject, request.Body, request.Files)
+		if err == platform.ErrComposeUnsupported {
+			return a.composeMailto(request, subject)
+		}
+		return err
+	default:
+		return common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown email method %q", request.Method))
+	}
+}
+
+// sendEmail sends the files through the SMTP server in the preferences
+func (a *App) sendEmail(request EmailRequest, subject string) error {
+	prefs, err := a.db.GetPreferences()
+	if err != nil {
+		return err
+	}
+
+	password, err := readSecret(smtpSecretKey)
+	if err != nil {
+		return fmt.Errorf("failed to read the SMTP password: %v", err)
+	}
+
+	err = email.Send(a.ctx, email.Config{
+		Host:     prefs.SMTPHost,
+		Port:     prefs.SMTPPort,
+		Username: prefs.SMTPUsername,
+		Password: password,
+		From:     prefs.SMTPFrom,
+		Security: prefs.SMTPSecurity,
+	}, email.Message{
+		To:          request.To,
+		Subject:     subject,
+		Body:        request.Body,
+		Attachments: request.Files,
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to send email", "recipients", len(request.To), "error", err)
+		return err
+	}
+
+	a.config.Logger.Info("Sent compressed files by email", "files", len(request.Files), "recipients", len(request.To))
+	return nil
+}
+
+// composeMailto opens a mailto: draft, which cannot carry attachments, and
+// reveals the first file so it can be dragged into the message
+func (a *App) composeMailto(request EmailRequest, subject string) error {
+	query := url.Values{}
+	query.Set("subject", subject)
+	if request.Body != "" {
+		query.Set("body", request.Body)
+	}
+	mailto := "mailto:" + url.PathEscape(strings.Join(request.To, ",")) + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	wailsruntime.BrowserOpenURL(a.ctx, mailto)
+	return platform.RevealInFileManager(request.Files[0])
+}
@@ -0,0 +1,246 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"kleinpdf/internal/common"
+)
+
+const (
+	// eventsPath is the route that streams progress events over SSE
+	eventsPath = "/events"
+
+	// eventStreamBuffer is how many events a slow client may fall behind by
+	// before progress updates are dropped for it
+	eventStreamBuffer = 64
+
+	// eventKeepAlive is how often an idle stream sends a comment line
+	eventKeepAlive = 15 * time.Second
+
+	// eventServerShutdownTimeout bounds how long open streams delay stopping
+	// the server
+	eventServerShutdownTimeout = 2 * time.Second
+)
+
+// EventStreamInfo tells a client where to connect to the event stream and
+// the token to send as "Authorization: Bearer <token>"
+type EventStreamInfo struct {
+	Running bool   `json:"running"`
+	URL     string `json:"url,omitempty"`
+	Token   string `json:"token,omitempty"`
+}
+
+// streamedEvents are the events forwarded to event stream clients
+var streamedEvents = []string{
+	"batch:started",
+	"compression:progress",
+	"file:progress",
+	"batch:summary",
+	"batch:paused",
+	"batch:resumed",
+}
+
+// streamEvent is one event sent to stream clients
+type streamEvent struct {
+	name string
+	data interface{}
+}
+
+// eventHub fans events out to stream clients, each optionally limited to one batch
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan streamEvent]string
+}
+
+// subscribe registers a client for the events of batchID, or of every batch
+// when batchID is empty
+func (h *eventHub) subscribe(batchID string) chan streamEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers == nil {
+		h.subscribers = make(map[chan streamEvent]string)
+	}
+	events := make(chan streamEvent, eventStreamBuffer)
+	h.subscribers[events] = batchID
+	return events
+}
+
+// unsubscribe removes a client
+func (h *eventHub) unsubscribe(events chan streamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, events)
+}
+
+// publish sends an event to the clients following its batch. A client that
+// is not keeping up misses the event rather than stalling compression.
+func (h *eventHub) publish(name string, data interface{}) {
+	batchID := ""
+	if fields, ok := data.(map[string]interface{}); ok {
+		batchID, _ = fields["batch_id"].(string)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for events, filter := range h.subscribers {
+		if filter != "" && filter != batchID {
+			continue
+		}
+		select {
+		case events <- streamEvent{name: name, data: data}:
+		default:
+		}
+	}
+}
+
+// streamEvents forwards progress events emitted to the frontend to stream clients
+func (a *App) streamEvents() {
+	for _, name := range streamedEvents {
+		name := name
+		wailsruntime.EventsOn(a.ctx, name, func(data ...interface{}) {
+			if len(data) > 0 {
+				a.events.publish(name, data[0])
+			}
+		})
+	}
+}
+
+// eventsHandler streams progress events as server-sent events to clients
+// holding the server's token
+type eventsHandler struct {
+	a     *App
+	token string
+}
+
+func (h *eventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "events must use GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	events := h.a.events.subscribe(r.URL.Query().Get("batch_id"))
+	defer h.a.events.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flush()
+		case event := <-events:
+			data, err := json.Marshal(event.data)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, data); err != nil {
+				return
+			}
+			flush()
+		}
+	}
+}
+
+// applyEventStreamPort starts the event stream server on port of the loopback
+// interface, or stops it when port is 0. Each start issues a new token, so
+// clients of a previous server must fetch it again.
+func (a *App) applyEventStreamPort(port int) error {
+	a.eventServerMu.Lock()
+	defer a.eventServerMu.Unlock()
+
+	a.stopEventServerLocked()
+	if port == 0 {
+		return nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(secret)
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return common.WrapError(common.ErrInvalidRequest, fmt.Sprintf("event stream port %d is unavailable", port), err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(eventsPath, &eventsHandler{a: a, token: token})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.config.Logger.Error("Event stream server stopped", "error", err)
+		}
+	}()
+
+	a.eventServer = server
+	a.eventStream = EventStreamInfo{
+		Running: true,
+		URL:     "http://" + listener.Addr().String() + eventsPath,
+		Token:   token,
+	}
+	a.config.Logger.Info("Event stream server started", "address", listener.Addr().String())
+	return nil
+}
+
+// stopEventServerLocked closes the event stream server and its open streams.
+// The caller holds eventServerMu.
+func (a *App) stopEventServerLocked() {
+	if a.eventServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventServerShutdownTimeout)
+	defer cancel()
+	if err := a.eventServer.Shutdown(ctx); err != nil {
+		a.eventServer.Close()
+	}
+	a.eventServer = nil
+	a.eventStream = EventStreamInfo{}
+	a.config.Logger.Info("Event stream server stopped")
+}
+
+// GetEventStreamInfo returns the address and token of the event stream
+// server, which runs while the event_stream_port preference is set
+func (a *App) GetEventStreamInfo() EventStreamInfo {
+	a.eventServerMu.Lock()
+	defer a.eventServerMu.Unlock()
+	return a.eventStream
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// scanProgressInterval is how many directories are walked between scan:progress events
+const scanProgressInterval = 25
+
+// imageExtensions are the names of the image formats converted to PDF, as
+// offered in the file dialog
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".tif", ".tiff", ".webp"}
+
+// officeExtensions are the names of the document formats LibreOffice converts
+var officeExtensions = []string{".docx", ".xlsx", ".pptx", ".doc", ".rtf", ".odt", ".ods", ".odp"}
+
+// isInputFile reports whether a file found in a directory is one the
+// compressor takes, going by its extension. Documents are only taken when
+// LibreOffice is installed to convert them.
+func (a *App) isInputFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".pdf", slices.Contains(imageExtensions, ext):
+		return true
+	case slices.Contains(officeExtensions, ext):
+		return a.compressor.IsOfficeAvailable()
+	}
+	return false
+}
+
+// expandInputs replaces directories in files with the PDFs, images and documents they contain.
+// maxDepth limits recursion (0 means unlimited, 1 means only the directory itself)
+// and excludes are glob patterns matched against names and paths relative to the directory.
+// The returned map gives each expanded file's directory relative to the parent of
+// the directory it was found in, e.g. "Archive/2020".
+func (a *App) expandInputs(files []string, maxDepth int, excludes []string) ([]string, map[string]string) {
+	var expanded []string
+	subdirs := make(map[string]string)
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || !info.IsDir() {
+			// Leave files (and unreadable paths) for the compressor to report
+			expanded = append(expanded, file)
+			continue
+		}
+
+		parent := filepath.Dir(filepath.Clean(file))
+		for _, found := range a.walkDirectory(file, maxDepth, excludes) {
+			if relDir, err := filepath.Rel(parent, filepath.Dir(found)); err == nil {
+				subdirs[found] = relDir
+			}
+			expanded = append(expanded, found)
+		}
+	}
+
+	return expanded, subdirs
+}
+
+// walkDirectory collects the PDFs, images and documents under root, emitting scan:progress events
+func (a *App) walkDirectory(root string, maxDepth int, excludes []string) []string {
+	var found []string
+	dirsScanned := 0
+
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			a.config.Logger.Warn("Failed to read path while scanning", "path", path, "error", err)
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(root, path)
+		if path != root && isExcluded(relPath, entry.Name(), excludes) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			depth := 0
+			if relPath != "." {
+				depth = strings.Count(relPath, string(filepath.Separator)) + 1
+			}
+			if maxDepth > 0 && depth >= maxDepth {
+				return filepath.SkipDir
+			}
+
+			dirsScanned++
+			if dirsScanned%scanProgressInterval == 0 {
+				a.emitScanProgress(root, path, dirsScanned, len(found), false)
+			}
+			return nil
+		}
+
+		if entry.Type().IsRegular() && a.isInputFile(path) {
+			found = append(found, path)
+		}
+		return nil
+	})
+
+	a.emitScanProgress(root, root, dirsScanned, len(found), true)
+	a.config.Logger.Info("Expanded directory input", "directory", root, "input_files", len(found))
+	return found
+}
+
+// emitScanProgress reports directory walking progress to the frontend
+func (a *App) emitScanProgress(root, current string, dirsScanned, filesFound int, done bool) {
+	wailsruntime.EventsEmit(a.ctx, "scan:progress", map[string]interface{}{
+		"directory":    root,
+		"current":      current,
+		"dirs_scanned": dirsScanned,
+		"files_found":  filesFound,
+		"done":         done,
+	})
+}
+
+// isExcluded matches a path against exclusion globs by name and by relative path
+func isExcluded(relPath, name string, excludes []string) bool {
+	for _, pattern := range excludes {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, relPath); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// pathLocks lets one file at a time hold a path, across every batch running
+// in the process, so overlapping batches never work on the same file at once
+type pathLocks struct {
+	mu   sync.Mutex
+	held map[string]chan struct{}
+}
+
+// acquire waits until no other file holds key, then holds it until the
+// returned function is called
+func (l *pathLocks) acquire(ctx context.Context, key string) (func(), error) {
+	for {
+		l.mu.Lock()
+		released, busy := l.held[key]
+		if !busy {
+			if l.held == nil {
+				l.held = make(map[string]chan struct{})
+			}
+			done := make(chan struct{})
+			l.held[key] = done
+			l.mu.Unlock()
+			return func() {
+				l.mu.Lock()
+				delete(l.held, key)
+				l.mu.Unlock()
+				close(done)
+			}, nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// lockKey resolves path to the form two references to the same file share:
+// absolute, with symlinks followed, and case-folded where the default
+// filesystem ignores case
+func lockKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	} else if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		// Outputs do not exist yet, but their folder may be a symlink
+		path = filepath.Join(dir, filepath.Base(path))
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+	return path
+}
@@ -0,0 +1,18 @@
+package app
+
+import (
+	"kleinpdf/internal/pdfops"
+)
+
+// ListFonts reports the embedded, subset and missing fonts of a document, which
+// explains what the EmbedFonts option changes and which files may render
+// differently on other machines
+func (a *App) ListFonts(path string) (*pdfops.FontReport, error) {
+	report, err := a.pdfops.ListFonts(path)
+	if err != nil {
+		a.config.Logger.Error("Failed to list fonts", "file", path, "error", err)
+		return nil, err
+	}
+
+	return report, nil
+}
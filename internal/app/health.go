@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"kleinpdf/internal/compression"
+)
+
+// healthCheckTimeout bounds the startup Ghostscript test compression
+const healthCheckTimeout = 30 * time.Second
+
+// checkGhostscriptHealth detects the Ghostscript version and runs a test compression
+func (a *App) checkGhostscriptHealth() {
+	ctx, cancel := context.WithTimeout(a.ctx, healthCheckTimeout)
+	defer cancel()
+
+	health := a.compressor.CheckHealth(ctx)
+	if health.Healthy {
+		a.config.Logger.Info("Ghostscript health check passed", "version", health.Version)
+	} else {
+		a.config.Logger.Error("Ghostscript health check failed", "version", health.Version, "error", health.Error)
+	}
+
+	a.healthMu.Lock()
+	a.health = health
+	a.healthMu.Unlock()
+}
+
+// ghostscriptHealth returns the result of the last health check
+func (a *App) ghostscriptHealth() compression.HealthStatus {
+	a.healthMu.RLock()
+	defer a.healthMu.RUnlock()
+	return a.health
+}
+
+// applyGhostscriptPath switches to a user-provided Ghostscript, or back to the
+// embedded binary when path is empty or fails validation
+func (a *App) applyGhostscriptPath(path string) {
+	if path != "" {
+		version, err := compression.ValidateGhostscript(a.ctx, path)
+		if err != nil {
+			a.config.Logger.Warn("Configured Ghostscript unusable, using embedded binary", "path", path, "error", err)
+			path = ""
+		} else {
+			a.config.Logger.Info("Using configured Ghostscript", "path", path, "version", version)
+		}
+	}
+	if path == "" {
+		path = a.config.GhostscriptPath
+	}
+
+	a.compressor.SetGhostscriptPath(path)
+	a.pdfops.SetGhostscriptPath(path)
+}
+
+// OnDomReady reports setup problems and interrupted work once the frontend can receive events
+func (a *App) OnDomReady(ctx context.Context) {
+	if a.config == nil {
+		return
+	}
+
+	setup := a.config.GhostscriptSetup
+	wailsruntime.EventsEmit(a.ctx, "setup:ghostscript", map[string]interface{}{
+		"source":   setup.Source,
+		"path":     setup.Path,
+		"repaired": setup.Repaired,
+		"error":    setup.Error,
+	})
+
+	if a.db != nil {
+		a.emitRestorableSession()
+	}
+
+	// Hide the window now that it exists if the app lives in the menu bar
+	a.setupMenuBar()
+
+	// Files opened from Finder or the command line before the UI loaded
+	a.flushOpenedFiles()
+
+	health := a.ghostscriptHealth()
+	if a.compressor == nil || health.Healthy {
+		return
+	}
+
+	wailsruntime.EventsEmit(a.ctx, "setup:error", map[string]interface{}{
+		"component": "ghostscript",
+		"version":   health.Version,
+		"error":     health.Error,
+	})
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+	"kleinpdf/internal/database"
+)
+
+const (
+	// defaultHistoryPageSize is used when a search does not give a page size
+	defaultHistoryPageSize = 50
+
+	// maxHistoryPageSize caps the entries returned by one search
+	maxHistoryPageSize = 500
+)
+
+// recordHistory stores the finished files of a batch. Cancelled files are left
+// out since they will run again when the batch is resumed.
+func (a *App) recordHistory(batchID string, results []FileResult) {
+	var entries []database.HistoryEntry
+	for _, result := range results {
+		if result.Status == "cancelled" {
+			continue
+		}
+		entries = append(entries, database.HistoryEntry{
+			BatchID:          batchID,
+			OriginalFilename: result.OriginalFilename,
+			OriginalPath:     result.OriginalPath,
+			CompressedPath:   result.CompressedPath,
+			OriginalSize:     result.OriginalSize,
+			CompressedSize:   result.CompressedSize,
+			CompressionRatio: result.CompressionRatio,
+			CompressionLevel: result.CompressionLevel,
+			Status:           result.Status,
+			Error:            result.Error,
+			DurationSeconds:  result.DurationSeconds,
+			ThroughputMBps:   result.ThroughputMBps,
+			OriginalHash:     result.inputHash,
+			BackupPath:       result.backupPath,
+			OptionsJSON:      historyOptionsJSON(result.options),
+		})
+	}
+
+	if err := a.db.SaveHistoryEntries(entries); err != nil {
+		a.config.Logger.Error("Failed to record compression history", "batch_id", batchID, "error", err)
+	}
+}
+
+// SearchHistory returns one page of the compression history, newest first.
+// The query matches filenames; filter narrows by level, status and date range.
+// Pages start at 1.
+func (a *App) SearchHistory(query string, filter database.HistoryFilter, page, pageSize int) (*HistoryPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultHistoryPageSize
+	}
+	if pageSize > maxHistoryPageSize {
+		pageSize = maxHistoryPageSize
+	}
+	filter.Query = query
+
+	entries, total, err := a.db.SearchHistory(filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HistoryPage{
+		Entries:  entries,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// RecompressFromHistory compresses the original file of a history entry again
+// at level, reusing the options recorded for it. An empty level repeats the
+// previous one. Passwords are never recorded and must be entered again.
+func (a *App) RecompressFromHistory(entryID uint, level string) CompressionResponse {
+	entry, err := a.db.GetHistoryEntry(entryID)
+	if err != nil {
+		return CompressionResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("history entry %d not found", entryID),
+			ErrorCode: common.ErrInvalidRequest,
+		}
+	}
+
+	if err := verifyHistoryOriginal(entry); err != nil {
+		return CompressionResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	var options *compression.CompressionOptions
+	if entry.OptionsJSON != "" {
+		options = &compression.CompressionOptions{}
+		if err := json.Unmarshal([]byte(entry.OptionsJSON), options); err != nil {
+			a.config.Logger.Warn("Ignoring unreadable history options", "entry_id", entryID, "error", err)
+			options = nil
+		}
+	}
+
+	if level == "" {
+		level = entry.CompressionLevel
+	}
+
+	request := CompressionRequest{
+		Files:            []string{entry.OriginalPath},
+		CompressionLevel: level,
+		AdvancedOptions:  options,
+	}
+	if entry.CompressedPath != "" {
+		request.OutputDir = filepath.Dir(entry.CompressedPath)
+	}
+
+	return a.CompressPDF(request)
+}
+
+// verifyHistoryOriginal checks that the original file of an entry still exists
+// and, when its hash was recorded, has not been changed since
+func verifyHistoryOriginal(entry *database.HistoryEntry) error {
+	if _, err := os.Stat(entry.OriginalPath); err != nil {
+		return common.WrapError(common.ErrInvalidRequest, "original file is no longer available", err)
+	}
+
+	if entry.OriginalHash == "" {
+		return nil
+	}
+	hash, err := common.HashFile(entry.OriginalPath)
+	if err != nil {
+		return err
+	}
+	if hash != entry.OriginalHash {
+		return common.NewError(common.ErrInvalidRequest, "original file has changed since it was compressed")
+	}
+	return nil
+}
+
+// historyOptionsJSON encodes options for the history without any passwords
+func historyOptionsJSON(options *compression.CompressionOptions) string {
+	if options == nil {
+		return ""
+	}
+
+	recorded := *options
+	recorded.InputPassword = ""
+	recorded.OwnerPassword = ""
+	recorded.UserPassword = ""
+
+	data, err := json.Marshal(recorded)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
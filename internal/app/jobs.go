@@ -0,0 +1,144 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// PendingBatch describes a batch interrupted by a quit or crash
+type PendingBatch struct {
+	BatchID      string    `json:"batch_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	TotalFiles   int       `json:"total_files"`
+	PendingFiles []string  `json:"pending_files"`
+}
+
+// GetPendingBatches returns batches that were interrupted before finishing
+func (a *App) GetPendingBatches() ([]PendingBatch, error) {
+	jobs, err := a.db.GetBatchJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []PendingBatch
+	for _, job := range jobs {
+		// Skip batches that are still running in this session
+		if _, err := a.getBatch(job.ID); err == nil {
+			continue
+		}
+
+		batch := PendingBatch{
+			BatchID:    job.ID,
+			CreatedAt:  job.CreatedAt,
+			TotalFiles: len(job.Files),
+		}
+		for _, file := range job.Files {
+			if file.Status == "pending" {
+				batch.PendingFiles = append(batch.PendingFiles, file.FilePath)
+			}
+		}
+		if len(batch.PendingFiles) > 0 {
+			pending = append(pending, batch)
+		}
+	}
+
+	return pending, nil
+}
+
+// ResumePendingBatch compresses the remaining files of an interrupted batch
+func (a *App) ResumePendingBatch(batchID string) CompressionResponse {
+	job, err := a.db.GetBatchJob(batchID)
+	if err != nil {
+		return CompressionResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("pending batch %s not found", batchID),
+			ErrorCode: common.ErrInvalidRequest,
+		}
+	}
+
+	var request CompressionRequest
+	if err := json.Unmarshal([]byte(job.RequestJSON), &request); err != nil {
+		return CompressionResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("failed to read pending batch: %v", err),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	request.BatchID = batchID
+	request.Files = nil
+	for _, file := range job.Files {
+		if file.Status == "pending" {
+			request.Files = append(request.Files, file.FilePath)
+		}
+	}
+
+	a.config.Logger.Info("Resuming pending batch", "batch_id", batchID, "files", len(request.Files))
+	return a.CompressPDF(request)
+}
+
+// DiscardPendingBatch forgets an interrupted batch
+func (a *App) DiscardPendingBatch(batchID string) error {
+	return a.db.DeleteBatchJob(batchID)
+}
+
+// persistBatch stores the batch so it can be resumed after a restart. Batches
+// that encrypt their output are not stored to keep passwords off disk.
+func (a *App) persistBatch(batchID string, request CompressionRequest) {
+	if requestHasPasswords(request) {
+		a.config.Logger.Info("Not persisting batch with output passwords", "batch_id", batchID)
+		return
+	}
+
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		a.config.Logger.Warn("Failed to encode batch for persistence", "batch_id", batchID, "error", err)
+		return
+	}
+
+	if err := a.db.SaveBatchJob(batchID, string(requestJSON), request.Files); err != nil {
+		a.config.Logger.Warn("Failed to persist batch", "batch_id", batchID, "error", err)
+	}
+}
+
+// markBatchFile records the outcome of a file in the persisted batch
+func (a *App) markBatchFile(batchID, filePath, status string) {
+	if err := a.db.UpdateBatchJobFile(batchID, filePath, status); err != nil {
+		a.config.Logger.Warn("Failed to update persisted batch", "batch_id", batchID, "file", filePath, "error", err)
+	}
+}
+
+// finishPersistedBatch drops the persisted batch unless the app is shutting
+// down, in which case the remaining files stay queued for the next launch
+func (a *App) finishPersistedBatch(batchID string) {
+	if a.ctx.Err() != nil {
+		return
+	}
+
+	if err := a.db.DeleteBatchJob(batchID); err != nil {
+		a.config.Logger.Warn("Failed to remove persisted batch", "batch_id", batchID, "error", err)
+	}
+}
+
+// requestHasPasswords reports whether any options in the request carry passwords
+func requestHasPasswords(request CompressionRequest) bool {
+	hasPasswords := func(options *compression.CompressionOptions) bool {
+		return options != nil && (options.InputPassword != "" || options.OwnerPassword != "" || options.UserPassword != "")
+	}
+
+	if hasPasswords(request.AdvancedOptions) {
+		return true
+	}
+	for _, override := range request.FileOverrides {
+		for _, key := range []string{"input_password", "owner_password", "user_password"} {
+			if password, ok := override.AdvancedOptions[key].(string); ok && password != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
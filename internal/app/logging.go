@@ -0,0 +1,195 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// logFileName is the active log file in the app data logs directory
+	logFileName = "kleinpdf.log"
+
+	// maxLogFileSize is the size at which the log file is rotated
+	maxLogFileSize = 5 * 1024 * 1024
+
+	// maxLogBackups is how many rotated log files are kept
+	maxLogBackups = 3
+)
+
+// rotatingFile is an append-only log file that is renamed to .1, .2, ...
+// once it reaches maxLogFileSize
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens or creates the log file at path
+func openRotatingFile(path string) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	r := &rotatingFile{path: path}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if it would push the file over the limit
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size+int64(len(p)) > maxLogFileSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups up by one and starts a new log file
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+
+	os.Remove(fmt.Sprintf("%s.%d", r.path, maxLogBackups))
+	for i := maxLogBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	os.Rename(r.path, r.path+".1")
+
+	return r.open()
+}
+
+// setupLogging writes JSON logs to a rotating file in the app data directory,
+// mirrored to stderr for development. Logging falls back to stderr alone when
+// the file cannot be opened.
+func (c *Config) setupLogging() {
+	c.LogLevel = new(slog.LevelVar)
+	c.LogPath = filepath.Join(getAppDataDir(), "logs", logFileName)
+
+	var writer io.Writer = os.Stderr
+	file, err := openRotatingFile(c.LogPath)
+	if err == nil {
+		writer = io.MultiWriter(os.Stderr, file)
+	}
+
+	c.Logger = slog.New(slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: c.LogLevel}))
+	slog.SetDefault(c.Logger)
+
+	if err != nil {
+		c.Logger.Error("Failed to open log file, logging to stderr only", "path", c.LogPath, "error", err)
+	}
+}
+
+// parseLogLevel maps a log level preference to its slog level
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// applyLogLevel changes the minimum level written to the log
+func (a *App) applyLogLevel(level string) {
+	parsed, err := parseLogLevel(level)
+	if err != nil {
+		a.config.Logger.Warn("Invalid log level preference, using info", "error", err)
+	}
+	a.config.LogLevel.Set(parsed)
+}
+
+// GetRecentLogs returns up to n of the most recent log records, oldest first
+func (a *App) GetRecentLogs(n int) ([]map[string]interface{}, error) {
+	if n <= 0 {
+		return []map[string]interface{}{}, nil
+	}
+
+	// Read the active file, then older backups until n lines are found
+	var lines []string
+	for i := 0; i <= maxLogBackups && len(lines) < n; i++ {
+		path := a.config.LogPath
+		if i > 0 {
+			path = fmt.Sprintf("%s.%d", path, i)
+		}
+
+		fileLines, err := readLogLines(path)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(fileLines, lines...)
+	}
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	records := make([]map[string]interface{}, 0, len(lines))
+	for _, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			record = map[string]interface{}{"msg": line} // Keep lines written by older versions
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// readLogLines returns the non-empty lines of a log file
+func readLogLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
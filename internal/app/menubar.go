@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"kleinpdf/internal/platform"
+)
+
+// setupMenuBar connects the menu bar icon and enters menu bar mode if it was
+// left on. It runs once the window exists so the window can be hidden.
+func (a *App) setupMenuBar() {
+	platform.SetStatusItemHandlers(platform.StatusItemHandlers{
+		OnDrop: a.compressFromMenuBar,
+		OnShow: func() {
+			wailsruntime.WindowShow(a.ctx)
+			wailsruntime.WindowUnminimise(a.ctx)
+		},
+		OnQuit: func() {
+			a.quitting.Store(true)
+			wailsruntime.Quit(a.ctx)
+		},
+	})
+
+	if prefs, err := a.db.GetPreferences(); err == nil && prefs.MenuBarMode {
+		a.applyMenuBarMode(true)
+	}
+}
+
+// applyMenuBarMode moves the app between the Dock and the menu bar. In menu
+// bar mode the main window is hidden until it is opened from the icon's menu.
+func (a *App) applyMenuBarMode(enabled bool) {
+	if !enabled {
+		a.menuBarMode.Store(false)
+		platform.HideStatusItem()
+		wailsruntime.WindowShow(a.ctx)
+		return
+	}
+
+	if err := platform.ShowStatusItem(); err != nil {
+		a.config.Logger.Warn("Menu bar mode is unavailable", "error", err)
+		return
+	}
+	a.menuBarMode.Store(true)
+	wailsruntime.WindowHide(a.ctx)
+}
+
+// OnBeforeClose hides the window instead of quitting while in menu bar mode
+func (a *App) OnBeforeClose(ctx context.Context) (prevent bool) {
+	if !a.menuBarMode.Load() || a.quitting.Load() {
+		return false
+	}
+	wailsruntime.WindowHide(ctx)
+	return true
+}
+
+// compressFromMenuBar compresses files dropped on the menu bar icon with the
+// saved preferences and reports the savings in a notification
+func (a *App) compressFromMenuBar(paths []string) {
+	defer a.recoverPanic("menu bar", nil)
+
+	a.config.Logger.Info("Compressing files dropped on the menu bar icon", "files", len(paths))
+	wailsruntime.EventsEmit(a.ctx, "menubar:compress", map[string]interface{}{
+		"files": paths,
+	})
+
+	response := a.CompressPDF(CompressionRequest{
+		Files:           paths,
+		AdvancedOptions: a.savedCompressionOptions(),
+	})
+	if !response.Success {
+		a.config.Logger.Error("Menu bar compression failed", "error", response.Error)
+		platform.Notify("Compression failed", response.Error)
+		return
+	}
+
+	var compressed, failed int
+	for _, file := range response.Files {
+		switch file.Status {
+		case "error":
+			failed++
+		case "cancelled":
+		default:
+			compressed++
+		}
+	}
+
+	title := fmt.Sprintf("Compressed %d PDF", compressed)
+	if compressed != 1 {
+		title += "s"
+	}
+	saved := response.TotalOriginalSize - response.TotalCompressedSize
+	body := fmt.Sprintf("Saved %.1f MB (%.0f%%)", float64(saved)/(1024*1024), response.OverallCompressionRatio)
+	if failed > 0 {
+		body += fmt.Sprintf(", %d failed", failed)
+	}
+	platform.Notify(title, body)
+}
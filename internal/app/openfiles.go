@@ -0,0 +1,119 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// OnFileOpen receives a file opened with KleinPDF from Finder, the Dock icon
+// or an "Open With" menu. macOS can deliver files before the frontend has
+// loaded, so they are queued until it is ready.
+func (a *App) OnFileOpen(path string) {
+	a.queueOpenedFiles([]string{path})
+}
+
+// OnSecondInstanceLaunch forwards the files of a second launch, e.g. a
+// double-clicked PDF on Windows, to the running instance and raises its window
+func (a *App) OnSecondInstanceLaunch(data options.SecondInstanceData) {
+	files := openedFileArgs(data.Args, data.WorkingDirectory)
+	if a.ctx != nil {
+		wailsruntime.WindowUnminimise(a.ctx)
+		wailsruntime.Show(a.ctx)
+	}
+	a.queueOpenedFiles(files)
+
+	// Windows passes kleinpdf:// URLs to a new process
+	for _, rawURL := range automationURLArgs(data.Args) {
+		a.OnUrlOpen(rawURL)
+	}
+}
+
+// TakeOpenedFiles returns and clears files opened before the frontend started listening
+func (a *App) TakeOpenedFiles() []string {
+	a.openedMu.Lock()
+	defer a.openedMu.Unlock()
+
+	files := a.openedFiles
+	a.openedFiles = nil
+	return files
+}
+
+// queueOpenedFiles emits the files to the frontend, or holds them until it is ready
+func (a *App) queueOpenedFiles(files []string) {
+	if len(files) == 0 {
+		return
+	}
+
+	a.openedMu.Lock()
+	if !a.frontendReady {
+		a.openedFiles = append(a.openedFiles, files...)
+		a.openedMu.Unlock()
+		return
+	}
+	a.openedMu.Unlock()
+
+	a.emitOpenedFiles(files)
+}
+
+// flushOpenedFiles marks the frontend ready and handles the files and URLs
+// queued before it was
+func (a *App) flushOpenedFiles() {
+	a.openedMu.Lock()
+	a.frontendReady = true
+	files, urls := a.openedFiles, a.openedURLs
+	a.openedFiles, a.openedURLs = nil, nil
+	a.openedMu.Unlock()
+
+	if len(files) > 0 {
+		a.emitOpenedFiles(files)
+	}
+	for _, rawURL := range urls {
+		a.runAutomationURL(rawURL)
+	}
+}
+
+// emitOpenedFiles asks the frontend to start compressing the files
+func (a *App) emitOpenedFiles(files []string) {
+	a.config.Logger.Info("Files opened with KleinPDF", "files", len(files))
+	wailsruntime.EventsEmit(a.ctx, "files:opened", map[string]interface{}{
+		"files": files,
+	})
+}
+
+// automationURLArgs picks the kleinpdf:// URLs out of command-line arguments
+func automationURLArgs(args []string) []string {
+	var urls []string
+	for _, arg := range args {
+		if strings.HasPrefix(strings.ToLower(arg), urlScheme+":") {
+			urls = append(urls, arg)
+		}
+	}
+	return urls
+}
+
+// openedFileArgs picks the PDFs and folders out of command-line arguments,
+// resolving relative paths against workingDir
+func openedFileArgs(args []string, workingDir string) []string {
+	var files []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if !filepath.IsAbs(arg) && workingDir != "" {
+			arg = filepath.Join(workingDir, arg)
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() || strings.EqualFold(filepath.Ext(arg), ".pdf") {
+			files = append(files, arg)
+		}
+	}
+	return files
+}
@@ -0,0 +1,426 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/pdfops"
+)
+
+// SplitPDF splits a document into one file per page range (e.g. "1-5", "6-")
+func (a *App) SplitPDF(file string, ranges []string) PageOperationResponse {
+	if len(ranges) == 0 {
+		return PageOperationResponse{
+			Success:   false,
+			Error:     "no page ranges provided",
+			ErrorCode: common.ErrInvalidRequest,
+		}
+	}
+
+	for _, pageRange := range ranges {
+		if err := pdfops.ValidatePageRange(pageRange); err != nil {
+			return PageOperationResponse{
+				Success:   false,
+				Error:     err.Error(),
+				ErrorCode: common.ErrorCodeOf(err),
+			}
+		}
+	}
+
+	var results []FileResult
+	for _, pageRange := range ranges {
+		suffix := "pages_" + strings.TrimSuffix(pageRange, "-")
+		if strings.HasSuffix(pageRange, "-") {
+			suffix += "-end"
+		}
+		_, outputPath := buildOutputPath(file, "", suffix)
+
+		result, err := a.runPageOperation(file, outputPath, func() error {
+			return a.pdfops.ExtractPages(a.ctx, file, outputPath, pageRange)
+		})
+		if err != nil {
+			a.config.Logger.Error("Failed to split PDF", "file", file, "range", pageRange, "error", err)
+			result = pageOperationError(file, err)
+		}
+		results = append(results, *result)
+	}
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   results,
+	}
+}
+
+// SplitBySize divides a document into numbered parts of consecutive pages,
+// each under maxMB megabytes, for upload portals that limit file size
+func (a *App) SplitBySize(input string, maxMB int) PageOperationResponse {
+	if maxMB <= 0 {
+		return PageOperationResponse{
+			Success:   false,
+			Error:     "size limit must be at least 1 MB",
+			ErrorCode: common.ErrInvalidRequest,
+		}
+	}
+
+	originalInfo, err := os.Stat(input)
+	if err != nil {
+		return PageOperationResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	// One timestamp keeps the parts of a split together when sorted by name
+	_, basePath := buildOutputPath(input, "", "part")
+	parts, err := a.pdfops.SplitBySize(input, int64(maxMB)*1024*1024, func(part int) string {
+		return fmt.Sprintf("%s%03d.pdf", strings.TrimSuffix(basePath, ".pdf"), part)
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to split PDF by size", "file", input, "max_mb", maxMB, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Files:     []FileResult{*pageOperationError(input, err)},
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	results := make([]FileResult, 0, len(parts))
+	for _, part := range parts {
+		results = append(results, FileResult{
+			FileID:             common.GenerateUUID(),
+			OriginalFilename:   filepath.Base(input),
+			OriginalPath:       input,
+			CompressedFilename: filepath.Base(part.Path),
+			OriginalSize:       originalInfo.Size(),
+			CompressedSize:     part.Size,
+			CompressedPath:     part.Path,
+			PageCount:          part.LastPage - part.FirstPage + 1,
+			Status:             "completed",
+		})
+	}
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   results,
+	}
+}
+
+// ExtractPages copies the given pages into a new PDF. When output is empty the
+// file is written next to the input using the compression naming convention.
+func (a *App) ExtractPages(input string, pages []int, output string) PageOperationResponse {
+	pageList, err := pdfops.PageList(pages)
+	if err != nil {
+		return PageOperationResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	if output == "" {
+		_, output = buildOutputPath(input, "", "extracted")
+	}
+
+	result, err := a.runPageOperation(input, output, func() error {
+		return a.pdfops.ExtractPages(a.ctx, input, output, pageList)
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to extract pages", "file", input, "pages", pageList, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Files:     []FileResult{*pageOperationError(input, err)},
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   []FileResult{*result},
+	}
+}
+
+// ResizePages writes a copy of the document with every page fitted to the
+// target paper size (e.g. "A4", "Letter") or scaled by a percentage (e.g. "50%")
+func (a *App) ResizePages(input string, target string) PageOperationResponse {
+	_, output := buildOutputPath(input, "", "resized")
+
+	result, err := a.runPageOperation(input, output, func() error {
+		return a.pdfops.ResizePages(a.ctx, input, output, target)
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to resize pages", "file", input, "target", target, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Files:     []FileResult{*pageOperationError(input, err)},
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   []FileResult{*result},
+	}
+}
+
+// NUp writes a copy of the document with n pages (2, 4 or 8) on each sheet
+func (a *App) NUp(input string, n int) PageOperationResponse {
+	_, output := buildOutputPath(input, "", fmt.Sprintf("%dup", n))
+
+	result, err := a.runPageOperation(input, output, func() error {
+		return a.pdfops.NUp(a.ctx, input, output, n)
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to impose pages", "file", input, "n", n, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Files:     []FileResult{*pageOperationError(input, err)},
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   []FileResult{*result},
+	}
+}
+
+// TrimMargins writes a copy of the document with each page cropped to its content
+func (a *App) TrimMargins(input string) PageOperationResponse {
+	_, output := buildOutputPath(input, "", "trimmed")
+
+	result, err := a.runPageOperation(input, output, func() error {
+		return a.compressor.TrimMargins(a.ctx, input, output, "")
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to trim margins", "file", input, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Files:     []FileResult{*pageOperationError(input, err)},
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   []FileResult{*result},
+	}
+}
+
+// StampPages stamps page or Bates numbers across a batch. Numbering continues
+// from one file to the next in the order given.
+func (a *App) StampPages(files []string, options pdfops.StampOptions) PageOperationResponse {
+	if len(files) == 0 {
+		return PageOperationResponse{
+			Success:   false,
+			Error:     "no files provided",
+			ErrorCode: common.ErrInvalidRequest,
+		}
+	}
+
+	next := options.Start
+	if next < 1 {
+		next = 1
+	}
+
+	var results []FileResult
+	for _, file := range files {
+		_, output := buildOutputPath(file, "", "stamped")
+
+		var stamped int
+		result, err := a.runPageOperation(file, output, func() error {
+			var err error
+			stamped, err = a.pdfops.StampPages(a.ctx, file, output, options, next)
+			return err
+		})
+		if err != nil {
+			a.config.Logger.Error("Failed to stamp pages", "file", file, "error", err)
+			return PageOperationResponse{
+				Success:   false,
+				Files:     append(results, *pageOperationError(file, err)),
+				Error:     err.Error(),
+				ErrorCode: common.ErrorCodeOf(err),
+			}
+		}
+		result.PageCount = stamped
+		results = append(results, *result)
+		next += stamped
+	}
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   results,
+	}
+}
+
+// MergePDFs combines the files in the order given into one document written
+// next to the first file, optionally with a bookmark and a contents page
+// entry for each source file
+func (a *App) MergePDFs(files []string, options pdfops.MergeOptions) PageOperationResponse {
+	if len(files) < 2 {
+		return PageOperationResponse{
+			Success:   false,
+			Error:     "at least two files are needed to merge",
+			ErrorCode: common.ErrInvalidRequest,
+		}
+	}
+
+	_, output := buildOutputPath(files[0], "", "merged")
+
+	var pageCount int
+	result, err := a.runPageOperation(files[0], output, func() error {
+		var err error
+		pageCount, err = a.pdfops.Merge(a.ctx, files, output, options)
+		return err
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to merge PDFs", "files", len(files), "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Files:     []FileResult{*pageOperationError(files[0], err)},
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+	result.PageCount = pageCount
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   []FileResult{*result},
+	}
+}
+
+// runPageOperation runs a page-level operation and builds the FileResult for its output
+func (a *App) runPageOperation(filePath, outputPath string, operation func() error) (*FileResult, error) {
+	originalInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := operation(); err != nil {
+		return nil, err
+	}
+
+	outputInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileResult{
+		FileID:             common.GenerateUUID(),
+		OriginalFilename:   filepath.Base(filePath),
+		OriginalPath:       filePath,
+		CompressedFilename: filepath.Base(outputPath),
+		OriginalSize:       originalInfo.Size(),
+		CompressedSize:     outputInfo.Size(),
+		CompressedPath:     outputPath,
+		Status:             "completed",
+	}, nil
+}
+
+// pageOperationError builds the error result for a failed page-level operation
+func pageOperationError(filePath string, err error) *FileResult {
+	return &FileResult{
+		FileID:           common.GenerateUUID(),
+		OriginalFilename: filepath.Base(filePath),
+		OriginalPath:     filePath,
+		Status:           "error",
+		Error:            err.Error(),
+		ErrorCode:        common.ErrorCodeOf(err),
+	}
+}
+
+// DeletePages writes a copy of the document without the given pages
+func (a *App) DeletePages(input string, pages []int) PageOperationResponse {
+	totalPages, err := a.pdfops.PageCount(a.ctx, input)
+	if err != nil {
+		a.config.Logger.Error("Failed to read page count", "file", input, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	remaining, err := pdfops.RemainingPages(totalPages, pages)
+	if err != nil {
+		return PageOperationResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	pageList, _ := pdfops.PageList(remaining)
+	_, output := buildOutputPath(input, "", "edited")
+
+	result, err := a.runPageOperation(input, output, func() error {
+		return a.pdfops.ExtractPages(a.ctx, input, output, pageList)
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to delete pages", "file", input, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Files:     []FileResult{*pageOperationError(input, err)},
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+	result.PageCount = len(remaining)
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   []FileResult{*result},
+	}
+}
+
+// ReorderPages writes a copy of the document with its pages in the given order
+func (a *App) ReorderPages(input string, newOrder []int) PageOperationResponse {
+	totalPages, err := a.pdfops.PageCount(a.ctx, input)
+	if err != nil {
+		a.config.Logger.Error("Failed to read page count", "file", input, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	if err := pdfops.ValidatePageOrder(totalPages, newOrder); err != nil {
+		return PageOperationResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	pageList, _ := pdfops.PageList(newOrder)
+	_, output := buildOutputPath(input, "", "reordered")
+
+	result, err := a.runPageOperation(input, output, func() error {
+		return a.pdfops.ExtractPages(a.ctx, input, output, pageList)
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to reorder pages", "file", input, "error", err)
+		return PageOperationResponse{
+			Success:   false,
+			Files:     []FileResult{*pageOperationError(input, err)},
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+	result.PageCount = totalPages
+
+	return PageOperationResponse{
+		Success: true,
+		Files:   []FileResult{*result},
+	}
+}
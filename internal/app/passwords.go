@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// ProvidePassword supplies the password for an encrypted input file. An empty
+// password skips the file.
+func (a *App) ProvidePassword(fileID, password string) error {
+	a.passwordsMu.Lock()
+	reply, ok := a.passwordRequests[fileID]
+	if ok {
+		delete(a.passwordRequests, fileID)
+	}
+	a.passwordsMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no password requested for file %s", fileID)
+	}
+
+	reply <- password
+	return nil
+}
+
+// resolveInputPassword asks the frontend for a password when the input cannot
+// be opened without one and returns options carrying it. Other inputs pass
+// through unchanged.
+func (a *App) resolveInputPassword(ctx context.Context, fileID, filePath string, options *compression.CompressionOptions) (*compression.CompressionOptions, error) {
+	if options != nil && options.InputPassword != "" {
+		return options, nil
+	}
+
+	needsPassword, err := compression.NeedsPassword(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !needsPassword {
+		return options, nil
+	}
+
+	reply := make(chan string, 1)
+	a.passwordsMu.Lock()
+	a.passwordRequests[fileID] = reply
+	a.passwordsMu.Unlock()
+
+	a.config.Logger.Info("Password required for encrypted input", "file", filePath, "file_id", fileID)
+	wailsruntime.EventsEmit(a.ctx, "file:password_required", map[string]interface{}{
+		"file_id":  fileID,
+		"filename": filepath.Base(filePath),
+	})
+
+	var password string
+	select {
+	case password = <-reply:
+	case <-ctx.Done():
+		a.passwordsMu.Lock()
+		delete(a.passwordRequests, fileID)
+		a.passwordsMu.Unlock()
+		return nil, ctx.Err()
+	}
+
+	if password == "" {
+		return nil, common.NewError(common.ErrEncryptedInput, "file is password protected")
+	}
+
+	// Copy the options so the password stays with this file only
+	fileOptions := compression.DefaultCompressionOptions()
+	if options != nil {
+		fileOptions = *options
+	}
+	fileOptions.InputPassword = password
+	return &fileOptions, nil
+}
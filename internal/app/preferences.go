@@ -1,15 +1,167 @@
 package app
 
 import (
+	"fmt"
+	"os"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
 	"kleinpdf/internal/database"
+	"kleinpdf/internal/platform"
 )
 
-// GetPreferences gets the current user preferences
+// GetPreferences gets the current user preferences. Passwords stay in the
+// system keychain and are never sent back to the frontend.
 func (a *App) GetPreferences() (*database.UserPreferencesData, error) {
-	return a.db.GetPreferences()
+	prefs, err := a.db.GetPreferences()
+	if err != nil {
+		return nil, err
+	}
+	return prefs, nil
 }
 
-// UpdatePreferences updates user preferences
+// UpdatePreferences validates and saves user preferences, then applies the
+// ones that configure logging and external tools. Invalid values are rejected
+// with an invalid_request error naming the preference.
 func (a *App) UpdatePreferences(data map[string]interface{}) error {
-	return a.db.UpdatePreferences(data)
-}
\ No newline at end of file
+	// Passwords are hidden from the frontend, so an empty one means unchanged
+	for _, key := range []string{"smtp_password", "signing_password"} {
+		if password, ok := data[key].(string); ok && password == "" {
+			delete(data, key)
+		}
+	}
+
+	// Reject a custom Ghostscript before saving it
+	if path, ok := data["ghostscript_path"].(string); ok && path != "" {
+		if _, err := compression.ValidateGhostscript(a.ctx, path); err != nil {
+			return err
+		}
+	}
+
+	// Passwords go to the system keychain, not the database
+	passwords := make(map[string]string)
+	for key := range preferenceSecretKeys {
+		if password, ok := data[key].(string); ok {
+			passwords[key] = password
+		}
+		delete(data, key)
+	}
+
+	if err := a.db.UpdatePreferences(data); err != nil {
+		return err
+	}
+
+	for key, password := range passwords {
+		if err := platform.SetSecret(preferenceSecretKeys[key], password); err != nil {
+			return fmt.Errorf("failed to save %s: %v", key, err)
+		}
+	}
+
+	if path, ok := data["ghostscript_path"].(string); ok {
+		a.applyGhostscriptPath(path)
+		a.checkGhostscriptHealth()
+	}
+
+	if level, ok := data["log_level"].(string); ok {
+		a.applyLogLevel(level)
+	}
+
+	if dir, ok := data["working_dir"].(string); ok {
+		a.applyWorkingDir(dir)
+	}
+
+	if enabled, ok := data["menu_bar_mode"].(bool); ok {
+		a.applyMenuBarMode(enabled)
+	}
+
+	if enabled, ok := data["secure_delete_temp"].(bool); ok {
+		common.SetSecureDelete(enabled)
+	}
+
+	if port, ok := data["event_stream_port"].(float64); ok {
+		if err := a.applyEventStreamPort(int(port)); err != nil {
+			return err
+		}
+	}
+
+	_, hasPriority := data["background_priority"]
+	_, hasMemory := data["max_memory_mb"]
+	if hasPriority || hasMemory {
+		prefs, err := a.db.GetPreferences()
+		if err != nil {
+			return err
+		}
+		a.applyResourceLimits(prefs)
+	}
+
+	return nil
+}
+
+// applyStoredPreferences configures logging and external tools from the saved preferences
+func (a *App) applyStoredPreferences() {
+	if prefs, err := a.db.GetPreferences(); err == nil {
+		if prefs.WorkingDir != "" {
+			a.config.WorkingDir = prefs.WorkingDir
+		}
+		a.applyResourceLimits(prefs)
+		a.applyLogLevel(prefs.LogLevel)
+		common.SetSecureDelete(prefs.SecureDeleteTemp)
+		if prefs.GhostscriptPath != "" {
+			a.applyGhostscriptPath(prefs.GhostscriptPath)
+		}
+	}
+	a.applyWorkingDir(a.config.WorkingDir)
+}
+
+// applyWorkingDir points intermediate files at dir, falling back to the system
+// temp directory when dir is empty or cannot be created
+func (a *App) applyWorkingDir(dir string) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, common.DefaultFilePermissions); err != nil {
+			a.config.Logger.Warn("Working directory unavailable, using system temp dir", "dir", dir, "error", err)
+			dir = ""
+		}
+	}
+
+	a.config.WorkingDir = dir
+	a.compressor.SetWorkingDir(dir)
+}
+
+// applyResourceLimits configures priority and memory limits for external tools
+func (a *App) applyResourceLimits(prefs *database.UserPreferencesData) {
+	limits := common.ResourceLimits{
+		BackgroundPriority: prefs.BackgroundPriority,
+		MaxMemoryMB:        prefs.MaxMemoryMB,
+	}
+	a.compressor.SetResourceLimits(limits)
+	a.pdfops.SetResourceLimits(limits)
+	common.SetGhostscriptMemoryLimit(prefs.MaxMemoryMB)
+}
+
+// savedCompressionOptions builds compression options from the saved
+// preferences, for requests that do not come from the frontend, such as
+// Finder services, automation URLs, AppleScript and the menu bar
+func (a *App) savedCompressionOptions() *compression.CompressionOptions {
+	options := compression.DefaultCompressionOptions()
+	prefs, err := a.db.GetPreferences()
+	if err != nil || prefs == nil {
+		a.config.Logger.Warn("Failed to load preferences, using default compression options", "error", err)
+		return &options
+	}
+
+	options.ImageDPI = prefs.ImageDPI
+	options.ImageQuality = prefs.ImageQuality
+	options.PDFVersion = prefs.PDFVersion
+	options.RemoveMetadata = prefs.RemoveMetadata
+	options.EmbedFonts = prefs.EmbedFonts
+	options.GenerateThumbnails = prefs.GenerateThumbnails
+	options.ConvertToGrayscale = prefs.ConvertToGrayscale
+	options.Backend = prefs.CompressionBackend
+	options.DownsampleType = prefs.DownsampleType
+	options.DownsampleThreshold = prefs.DownsampleThreshold
+	options.MonoImageCodec = prefs.MonoImageCodec
+	options.DeduplicateImages = prefs.DeduplicateImages
+	options.PreserveBookmarks = prefs.PreserveBookmarks
+	options.ColorConversion = prefs.ColorConversion
+	return &options
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/pdfops"
+)
+
+// RepairPDF rewrites a damaged PDF next to the original and reports what was fixed
+func (a *App) RepairPDF(path string) RepairResponse {
+	_, output := buildOutputPath(path, "", "repaired")
+
+	var report *pdfops.RepairReport
+	result, err := a.runPageOperation(path, output, func() error {
+		var err error
+		report, err = a.pdfops.Repair(a.ctx, path, output)
+		return err
+	})
+	if err != nil {
+		a.config.Logger.Error("Failed to repair PDF", "file", path, "error", err)
+		return RepairResponse{
+			Success:   false,
+			File:      pageOperationError(path, err),
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	a.config.Logger.Info("Repaired PDF", "file", path, "method", report.Method, "issues", len(report.Issues))
+	return RepairResponse{
+		Success: true,
+		File:    result,
+		Method:  report.Method,
+		Issues:  report.Issues,
+	}
+}
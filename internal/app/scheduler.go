@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"os"
+	"runtime"
+
+	"kleinpdf/internal/common"
+)
+
+// batchScheduler sizes the worker pool for a batch and limits how many large
+// files are compressed at once, so many small files run in parallel without
+// several huge ones thrashing memory and disk
+type batchScheduler struct {
+	workers    int
+	largeSlots chan struct{}
+	sizes      map[string]int64
+}
+
+// newBatchScheduler inspects the batch files and picks the worker count.
+// maxWorkers caps the pool when positive, as set by the max_concurrency
+// preference; otherwise the CPU count decides.
+func newBatchScheduler(files []string, maxWorkers int) *batchScheduler {
+	workers := runtime.NumCPU()
+	if workers > common.MaxConcurrencyLimit {
+		workers = common.MaxConcurrencyLimit
+	}
+	if maxWorkers > 0 && workers > maxWorkers {
+		workers = maxWorkers
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	sizes := make(map[string]int64, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			sizes[file] = info.Size()
+		}
+	}
+
+	largeSlots := common.MaxConcurrentLargeFiles
+	if largeSlots > workers {
+		largeSlots = workers
+	}
+
+	return &batchScheduler{
+		workers:    workers,
+		largeSlots: make(chan struct{}, largeSlots),
+		sizes:      sizes,
+	}
+}
+
+// isLarge reports whether a file needs a large-file slot to start
+func (s *batchScheduler) isLarge(file string) bool {
+	return s.sizes[file] >= common.LargeFileThreshold
+}
+
+// acquire blocks until the file may start; the returned func releases its
+// slot. Callers wait here before submitting the file to the pool.
+func (s *batchScheduler) acquire(ctx context.Context, file string) (func(), error) {
+	if !s.isLarge(file) {
+		return func() {}, nil
+	}
+
+	select {
+	case s.largeSlots <- struct{}{}:
+		return func() { <-s.largeSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
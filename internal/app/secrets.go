@@ -0,0 +1,64 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"kleinpdf/internal/platform"
+)
+
+// destinationSecretKey names a destination's password in the system keychain
+func destinationSecretKey(id uint) string {
+	return fmt.Sprintf("destination-%d", id)
+}
+
+// Keychain names of the passwords set in the preferences
+const (
+	smtpSecretKey    = "smtp"
+	signingSecretKey = "signing-certificate"
+)
+
+// preferenceSecretKeys maps password preferences to their keychain names
+var preferenceSecretKeys = map[string]string{
+	"smtp_password":    smtpSecretKey,
+	"signing_password": signingSecretKey,
+}
+
+// readSecret loads a password from the system keychain; a missing entry is
+// an empty password
+func readSecret(key string) (string, error) {
+	secret, err := platform.GetSecret(key)
+	if errors.Is(err, platform.ErrSecretNotFound) {
+		return "", nil
+	}
+	return secret, err
+}
+
+// migrateLegacySecrets moves passwords that older versions stored in the
+// database into the system keychain. A password the keychain will not take
+// stays where it is and is retried at the next start.
+func (a *App) migrateLegacySecrets() {
+	a.migrateLegacyColumn("destinations", "password", destinationSecretKey)
+	for column, key := range preferenceSecretKeys {
+		a.migrateLegacyColumn("user_preferences", column, func(uint) string { return key })
+	}
+}
+
+// migrateLegacyColumn moves the passwords in one database column into the
+// keychain under the key returned for each row
+func (a *App) migrateLegacyColumn(table, column string, key func(id uint) string) {
+	passwords, err := a.db.LegacyPasswords(table, column)
+	if err != nil {
+		a.config.Logger.Warn("Failed to read stored passwords", "column", column, "error", err)
+		return
+	}
+	for id, password := range passwords {
+		if err := platform.SetSecret(key(id), password); err != nil {
+			a.config.Logger.Warn("Failed to move password to the keychain", "column", column, "id", id, "error", err)
+			continue
+		}
+		if err := a.db.ClearLegacyPassword(table, column, id); err != nil {
+			a.config.Logger.Warn("Failed to clear stored password", "column", column, "id", id, "error", err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"kleinpdf/internal/platform"
+)
+
+// setupServices registers the "Compress with KleinPDF" entry in the macOS
+// Services menu and Finder Quick Actions
+func (a *App) setupServices() {
+	platform.SetServiceHandler(a.compressFromService)
+	platform.RegisterServices()
+}
+
+// compressFromService compresses files sent from Finder with the saved
+// preferences, next to the originals, without any interaction
+func (a *App) compressFromService(paths []string) {
+	defer a.recoverPanic("services", nil)
+
+	a.config.Logger.Info("Compressing files from Services menu", "files", len(paths))
+	wailsruntime.EventsEmit(a.ctx, "service:compress", map[string]interface{}{
+		"files": paths,
+	})
+
+	response := a.CompressPDF(CompressionRequest{
+		Files:           paths,
+		AdvancedOptions: a.savedCompressionOptions(),
+	})
+	if !response.Success {
+		a.config.Logger.Error("Services compression failed", "error", response.Error)
+	}
+}
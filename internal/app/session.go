@@ -0,0 +1,60 @@
+package app
+
+import (
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// emitRestorableSession tells the frontend about files that were queued or
+// being compressed when the app last exited
+func (a *App) emitRestorableSession() {
+	pending, err := a.GetPendingBatches()
+	if err != nil {
+		a.config.Logger.Warn("Failed to load interrupted batches", "error", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	files := 0
+	for _, batch := range pending {
+		files += len(batch.PendingFiles)
+	}
+
+	wailsruntime.EventsEmit(a.ctx, "session:restorable", map[string]interface{}{
+		"batches":     pending,
+		"total_files": files,
+	})
+}
+
+// RestoreSession resumes every batch interrupted by the last quit or crash,
+// oldest first, and returns their results
+func (a *App) RestoreSession() ([]CompressionResponse, error) {
+	pending, err := a.GetPendingBatches()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]CompressionResponse, 0, len(pending))
+	for _, batch := range pending {
+		responses = append(responses, a.ResumePendingBatch(batch.BatchID))
+	}
+	return responses, nil
+}
+
+// DiscardSession forgets every batch interrupted by the last quit or crash
+func (a *App) DiscardSession() error {
+	pending, err := a.GetPendingBatches()
+	if err != nil {
+		return err
+	}
+
+	for _, batch := range pending {
+		if err := a.DiscardPendingBatch(batch.BatchID); err != nil {
+			return err
+		}
+	}
+
+	a.config.Logger.Info("Discarded interrupted session", "batches", len(pending))
+	return nil
+}
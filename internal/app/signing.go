@@ -0,0 +1,92 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/database"
+	"kleinpdf/internal/platform"
+	"kleinpdf/internal/signing"
+)
+
+// signingSource identifies the certificate an identity was loaded from, so a
+// preference change loads it again
+type signingSource struct {
+	path     string
+	keychain string
+	password string
+}
+
+// signingIdentity returns the certificate that signs outputs, or nil when
+// signing is off. The identity is kept so Keychain asks only once.
+func (a *App) signingIdentity() (*signing.Identity, error) {
+	prefs, err := a.db.GetPreferences()
+	if err != nil {
+		return nil, err
+	}
+	if !prefs.SignOutput {
+		return nil, nil
+	}
+
+	prefs.SigningPassword, err = readSecret(signingSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the signing certificate password: %v", err)
+	}
+
+	source := signingSource{path: prefs.SigningCertificatePath, keychain: prefs.SigningKeychainIdentity, password: prefs.SigningPassword}
+
+	a.signingMu.Lock()
+	defer a.signingMu.Unlock()
+	if a.signer != nil && a.signerSource == source {
+		return a.signer, nil
+	}
+
+	identity, err := loadSigningIdentity(prefs)
+	if err != nil {
+		return nil, common.WrapError(common.ErrInvalidRequest, "failed to load signing certificate", err)
+	}
+	a.signer, a.signerSource = identity, source
+	return identity, nil
+}
+
+// loadSigningIdentity reads the certificate file, or exports the identity
+// from the Keychain when no file is set
+func loadSigningIdentity(prefs *database.UserPreferencesData) (*signing.Identity, error) {
+	if prefs.SigningCertificatePath != "" {
+		return signing.LoadPKCS12File(prefs.SigningCertificatePath, prefs.SigningPassword)
+	}
+	if prefs.SigningKeychainIdentity == "" {
+		return nil, common.NewError(common.ErrInvalidRequest, "no signing certificate is set")
+	}
+
+	// The export only lives in memory, so a throwaway passphrase is enough
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	passphrase := hex.EncodeToString(secret)
+
+	data, err := platform.ExportKeychainIdentity(prefs.SigningKeychainIdentity, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return signing.LoadPKCS12(data, passphrase)
+}
+
+// signOutput signs a compressed file in place. An output that could not be
+// signed is removed so it is never mistaken for a signed one.
+func (a *App) signOutput(identity *signing.Identity, path string) error {
+	prefs, err := a.db.GetPreferences()
+	if err != nil {
+		return err
+	}
+
+	if err := signing.SignFile(path, identity, signing.Options{Reason: prefs.SigningReason}); err != nil {
+		os.Remove(path)
+		return common.WrapError(common.ErrInternal, "failed to sign output", err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package app
+
+import (
+	"kleinpdf/internal/pdfops"
+)
+
+// AnalyzeSize breaks a document's size down by images, fonts, content,
+// attachments and metadata so the frontend can explain where the bytes go
+func (a *App) AnalyzeSize(path string) (*pdfops.SizeBreakdown, error) {
+	breakdown, err := a.pdfops.AnalyzeSize(path)
+	if err != nil {
+		a.config.Logger.Error("Failed to analyze PDF size", "file", path, "error", err)
+		return nil, err
+	}
+
+	return breakdown, nil
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/database"
+)
+
+// StatsManager keeps session counters in memory and lifetime totals in the database
+type StatsManager struct {
+	db     *database.Database
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	stats AppStats
+}
+
+// NewStatsManager loads the lifetime totals and starts a new session
+func NewStatsManager(db *database.Database, logger *slog.Logger) *StatsManager {
+	m := &StatsManager{db: db, logger: logger}
+	m.Reload()
+	return m
+}
+
+// Reload replaces the lifetime totals with those stored in the database
+func (m *StatsManager) Reload() {
+	lifetime, err := m.db.GetLifetimeStats()
+	if err != nil {
+		m.logger.Error("Failed to load lifetime statistics", "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.TotalFilesCompressed = lifetime.TotalFilesCompressed
+	m.stats.TotalDataSaved = lifetime.TotalDataSaved
+}
+
+// UpdateStats adds a finished batch to the session counters and the lifetime totals
+func (m *StatsManager) UpdateStats(files int, dataSaved int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats.SessionFilesCompressed += files
+	m.stats.SessionDataSaved += dataSaved
+
+	lifetime, err := m.db.AddLifetimeStats(int64(files), dataSaved)
+	if err != nil {
+		// Keep counting in memory so the session still shows the batch
+		m.logger.Error("Failed to persist lifetime statistics", "error", err)
+		m.stats.TotalFilesCompressed += int64(files)
+		m.stats.TotalDataSaved += dataSaved
+		return
+	}
+
+	m.stats.TotalFilesCompressed = lifetime.TotalFilesCompressed
+	m.stats.TotalDataSaved = lifetime.TotalDataSaved
+}
+
+// Snapshot returns a copy of the current statistics
+func (m *StatsManager) Snapshot() AppStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// GetStatsTimeline aggregates the compression history since the given time
+// into "day" or "week" buckets, oldest first. Weeks start on Monday.
+func (a *App) GetStatsTimeline(granularity string, since time.Time) ([]StatsBucket, error) {
+	if granularity != "day" && granularity != "week" {
+		return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown granularity %q", granularity))
+	}
+
+	entries, err := a.db.GetHistorySince(since)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := []StatsBucket{}
+	ratioSums := []float64{}
+	for _, entry := range entries {
+		if entry.Status != "completed" && entry.Status != "skipped_larger" {
+			continue
+		}
+
+		start := bucketStart(entry.CreatedAt.Local(), granularity)
+		last := len(buckets) - 1
+		if last < 0 || !buckets[last].Start.Equal(start) {
+			buckets = append(buckets, StatsBucket{Start: start, Levels: map[string]int{}})
+			ratioSums = append(ratioSums, 0)
+			last++
+		}
+
+		bucket := &buckets[last]
+		bucket.Files++
+		bucket.OriginalSize += entry.OriginalSize
+		bucket.CompressedSize += entry.CompressedSize
+		bucket.BytesSaved += entry.OriginalSize - entry.CompressedSize
+		bucket.Levels[entry.CompressionLevel]++
+		ratioSums[last] += entry.CompressionRatio
+		bucket.AverageRatio = ratioSums[last] / float64(bucket.Files)
+	}
+
+	return buckets, nil
+}
+
+// bucketStart returns the local midnight starting the day or week containing t
+func bucketStart(t time.Time, granularity string) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if granularity == "week" {
+		offset := (int(start.Weekday()) + 6) % 7 // Days since Monday
+		start = start.AddDate(0, 0, -offset)
+	}
+	return start
+}
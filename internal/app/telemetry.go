@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// telemetryInterval is how often worker counters are emitted during a batch
+const telemetryInterval = 2 * time.Second
+
+// workerTelemetry tracks per-worker counters for the session. Workers are
+// numbered slots; a file takes the lowest free slot while it is compressed.
+type workerTelemetry struct {
+	mu      sync.Mutex
+	workers []WorkerStats
+	seconds []float64
+	busy    []bool
+}
+
+// start claims a free worker slot for file and returns its ID
+func (t *workerTelemetry) start(file string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := 0
+	for id < len(t.busy) && t.busy[id] {
+		id++
+	}
+	if id == len(t.busy) {
+		t.workers = append(t.workers, WorkerStats{WorkerID: id})
+		t.seconds = append(t.seconds, 0)
+		t.busy = append(t.busy, false)
+	}
+
+	t.busy[id] = true
+	t.workers[id].CurrentFile = file
+	return id
+}
+
+// finish frees a worker slot and counts the file it processed
+func (t *workerTelemetry) finish(id int, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.busy[id] = false
+	t.seconds[id] += elapsed.Seconds()
+	w := &t.workers[id]
+	w.CurrentFile = ""
+	w.FilesProcessed++
+	w.AverageSeconds = t.seconds[id] / float64(w.FilesProcessed)
+}
+
+// snapshot returns a copy of the worker counters
+func (t *workerTelemetry) snapshot() []WorkerStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]WorkerStats(nil), t.workers...)
+}
+
+// emitWorkerStats sends the worker counters to the frontend until ctx is done
+func (a *App) emitWorkerStats(ctx context.Context) {
+	defer a.recoverPanic("worker telemetry", nil)
+
+	ticker := time.NewTicker(telemetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			wailsruntime.EventsEmit(a.ctx, "stats:workers", map[string]interface{}{
+				"workers": a.telemetry.snapshot(),
+			})
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	// tempMaxAge is how old an intermediate file must be before it is treated
+	// as left over from a crash rather than in use
+	tempMaxAge = time.Hour
+
+	// lockMaxAge expires locks whose process cannot be checked
+	lockMaxAge = 7 * 24 * time.Hour
+)
+
+// tempPrefixes and tempSuffixes match the intermediate files and folders the
+// compressor creates in the working directory, and nothing else
+var (
+	tempPrefixes = []string{"kleinpdf-gsworker-", "kleinpdf-ocr-", "kleinpdf-office-", "kleinpdf-browser-", "kleinpdf-text-", "kleinpdf_compare_", "kleinpdf_health_", "kleinpdf_levels_", "kleinpdf_quality_", "kleinpdf_benchmark_", "kleinpdf_trim_"}
+	tempSuffixes = []string{"_temp.pdf"}
+)
+
+// batchLock records which process owns a running batch
+type batchLock struct {
+	PID     int       `json:"pid"`
+	BatchID string    `json:"batch_id"`
+	Started time.Time `json:"started"`
+}
+
+// lockDir holds one lockfile per running batch, under the working directory
+func (a *App) lockDir() string {
+	dir := a.compressor.WorkingDir()
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "kleinpdf-locks")
+}
+
+// lockBatch writes a lockfile that keeps the cleaner away from the batch's
+// intermediate files, returning a function that removes it
+func (a *App) lockBatch(batchID string) func() {
+	if err := os.MkdirAll(a.lockDir(), common.DefaultFilePermissions); err != nil {
+		a.config.Logger.Warn("Failed to create lock directory", "error", err)
+		return func() {}
+	}
+
+	// Batch IDs come from the frontend, so they are kept out of the file name
+	path := filepath.Join(a.lockDir(), common.GenerateUUID()+".lock")
+	data, _ := json.Marshal(batchLock{PID: os.Getpid(), BatchID: batchID, Started: time.Now()})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		a.config.Logger.Warn("Failed to write batch lock", "batch_id", batchID, "error", err)
+		return func() {}
+	}
+	return func() { os.Remove(path) }
+}
+
+// oldestLiveLock removes locks left by processes that are gone and returns
+// the start time of the oldest batch still running, or zero if none is
+func (a *App) oldestLiveLock() time.Time {
+	var oldest time.Time
+
+	entries, err := os.ReadDir(a.lockDir())
+	if err != nil {
+		return oldest
+	}
+	for _, entry := range entries {
+		path := filepath.Join(a.lockDir(), entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var lock batchLock
+		if err := json.Unmarshal(data, &lock); err != nil || time.Since(lock.Started) > lockMaxAge ||
+			(lock.PID != os.Getpid() && !common.ProcessAlive(lock.PID)) {
+			os.Remove(path)
+			continue
+		}
+		if oldest.IsZero() || lock.Started.Before(oldest) {
+			oldest = lock.Started
+		}
+	}
+	return oldest
+}
+
+// cleanTempFiles deletes intermediate files left behind by crashes. Only
+// names the compressor creates are touched, and only when they are older
+// than tempMaxAge and predate every batch that is still running.
+func (a *App) cleanTempFiles() {
+	dir := a.compressor.WorkingDir()
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-tempMaxAge)
+	if oldest := a.oldestLiveLock(); !oldest.IsZero() && oldest.Before(cutoff) {
+		cutoff = oldest
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !isTempName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			err = common.RemoveTempDir(path)
+		} else {
+			err = common.RemoveTemp(path)
+		}
+		if err == nil {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		a.config.Logger.Info("Removed leftover temporary files", "count", removed)
+	}
+}
+
+// isTempName reports whether name is one the compressor gives intermediate files
+func isTempName(name string) bool {
+	for _, prefix := range tempPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for _, suffix := range tempSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
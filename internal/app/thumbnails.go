@@ -0,0 +1,123 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"kleinpdf/internal/common"
+)
+
+// maxCachedThumbnails bounds the thumbnail cache; the least recently used are evicted
+const maxCachedThumbnails = 500
+
+// RenderThumbnail returns a PNG of the first page whose longest side is at
+// most maxPx pixels. Thumbnails are cached in the working directory.
+func (a *App) RenderThumbnail(path string, maxPx int) ThumbnailResponse {
+	thumbnailPath, err := a.thumbnail(path, maxPx)
+	if err != nil {
+		a.config.Logger.Error("Failed to render thumbnail", "file", path, "error", err)
+		return ThumbnailResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	data, err := os.ReadFile(thumbnailPath)
+	if err != nil {
+		return ThumbnailResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorCode: common.ErrorCodeOf(err),
+		}
+	}
+
+	return ThumbnailResponse{
+		Success: true,
+		Path:    thumbnailPath,
+		Image:   "data:image/png;base64," + base64.StdEncoding.EncodeToString(data),
+	}
+}
+
+// thumbnail returns the cached thumbnail for a file, rendering it on a miss
+func (a *App) thumbnail(path string, maxPx int) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	cacheDir := a.thumbnailCacheDir()
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create thumbnail cache: %v", err)
+	}
+
+	// Key on size and modification time so edited files are re-rendered
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%d", path, info.Size(), info.ModTime().UnixNano(), maxPx)))
+	thumbnailPath := filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".png")
+
+	a.thumbnailsMu.Lock()
+	defer a.thumbnailsMu.Unlock()
+
+	if _, err := os.Stat(thumbnailPath); err == nil {
+		now := time.Now()
+		os.Chtimes(thumbnailPath, now, now) // Mark as recently used
+		return thumbnailPath, nil
+	}
+
+	if err := a.pdfops.RenderThumbnail(a.ctx, path, thumbnailPath, maxPx); err != nil {
+		return "", err
+	}
+
+	a.evictThumbnails(cacheDir)
+	return thumbnailPath, nil
+}
+
+// thumbnailCacheDir returns the thumbnail cache directory inside the working directory
+func (a *App) thumbnailCacheDir() string {
+	workingDir := a.compressor.WorkingDir()
+	if workingDir == "" {
+		workingDir = os.TempDir()
+	}
+	return filepath.Join(workingDir, "kleinpdf_thumbnails")
+}
+
+// evictThumbnails removes the least recently used thumbnails beyond the cache limit
+func (a *App) evictThumbnails(cacheDir string) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil || len(entries) <= maxCachedThumbnails {
+		return
+	}
+
+	type cachedThumbnail struct {
+		path   string
+		usedAt time.Time
+	}
+	thumbnails := make([]cachedThumbnail, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		thumbnails = append(thumbnails, cachedThumbnail{filepath.Join(cacheDir, entry.Name()), info.ModTime()})
+	}
+
+	if len(thumbnails) <= maxCachedThumbnails {
+		return
+	}
+
+	sort.Slice(thumbnails, func(i, j int) bool {
+		return thumbnails[i].usedAt.Before(thumbnails[j].usedAt)
+	})
+
+	for _, thumbnail := range thumbnails[:len(thumbnails)-maxCachedThumbnails] {
+		if err := os.Remove(thumbnail.path); err != nil {
+			a.config.Logger.Debug("Failed to evict thumbnail", "path", thumbnail.path, "error", err)
+		}
+	}
+}
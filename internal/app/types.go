@@ -3,9 +3,16 @@ package app
 import (
 	"context"
 	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"kleinpdf/internal/common"
 	"kleinpdf/internal/compression"
 	"kleinpdf/internal/database"
+	"kleinpdf/internal/pdfops"
+	"kleinpdf/internal/signing"
 )
 
 // App represents the main application structure
@@ -14,49 +21,247 @@ type App struct {
 	config     *Config
 	db         *database.Database
 	compressor *compression.Compressor
-	stats      *AppStats
+	pdfops     *pdfops.Processor
+	stats      *StatsManager
+	telemetry  workerTelemetry
+	events     eventHub
+
+	batchesMu sync.Mutex
+	batches   map[string]*batch
+
+	passwordsMu      sync.Mutex
+	passwordRequests map[string]chan string
+
+	automationMu       sync.Mutex
+	automationRequests map[string]*CompressionRequest
+
+	eventServerMu sync.Mutex
+	eventServer   *http.Server
+	eventStream   EventStreamInfo
+
+	thumbnailsMu sync.Mutex
+
+	inputLocks      pathLocks
+	outputsMu       sync.Mutex
+	reservedOutputs map[string]bool
+
+	signingMu    sync.Mutex
+	signer       *signing.Identity
+	signerSource signingSource
+
+	menuBarMode atomic.Bool
+	quitting    atomic.Bool
+
+	openedMu      sync.Mutex
+	openedFiles   []string
+	openedURLs    []string
+	frontendReady bool
+
+	healthMu sync.RWMutex
+	health   compression.HealthStatus
 }
 
 // Config holds application configuration
 type Config struct {
 	DatabasePath    string
 	GhostscriptPath string
+	WorkingDir      string
 	Logger          *slog.Logger
+	LogLevel        *slog.LevelVar
+	LogPath         string
+
+	// GhostscriptSetup records how the Ghostscript binary was obtained at startup
+	GhostscriptSetup GhostscriptSetup
 }
 
+// GhostscriptSetup describes the outcome of locating Ghostscript at startup
+type GhostscriptSetup struct {
+	Source   string `json:"source"` // cached, extracted, system or none
+	Path     string `json:"path"`
+	Repaired bool   `json:"repaired"`
+	Error    string `json:"error,omitempty"`
+}
 
 // CompressionRequest represents a PDF compression request
 type CompressionRequest struct {
-	Files            []string                     `json:"files"`
-	CompressionLevel string                       `json:"compressionLevel"`
-	AdvancedOptions  *compression.CompressionOptions `json:"advancedOptions"`
+	BatchID           string                          `json:"batchId"`
+	Files             []string                        `json:"files"`
+	CompressionLevel  string                          `json:"compressionLevel"`
+	AdvancedOptions   *compression.CompressionOptions `json:"advancedOptions"`
+	OutputDir         string                          `json:"outputDir"`
+	FileOverrides     map[string]FileSettings         `json:"fileOverrides"`
+	MaxDepth          int                             `json:"maxDepth"`
+	ExcludePatterns   []string                        `json:"excludePatterns"`
+	PreserveStructure bool                            `json:"preserveStructure"`
+	OutputSubdirs     map[string]string               `json:"outputSubdirs"`
+	ZipOutput         bool                            `json:"zipOutput"`
+	DestinationID     uint                            `json:"destinationId"`
+	CollisionStrategy string                          `json:"collisionStrategy"`
+}
+
+// FileSettings overrides the batch compression settings for a single file.
+// AdvancedOptions holds only the options to change, keyed by their JSON
+// names; the rest keep the batch settings.
+type FileSettings struct {
+	CompressionLevel string                 `json:"compressionLevel"`
+	AdvancedOptions  map[string]interface{} `json:"advancedOptions"`
 }
 
 // CompressionResponse represents the result of a compression operation
 type CompressionResponse struct {
-	Success                 bool         `json:"success"`
-	Files                   []FileResult `json:"files"`
-	TotalFiles              int          `json:"total_files"`
-	TotalOriginalSize       int64        `json:"total_original_size"`
-	TotalCompressedSize     int64        `json:"total_compressed_size"`
-	OverallCompressionRatio float64      `json:"overall_compression_ratio"`
-	CompressionLevel        string       `json:"compression_level"`
-	Error                   string       `json:"error,omitempty"`
+	Success                 bool             `json:"success"`
+	BatchID                 string           `json:"batch_id"`
+	Cancelled               bool             `json:"cancelled"`
+	Files                   []FileResult     `json:"files"`
+	TotalFiles              int              `json:"total_files"`
+	TotalOriginalSize       int64            `json:"total_original_size"`
+	TotalCompressedSize     int64            `json:"total_compressed_size"`
+	OverallCompressionRatio float64          `json:"overall_compression_ratio"`
+	CompressionLevel        string           `json:"compression_level"`
+	Concurrency             int              `json:"concurrency"`
+	ArchivePath             string           `json:"archive_path,omitempty"`
+	DurationSeconds         float64          `json:"duration_seconds"`
+	Error                   string           `json:"error,omitempty"`
+	ErrorCode               common.ErrorCode `json:"error_code,omitempty"`
 }
 
+// WarningSignatureInvalidated marks a signed input whose signature the
+// compressed output no longer carries
+const WarningSignatureInvalidated = "signature_will_be_invalidated"
+
+// WarningOutputLarger marks an output kept although it is larger than the
+// input, because the options changed more than its size
+const WarningOutputLarger = "output_larger_than_original"
+
+// WarningFormsNotFlattened marks an input whose form fields were kept
+// interactive because their appearances are left for the viewer to draw
+const WarningFormsNotFlattened = "forms_not_flattened"
+
 // FileResult represents the result of compressing a single file
 type FileResult struct {
-	FileID             string  `json:"file_id"`
-	OriginalFilename   string  `json:"original_filename"`
-	CompressedFilename string  `json:"compressed_filename"`
-	OriginalSize       int64   `json:"original_size"`
-	CompressedSize     int64   `json:"compressed_size"`
-	CompressionRatio   float64 `json:"compression_ratio"`
-	CompressedPath     string  `json:"compressed_path"`
-	Status             string  `json:"status"`
-	Error              string  `json:"error,omitempty"`
+	FileID             string                     `json:"file_id"`
+	OriginalFilename   string                     `json:"original_filename"`
+	OriginalPath       string                     `json:"original_path"`
+	CompressedFilename string                     `json:"compressed_filename"`
+	OriginalSize       int64                      `json:"original_size"`
+	CompressedSize     int64                      `json:"compressed_size"`
+	CompressionRatio   float64                    `json:"compression_ratio"`
+	CompressedPath     string                     `json:"compressed_path"`
+	PageCount          int                        `json:"page_count,omitempty"`
+	PDFVersion         string                     `json:"pdf_version,omitempty"`
+	IsScanned          bool                       `json:"is_scanned,omitempty"`
+	CompressionLevel   string                     `json:"compression_level,omitempty"`
+	Cached             bool                       `json:"cached,omitempty"`
+	DurationSeconds    float64                    `json:"duration_seconds,omitempty"`
+	ThroughputMBps     float64                    `json:"throughput_mbps,omitempty"`
+	RemotePath         string                     `json:"remote_path,omitempty"`
+	UploadError        string                     `json:"upload_error,omitempty"`
+	CollisionAction    string                     `json:"collision_action,omitempty"`
+	TextIntegrity      *compression.TextIntegrity `json:"text_integrity,omitempty"`
+	Quality            *compression.QualityScore  `json:"quality,omitempty"`
+	AutoChoice         *compression.AutoChoice    `json:"auto_choice,omitempty"`
+	Warnings           []string                   `json:"warnings,omitempty"`
+	Status             string                     `json:"status"`
+	Error              string                     `json:"error,omitempty"`
+	ErrorCode          common.ErrorCode           `json:"error_code,omitempty"`
+
+	// Recorded in the history so the file can be compressed again
+	options    *compression.CompressionOptions
+	inputHash  string
+	backupPath string
 }
 
+// PageOperationResponse represents the result of a page-level operation such as split or extract
+type PageOperationResponse struct {
+	Success   bool             `json:"success"`
+	Files     []FileResult     `json:"files"`
+	Error     string           `json:"error,omitempty"`
+	ErrorCode common.ErrorCode `json:"error_code,omitempty"`
+}
+
+// ComparisonResponse holds the same page of two documents rendered as PNG data URLs
+type ComparisonResponse struct {
+	Success         bool             `json:"success"`
+	Page            int              `json:"page"`
+	DPI             int              `json:"dpi"`
+	OriginalImage   string           `json:"original_image"`
+	CompressedImage string           `json:"compressed_image"`
+	Error           string           `json:"error,omitempty"`
+	ErrorCode       common.ErrorCode `json:"error_code,omitempty"`
+}
+
+// LevelComparison is the outcome of compressing a document at one level
+type LevelComparison struct {
+	Level            string           `json:"level"`
+	CompressedSize   int64            `json:"compressed_size"`
+	CompressionRatio float64          `json:"compression_ratio"`
+	DurationSeconds  float64          `json:"duration_seconds"`
+	SampleImage      string           `json:"sample_image,omitempty"`
+	Error            string           `json:"error,omitempty"`
+	ErrorCode        common.ErrorCode `json:"error_code,omitempty"`
+}
+
+// LevelComparisonResponse holds a document compressed at every built-in level,
+// with the same sample page rendered from the original and each output
+type LevelComparisonResponse struct {
+	Success       bool              `json:"success"`
+	OriginalSize  int64             `json:"original_size"`
+	Page          int               `json:"page"`
+	DPI           int               `json:"dpi"`
+	OriginalImage string            `json:"original_image,omitempty"`
+	Levels        []LevelComparison `json:"levels"`
+	Error         string            `json:"error,omitempty"`
+	ErrorCode     common.ErrorCode  `json:"error_code,omitempty"`
+}
+
+// BenchmarkRun is the timing of one worker count in a benchmark
+type BenchmarkRun struct {
+	Workers         int     `json:"workers"`
+	Files           int     `json:"files"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	FilesPerSecond  float64 `json:"files_per_second"`
+	ThroughputMBps  float64 `json:"throughput_mbps"`
+}
+
+// BenchmarkResponse holds the runs of a concurrency benchmark and the worker
+// count recommended for this machine
+type BenchmarkResponse struct {
+	Success                bool             `json:"success"`
+	CPUCount               int              `json:"cpu_count"`
+	CompressionLevel       string           `json:"compression_level"`
+	Runs                   []BenchmarkRun   `json:"runs"`
+	RecommendedConcurrency int              `json:"recommended_concurrency"`
+	Saved                  bool             `json:"saved"`
+	Error                  string           `json:"error,omitempty"`
+	ErrorCode              common.ErrorCode `json:"error_code,omitempty"`
+}
+
+// ThumbnailResponse holds a rendered first-page thumbnail
+type ThumbnailResponse struct {
+	Success   bool             `json:"success"`
+	Path      string           `json:"path"`
+	Image     string           `json:"image"`
+	Error     string           `json:"error,omitempty"`
+	ErrorCode common.ErrorCode `json:"error_code,omitempty"`
+}
+
+// RepairResponse represents the result of repairing a damaged PDF
+type RepairResponse struct {
+	Success   bool             `json:"success"`
+	File      *FileResult      `json:"file,omitempty"`
+	Method    string           `json:"method,omitempty"`
+	Issues    []string         `json:"issues"`
+	Error     string           `json:"error,omitempty"`
+	ErrorCode common.ErrorCode `json:"error_code,omitempty"`
+}
+
+// HistoryPage is one page of history search results
+type HistoryPage struct {
+	Entries  []database.HistoryEntry `json:"entries"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"page_size"`
+}
 
 // FileUpload represents uploaded file data
 type FileUpload struct {
@@ -71,4 +276,38 @@ type AppStats struct {
 	TotalDataSaved         int64 `json:"total_data_saved"`
 	SessionFilesCompressed int   `json:"session_files_compressed"`
 	SessionDataSaved       int64 `json:"session_data_saved"`
-}
\ No newline at end of file
+
+	Workers []WorkerStats `json:"workers"`
+}
+
+// StatsBucket aggregates the files compressed in one day or week
+type StatsBucket struct {
+	Start          time.Time      `json:"start"`
+	Files          int            `json:"files"`
+	OriginalSize   int64          `json:"original_size"`
+	CompressedSize int64          `json:"compressed_size"`
+	BytesSaved     int64          `json:"bytes_saved"`
+	AverageRatio   float64        `json:"average_ratio"`
+	Levels         map[string]int `json:"levels"`
+}
+
+// VersionInfo identifies the running build
+type VersionInfo struct {
+	Version                   string `json:"version"`
+	Commit                    string `json:"commit"`
+	BuildDate                 string `json:"build_date"`
+	Modified                  bool   `json:"modified"`
+	GoVersion                 string `json:"go_version"`
+	OS                        string `json:"os"`
+	Arch                      string `json:"arch"`
+	BundledGhostscriptVersion string `json:"bundled_ghostscript_version"`
+	GhostscriptVersion        string `json:"ghostscript_version"`
+}
+
+// WorkerStats holds session counters for one compression worker
+type WorkerStats struct {
+	WorkerID       int     `json:"worker_id"`
+	FilesProcessed int64   `json:"files_processed"`
+	AverageSeconds float64 `json:"average_seconds"`
+	CurrentFile    string  `json:"current_file,omitempty"`
+}
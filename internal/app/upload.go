@@ -0,0 +1,169 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"kleinpdf/internal/common"
+)
+
+const (
+	// uploadPath is the asset server route the frontend posts files to
+	uploadPath = "/upload"
+
+	// uploadRetention is how long uploaded files and their outputs are kept
+	uploadRetention = 24 * time.Hour
+)
+
+// UploadedFile is a file streamed to disk by the upload handler
+type UploadedFile struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// uploadResponse is the JSON body returned by the upload handler
+type uploadResponse struct {
+	Files     []UploadedFile   `json:"files"`
+	Error     string           `json:"error,omitempty"`
+	ErrorCode common.ErrorCode `json:"error_code,omitempty"`
+}
+
+// uploadHandler streams multipart uploads to disk so large files never pass
+// through the Wails bridge as base64 []byte payloads
+type uploadHandler struct {
+	a *App
+}
+
+// NewUploadHandler returns the asset server handler for POST /upload. Each
+// file part is copied to the working directory in small chunks, and the
+// response lists the saved paths for CompressPDF.
+func NewUploadHandler(a *App) http.Handler {
+	return &uploadHandler{a: a}
+}
+
+func (h *uploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != uploadPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeUploadResponse(w, http.StatusMethodNotAllowed, uploadResponse{
+			Error:     "uploads must use POST",
+			ErrorCode: common.ErrInvalidRequest,
+		})
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeUploadResponse(w, http.StatusBadRequest, uploadResponse{
+			Error:     "expected a multipart/form-data body",
+			ErrorCode: common.ErrInvalidRequest,
+		})
+		return
+	}
+
+	var uploaded []UploadedFile
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil && part.FileName() != "" {
+			var file *UploadedFile
+			file, err = h.a.saveUpload(part.FileName(), part)
+			if file != nil {
+				uploaded = append(uploaded, *file)
+			}
+		}
+		if err != nil {
+			h.a.config.Logger.Error("Upload failed", "error", err)
+			for _, file := range uploaded {
+				common.RemoveTempDir(filepath.Dir(file.Path))
+			}
+			writeUploadResponse(w, http.StatusInternalServerError, uploadResponse{
+				Error:     err.Error(),
+				ErrorCode: common.ErrorCodeOf(err),
+			})
+			return
+		}
+	}
+
+	if len(uploaded) == 0 {
+		writeUploadResponse(w, http.StatusBadRequest, uploadResponse{
+			Error:     "no files provided",
+			ErrorCode: common.ErrInvalidRequest,
+		})
+		return
+	}
+	writeUploadResponse(w, http.StatusOK, uploadResponse{Files: uploaded})
+}
+
+// writeUploadResponse writes a JSON upload response
+func writeUploadResponse(w http.ResponseWriter, status int, response uploadResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// uploadDir is where uploaded files are written, under the working directory
+func (a *App) uploadDir() string {
+	workingDir := a.compressor.WorkingDir()
+	if workingDir == "" {
+		workingDir = os.TempDir()
+	}
+	return filepath.Join(workingDir, "uploads")
+}
+
+// saveUpload copies an uploaded file to its own folder in the upload
+// directory, keeping the original name so the compressed copy is named after it
+func (a *App) saveUpload(name string, content io.Reader) (*UploadedFile, error) {
+	dir := filepath.Join(a.uploadDir(), uuid.New().String())
+	if err := os.MkdirAll(dir, common.DefaultFilePermissions); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, filepath.Base(filepath.Clean("/"+name)))
+	file, err := os.Create(path)
+	if err != nil {
+		common.RemoveTempDir(dir)
+		return nil, err
+	}
+
+	size, err := io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		common.RemoveTempDir(dir)
+		return nil, common.WrapError(common.ErrorCodeOf(err), "failed to save upload", err)
+	}
+
+	return &UploadedFile{Name: name, Path: path, Size: size}, nil
+}
+
+// pruneUploads deletes uploads, and the outputs written next to them, once
+// they are older than the upload retention period
+func (a *App) pruneUploads() {
+	entries, err := os.ReadDir(a.uploadDir())
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-uploadRetention)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+		common.RemoveTempDir(filepath.Join(a.uploadDir(), entry.Name()))
+	}
+}
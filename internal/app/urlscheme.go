@@ -0,0 +1,165 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// urlScheme is registered for automation tools such as Shortcuts, Alfred and Raycast
+const urlScheme = "kleinpdf"
+
+// OnUrlOpen receives a kleinpdf:// URL. Like opened files, URLs that arrive
+// before the frontend has loaded are held until it is ready.
+func (a *App) OnUrlOpen(rawURL string) {
+	a.openedMu.Lock()
+	if !a.frontendReady {
+		a.openedURLs = append(a.openedURLs, rawURL)
+		a.openedMu.Unlock()
+		return
+	}
+	a.openedMu.Unlock()
+
+	a.runAutomationURL(rawURL)
+}
+
+// runAutomationURL parses a kleinpdf:// URL and asks the user to confirm the
+// compression it describes. Any web page can open such a URL, so nothing is
+// read or written until the user has seen the files and the output folder.
+func (a *App) runAutomationURL(rawURL string) {
+	defer a.recoverPanic("url scheme", nil)
+
+	request, err := a.resolveAutomationURL(rawURL)
+	if err != nil {
+		a.config.Logger.Warn("Ignoring invalid automation URL", "url", rawURL, "error", err)
+		wailsruntime.EventsEmit(a.ctx, "automation:error", map[string]interface{}{
+			"url":   rawURL,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	requestID := common.GenerateUUID()
+	a.automationMu.Lock()
+	a.automationRequests[requestID] = request
+	a.automationMu.Unlock()
+
+	a.config.Logger.Info("Automation URL awaiting confirmation", "request_id", requestID, "files", len(request.Files), "level", request.CompressionLevel)
+	wailsruntime.EventsEmit(a.ctx, "automation:confirm", map[string]interface{}{
+		"request_id":        requestID,
+		"files":             request.Files,
+		"compression_level": request.CompressionLevel,
+		"output_dir":        request.OutputDir,
+	})
+}
+
+// ConfirmAutomationRequest starts or discards a compression requested by a
+// kleinpdf:// URL once the user has answered the automation:confirm event
+func (a *App) ConfirmAutomationRequest(requestID string, approved bool) error {
+	a.automationMu.Lock()
+	request, ok := a.automationRequests[requestID]
+	delete(a.automationRequests, requestID)
+	a.automationMu.Unlock()
+
+	if !ok {
+		return common.NewError(common.ErrInvalidRequest, fmt.Sprintf("no automation request %s", requestID))
+	}
+	if !approved {
+		a.config.Logger.Info("Automation URL declined", "request_id", requestID)
+		return nil
+	}
+
+	a.config.Logger.Info("Compressing files from automation URL", "request_id", requestID, "files", len(request.Files), "level", request.CompressionLevel)
+	wailsruntime.EventsEmit(a.ctx, "automation:compress", map[string]interface{}{
+		"files":             request.Files,
+		"compression_level": request.CompressionLevel,
+		"output_dir":        request.OutputDir,
+	})
+
+	go func() {
+		defer a.recoverPanic("url scheme", nil)
+		response := a.CompressPDF(*request)
+		if !response.Success {
+			a.config.Logger.Error("Automation URL compression failed", "error", response.Error)
+		}
+	}()
+	return nil
+}
+
+// resolveAutomationURL parses a kleinpdf:// URL into the request to confirm:
+// folders are expanded to the PDFs they hold, so the user sees every file,
+// and the output folder must be one the user has picked in a dialog before
+func (a *App) resolveAutomationURL(rawURL string) (*CompressionRequest, error) {
+	request, err := parseAutomationURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.OutputDir != "" {
+		outputDir, err := filepath.Abs(request.OutputDir)
+		if err != nil {
+			return nil, common.WrapError(common.ErrInvalidRequest, "invalid output folder", err)
+		}
+		known, err := a.db.IsOutputFolder(outputDir)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("output folder %s has not been chosen in KleinPDF before", outputDir))
+		}
+		request.OutputDir = outputDir
+	}
+
+	for i, file := range request.Files {
+		if abs, err := filepath.Abs(file); err == nil {
+			request.Files[i] = abs
+		}
+	}
+	request.Files, _ = a.expandInputs(request.Files, request.MaxDepth, nil)
+	if len(request.Files) == 0 {
+		return nil, common.NewError(common.ErrInvalidRequest, "no PDF files found")
+	}
+
+	request.AdvancedOptions = a.savedCompressionOptions()
+	return request, nil
+}
+
+// parseAutomationURL turns kleinpdf://compress?path=...&level=ultra into a
+// compression request. path may be repeated; level and output are optional.
+func parseAutomationURL(rawURL string) (*CompressionRequest, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, common.WrapError(common.ErrInvalidRequest, "malformed URL", err)
+	}
+	if parsed.Scheme != urlScheme {
+		return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unsupported URL scheme %q", parsed.Scheme))
+	}
+
+	// kleinpdf://compress and kleinpdf:compress both name the action
+	action := parsed.Host
+	if action == "" {
+		action = strings.Trim(parsed.Opaque, "/")
+	}
+	if action != "compress" {
+		return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown action %q", action))
+	}
+
+	query := parsed.Query()
+	request := &CompressionRequest{
+		Files:            query["path"],
+		CompressionLevel: query.Get("level"),
+		OutputDir:        query.Get("output"),
+	}
+	if len(request.Files) == 0 {
+		return nil, common.NewError(common.ErrInvalidRequest, "no path given")
+	}
+	if request.CompressionLevel != "" && !compression.IsKnownLevel(request.CompressionLevel) {
+		return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown compression level %q", request.CompressionLevel))
+	}
+	return request, nil
+}
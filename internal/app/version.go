@@ -0,0 +1,51 @@
+package app
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"kleinpdf/internal/binary"
+)
+
+// Build information, set at link time:
+//
+//	-ldflags "-X kleinpdf/internal/app.Version=v1.2.0 -X kleinpdf/internal/app.Commit=abc1234 -X kleinpdf/internal/app.BuildDate=2025-08-19T10:00:00Z"
+//
+// Commit and BuildDate fall back to the VCS details Go records in the binary.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// GetVersionInfo reports exactly which build is running, for support requests
+func (a *App) GetVersionInfo() VersionInfo {
+	info := VersionInfo{
+		Version:                   Version,
+		Commit:                    Commit,
+		BuildDate:                 BuildDate,
+		GoVersion:                 runtime.Version(),
+		OS:                        runtime.GOOS,
+		Arch:                      runtime.GOARCH,
+		BundledGhostscriptVersion: binary.GhostscriptVersion,
+		GhostscriptVersion:        a.ghostscriptHealth().Version,
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
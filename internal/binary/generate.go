@@ -3,65 +3,444 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
 )
 
+// defaultVersion is the Ghostscript release embedded unless -version picks
+// another. The version actually embedded is written to ghostscript-version.txt,
+// so nothing else needs to repeat it.
+const defaultVersion = "10.05.1"
+
 const (
-	baseURL = "https://github.com/bimalpaudels/kleinPDF-ghostscript-binary/releases/download/ghostscript-10.05.1"
+	releasesAPI = "https://api.github.com/repos/bimalpaudels/kleinPDF-ghostscript-binary/releases"
+	tagPrefix   = "ghostscript-"
 )
 
+// release is the subset of the GitHub release object the generator needs
+type release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []asset `json:"assets"`
+}
+
+// asset is a file attached to a GitHub release
+type asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
 func main() {
+	version := flag.String("version", defaultVersion, `Ghostscript release to embed, or "latest"`)
+	universal := flag.Bool("universal", false, "embed an arm64 + x86_64 macOS binary merged with lipo")
+	flag.Parse()
+
+	fmt.Printf("Looking up Ghostscript release %s...\n", *version)
+	rel, err := fetchRelease(*version)
+	if err != nil {
+		fmt.Printf("Failed to look up release: %v\n", err)
+		os.Exit(1)
+	}
+	gsVersion := strings.TrimPrefix(rel.TagName, tagPrefix)
+
+	// Every asset is checked against the release's SHA256SUMS before it is embedded
+	fmt.Println("Downloading SHA256SUMS...")
+	sums, err := fetchChecksums(rel)
+	if err != nil {
+		fmt.Printf("Failed to download checksums: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *universal {
+		if err := generateUniversal(rel, gsVersion, sums); err != nil {
+			fmt.Printf("Failed to build universal binary: %v\n", err)
+			os.Exit(1)
+		}
+		if err := writeVersion(gsVersion); err != nil {
+			fmt.Printf("Failed to record version: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Successfully built universal ghostscript.zst and ghostscript-bundle.tar.gz")
+		return
+	}
+
 	var binaryName string
-	switch runtime.GOARCH {
-	case "arm64":
-		binaryName = "ghostscript-10.05.1-macos-arm64"
-	case "amd64":
-		binaryName = "ghostscript-10.05.1-macos-x86_64"
+	switch {
+	case runtime.GOOS == "windows" && runtime.GOARCH == "amd64":
+		binaryName = "ghostscript-" + gsVersion + "-windows-x86_64.exe"
+	case runtime.GOOS == "windows":
+		fmt.Printf("Unsupported Windows architecture: %s\n", runtime.GOARCH)
+		os.Exit(1)
+	case runtime.GOARCH == "arm64":
+		binaryName = "ghostscript-" + gsVersion + "-macos-arm64"
+	case runtime.GOARCH == "amd64":
+		binaryName = "ghostscript-" + gsVersion + "-macos-x86_64"
 	default:
 		fmt.Printf("Unsupported architecture: %s\n", runtime.GOARCH)
 		os.Exit(1)
 	}
 
-	url := fmt.Sprintf("%s/%s", baseURL, binaryName)
-	outputPath := "ghostscript"
-
 	fmt.Printf("Downloading %s for %s...\n", binaryName, runtime.GOARCH)
-
-	resp, err := http.Get(url)
+	binaryData, err := fetchAsset(rel, binaryName, sums)
 	if err != nil {
 		fmt.Printf("Failed to download binary: %v\n", err)
 		os.Exit(1)
 	}
+
+	if err := writeCompressedBinary(binaryData); err != nil {
+		fmt.Printf("Failed to compress binary: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Successfully downloaded %s\n", "ghostscript.zst")
+
+	// The lib/ and share/ghostscript trees the binary needs at runtime
+	bundleName := binaryName + "-resources.tar.gz"
+	fmt.Printf("Downloading %s...\n", bundleName)
+	if err := download(rel, bundleName, "ghostscript-bundle.tar.gz", sums, true); err != nil {
+		fmt.Printf("Failed to download resource bundle: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Successfully downloaded %s\n", "ghostscript-bundle.tar.gz")
+
+	if err := writeVersion(gsVersion); err != nil {
+		fmt.Printf("Failed to record version: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeVersion records the embedded release for binary.GhostscriptVersion
+func writeVersion(gsVersion string) error {
+	return os.WriteFile("ghostscript-version.txt", []byte(gsVersion+"\n"), 0644)
+}
+
+// fetchRelease looks up a release by Ghostscript version, or the newest one for "latest"
+func fetchRelease(version string) (*release, error) {
+	url := releasesAPI + "/latest"
+	if version != "latest" {
+		url = releasesAPI + "/tags/" + tagPrefix + version
+	}
+
+	data, err := fetch(url, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+
+	var rel release
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %v", err)
+	}
+	if !strings.HasPrefix(rel.TagName, tagPrefix) {
+		return nil, fmt.Errorf("unexpected release tag %q", rel.TagName)
+	}
+
+	return &rel, nil
+}
+
+// fetchChecksums downloads a sha256sum-style file and maps asset names to hex digests
+func fetchChecksums(rel *release) (map[string]string, error) {
+	url, ok := assetURL(rel, "SHA256SUMS")
+	if !ok {
+		return nil, fmt.Errorf("release %s has no SHA256SUMS asset", rel.TagName)
+	}
+
+	data, err := fetch(url, "application/octet-stream")
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary mode with a leading '*'
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	if len(sums) == 0 {
+		return nil, fmt.Errorf("no checksums found")
+	}
+	return sums, nil
+}
+
+// assetURL returns the download URL of the named release asset
+func assetURL(rel *release, name string) (string, bool) {
+	for _, a := range rel.Assets {
+		if a.Name == name {
+			return a.BrowserDownloadURL, true
+		}
+	}
+	return "", false
+}
+
+// errNotFound reports a release or asset that does not exist
+var errNotFound = errors.New("not found")
+
+// fetch downloads url into memory. GITHUB_TOKEN, when set, authenticates the
+// request to avoid the anonymous API rate limit.
+func fetch(url, accept string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Failed to download binary: HTTP %d\n", resp.StatusCode)
-		os.Exit(1)
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// download writes a release asset to outputPath after verifying its checksum.
+// When optional is set, a missing asset produces an empty file so the embed
+// directive still compiles.
+func download(rel *release, assetName, outputPath string, sums map[string]string, optional bool) error {
+	data, err := fetchAsset(rel, assetName, sums)
+	if optional && errors.Is(err, errNotFound) {
+		fmt.Printf("No %s in this release, embedding an empty file\n", outputPath)
+		return os.WriteFile(outputPath, nil, 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", outputPath, err)
+	}
+
+	return nil
+}
+
+// fetchAsset downloads a release asset into memory and verifies its checksum
+func fetchAsset(rel *release, assetName string, sums map[string]string) ([]byte, error) {
+	url, ok := assetURL(rel, assetName)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s asset: %w", rel.TagName, assetName, errNotFound)
 	}
 
-	file, err := os.Create(outputPath)
+	data, err := fetch(url, "application/octet-stream")
 	if err != nil {
-		fmt.Printf("Failed to create output file: %v\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+
+	expected, ok := sums[assetName]
+	if !ok {
+		return nil, fmt.Errorf("%s is not listed in SHA256SUMS", assetName)
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", assetName, expected, actual)
 	}
-	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
+	return data, nil
+}
+
+// generateUniversal merges the arm64 and x86_64 macOS builds with lipo so one
+// .app runs natively on Apple Silicon and Intel. Resource bundles are merged
+// file by file: Mach-O files that differ are combined, everything else is shared.
+func generateUniversal(rel *release, gsVersion string, sums map[string]string) error {
+	if _, err := exec.LookPath("lipo"); err != nil {
+		return fmt.Errorf("lipo not found: universal builds must be generated on macOS")
+	}
+
+	armName := "ghostscript-" + gsVersion + "-macos-arm64"
+	intelName := "ghostscript-" + gsVersion + "-macos-x86_64"
+
+	fmt.Printf("Downloading %s and %s...\n", armName, intelName)
+	armBinary, err := fetchAsset(rel, armName, sums)
 	if err != nil {
-		fmt.Printf("Failed to write binary: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	intelBinary, err := fetchAsset(rel, intelName, sums)
+	if err != nil {
+		return err
 	}
 
-	// Make executable
-	err = os.Chmod(outputPath, 0755)
+	merged, err := lipo(armBinary, intelBinary)
 	if err != nil {
-		fmt.Printf("Failed to make binary executable: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	if err := writeCompressedBinary(merged); err != nil {
+		return err
+	}
+
+	fmt.Println("Downloading resource bundles...")
+	armBundle, armErr := fetchAsset(rel, armName+"-resources.tar.gz", sums)
+	intelBundle, intelErr := fetchAsset(rel, intelName+"-resources.tar.gz", sums)
+	if errors.Is(armErr, errNotFound) && errors.Is(intelErr, errNotFound) {
+		fmt.Println("No resource bundles in this release, embedding an empty file")
+		return os.WriteFile("ghostscript-bundle.tar.gz", nil, 0644)
+	}
+	if armErr != nil {
+		return armErr
+	}
+	if intelErr != nil {
+		return intelErr
+	}
+
+	bundle, err := mergeBundles(armBundle, intelBundle)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile("ghostscript-bundle.tar.gz", bundle, 0644)
+}
+
+// writeCompressedBinary stores the executable as a single zstd frame that
+// records its size, which the app uses to detect truncated extractions
+func writeCompressedBinary(data []byte) error {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	if err != nil {
+		return err
 	}
+	defer encoder.Close()
 
-	fmt.Printf("Successfully downloaded %s\n", outputPath)
-}
\ No newline at end of file
+	compressed := encoder.EncodeAll(data, nil)
+	fmt.Printf("Compressed binary from %d to %d bytes\n", len(data), len(compressed))
+
+	if err := os.WriteFile("ghostscript.zst", compressed, 0644); err != nil {
+		return fmt.Errorf("failed to write ghostscript.zst: %v", err)
+	}
+	return nil
+}
+
+// lipo combines two thin Mach-O files into a universal one
+func lipo(arm, intel []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "kleinpdf-lipo-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	armPath := filepath.Join(dir, "arm64")
+	intelPath := filepath.Join(dir, "x86_64")
+	outputPath := filepath.Join(dir, "universal")
+	if err := os.WriteFile(armPath, arm, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(intelPath, intel, 0755); err != nil {
+		return nil, err
+	}
+
+	if output, err := exec.Command("lipo", "-create", armPath, intelPath, "-output", outputPath).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("lipo failed: %v, output: %s", err, output)
+	}
+
+	return os.ReadFile(outputPath)
+}
+
+// isMachO reports whether data starts with a thin 64-bit Mach-O magic number
+func isMachO(data []byte) bool {
+	return bytes.HasPrefix(data, []byte{0xcf, 0xfa, 0xed, 0xfe}) || bytes.HasPrefix(data, []byte{0xfe, 0xed, 0xfa, 0xcf})
+}
+
+// tarEntry is a buffered tar archive member
+type tarEntry struct {
+	header *tar.Header
+	data   []byte
+}
+
+// readBundle loads every member of a gzipped tar archive
+func readBundle(data []byte) ([]tarEntry, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var entries []tarEntry
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, tarEntry{header, content})
+	}
+}
+
+// mergeBundles combines the arm64 and x86_64 resource bundles into one archive
+func mergeBundles(armBundle, intelBundle []byte) ([]byte, error) {
+	armEntries, err := readBundle(armBundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read arm64 bundle: %v", err)
+	}
+	intelEntries, err := readBundle(intelBundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read x86_64 bundle: %v", err)
+	}
+
+	intelFiles := make(map[string][]byte, len(intelEntries))
+	for _, entry := range intelEntries {
+		if entry.header.Typeflag == tar.TypeReg {
+			intelFiles[entry.header.Name] = entry.data
+		}
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	writer := tar.NewWriter(gz)
+	for _, entry := range armEntries {
+		data := entry.data
+		intelData, ok := intelFiles[entry.header.Name]
+		if entry.header.Typeflag == tar.TypeReg && ok && isMachO(data) && isMachO(intelData) && !bytes.Equal(data, intelData) {
+			if data, err = lipo(data, intelData); err != nil {
+				return nil, fmt.Errorf("failed to merge %s: %v", entry.header.Name, err)
+			}
+		}
+
+		header := *entry.header
+		header.Size = int64(len(data))
+		if err := writer.WriteHeader(&header); err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,10 +1,55 @@
 package binary
 
 import (
+	"bytes"
 	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 //go:generate go run generate.go
 
-//go:embed ghostscript
-var GhostscriptBinary []byte
\ No newline at end of file
+// GhostscriptVersion is the release of the embedded binary, as recorded by
+// go generate
+var GhostscriptVersion = strings.TrimSpace(ghostscriptVersionFile)
+
+//go:embed ghostscript-version.txt
+var ghostscriptVersionFile string
+
+// ghostscriptCompressed is the Ghostscript executable as a single zstd frame
+//
+//go:embed ghostscript.zst
+var ghostscriptCompressed []byte
+
+// GhostscriptBundle is a gzipped tar of the lib/ and share/ghostscript trees the
+// binary was built against. It is empty when the build has no resource bundle.
+//
+//go:embed ghostscript-bundle.tar.gz
+var GhostscriptBundle []byte
+
+// GhostscriptBinarySize returns the uncompressed size of the embedded binary
+// from the zstd frame header, or -1 if the header does not record it
+func GhostscriptBinarySize() int64 {
+	var header zstd.Header
+	if err := header.Decode(ghostscriptCompressed); err != nil || !header.HasFCS {
+		return -1
+	}
+	return int64(header.FrameContentSize)
+}
+
+// WriteGhostscriptBinary decompresses the embedded binary into w
+func WriteGhostscriptBinary(w io.Writer) error {
+	decoder, err := zstd.NewReader(bytes.NewReader(ghostscriptCompressed))
+	if err != nil {
+		return fmt.Errorf("failed to open embedded binary: %w", err)
+	}
+	defer decoder.Close()
+
+	if _, err := io.Copy(w, decoder); err != nil {
+		return fmt.Errorf("failed to decompress embedded binary: %w", err)
+	}
+	return nil
+}
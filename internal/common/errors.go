@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+)
+
+// ErrorCode is a machine-readable error category reported to the frontend
+type ErrorCode string
+
+const (
+	ErrGhostscriptMissing ErrorCode = "ghostscript_missing"
+	ErrEncryptedInput     ErrorCode = "encrypted_input"
+	ErrNotAPDF            ErrorCode = "not_a_pdf"
+	ErrDiskFull           ErrorCode = "disk_full"
+	ErrTimeout            ErrorCode = "timeout"
+	ErrGhostscriptCrash   ErrorCode = "gs_crash"
+	ErrCancelled          ErrorCode = "cancelled"
+	ErrInvalidRequest     ErrorCode = "invalid_request"
+	ErrInternal           ErrorCode = "internal"
+	ErrUnknown            ErrorCode = "unknown"
+)
+
+// CodedError is an error carrying an ErrorCode
+type CodedError struct {
+	Code    ErrorCode
+	Message string
+	Err     error
+}
+
+// NewError creates an error with a code and human-readable message
+func NewError(code ErrorCode, message string) *CodedError {
+	return &CodedError{Code: code, Message: message}
+}
+
+// WrapError attaches a code and message to an underlying error
+func WrapError(code ErrorCode, message string, err error) *CodedError {
+	return &CodedError{Code: code, Message: message, Err: err}
+}
+
+func (e *CodedError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCodeOf classifies an error, falling back to ErrUnknown
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+
+	// Out-of-space failures surface from Ghostscript output as well as syscalls
+	if errors.Is(err, syscall.ENOSPC) || strings.Contains(err.Error(), "No space left on device") {
+		return ErrDiskFull
+	}
+
+	var coded *CodedError
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrCancelled
+	}
+
+	return ErrUnknown
+}
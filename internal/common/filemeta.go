@@ -0,0 +1,22 @@
+package common
+
+import (
+	"os"
+	"time"
+)
+
+// CopyFileMetadata copies the modification time and the extended attributes
+// that carry user organization, such as Finder tags, from src to dst
+func CopyFileMetadata(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	// A zero access time leaves it unchanged
+	if err := os.Chtimes(dst, time.Time{}, info.ModTime()); err != nil {
+		return err
+	}
+
+	return copyXattrs(src, dst)
+}
@@ -0,0 +1,12 @@
+package common
+
+import "strings"
+
+// preservedXattr reports whether an attribute describes how the user filed
+// the document: Finder tags and color labels, Spotlight comments, the
+// download source and the quarantine flag
+func preservedXattr(name string) bool {
+	return strings.HasPrefix(name, "com.apple.metadata:") ||
+		name == "com.apple.FinderInfo" ||
+		name == "com.apple.quarantine"
+}
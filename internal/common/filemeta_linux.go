@@ -0,0 +1,9 @@
+package common
+
+import "strings"
+
+// preservedXattr reports whether an attribute is user metadata, such as the
+// tags and comments desktop file managers store under user.xdg
+func preservedXattr(name string) bool {
+	return strings.HasPrefix(name, "user.")
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !linux
+
+package common
+
+// copyXattrs is a no-op on platforms without extended attributes
+func copyXattrs(src, dst string) error {
+	return nil
+}
@@ -0,0 +1,56 @@
+//go:build darwin || linux
+
+package common
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyXattrs copies the preserved extended attributes of src onto dst.
+// Filesystems without extended attribute support are skipped silently.
+func copyXattrs(src, dst string) error {
+	size, err := unix.Listxattr(src, nil)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil
+		}
+		return err
+	}
+	if size == 0 {
+		return nil
+	}
+
+	names := make([]byte, size)
+	size, err = unix.Listxattr(src, names)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range bytes.Split(names[:size], []byte{0}) {
+		if len(name) == 0 || !preservedXattr(string(name)) {
+			continue
+		}
+
+		valueSize, err := unix.Getxattr(src, string(name), nil)
+		if err != nil {
+			return err
+		}
+		value := make([]byte, valueSize)
+		valueSize, err = unix.Getxattr(src, string(name), value)
+		if err != nil {
+			return err
+		}
+
+		if err := unix.Setxattr(dst, string(name), value[:valueSize], 0); err != nil {
+			if errors.Is(err, unix.ENOTSUP) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ghostscriptEnvMu sync.RWMutex
+	ghostscriptEnv   = map[string][]string{}
+
+	// ghostscriptMemoryMB caps the memory each Ghostscript interpreter
+	// allocates, or is 0 for no cap
+	ghostscriptMemoryMB atomic.Int64
+)
+
+// SetGhostscriptMemoryLimit caps the memory every Ghostscript command started
+// afterwards may allocate. Ghostscript enforces the cap itself with -K, so it
+// holds on macOS and Windows, where another process's memory cannot be limited.
+func SetGhostscriptMemoryLimit(megabytes int) {
+	ghostscriptMemoryMB.Store(int64(max(megabytes, 0)))
+}
+
+// SetGhostscriptEnv registers environment variables, such as GS_LIB and
+// DYLD_LIBRARY_PATH, that the Ghostscript binary at path needs to run
+func SetGhostscriptEnv(path string, env []string) {
+	ghostscriptEnvMu.Lock()
+	defer ghostscriptEnvMu.Unlock()
+	ghostscriptEnv[path] = env
+}
+
+// GhostscriptCommand builds the command for a Ghostscript binary, adding the
+// memory cap and any environment registered for it. Other installs keep the
+// inherited environment.
+func GhostscriptCommand(ctx context.Context, path string, args ...string) *exec.Cmd {
+	if megabytes := ghostscriptMemoryMB.Load(); megabytes > 0 {
+		args = append([]string{fmt.Sprintf("-K%d", megabytes*1024)}, args...)
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+
+	ghostscriptEnvMu.RLock()
+	env := ghostscriptEnv[path]
+	ghostscriptEnvMu.RUnlock()
+
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
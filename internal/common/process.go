@@ -0,0 +1,40 @@
+package common
+
+import (
+	"bytes"
+	"os/exec"
+)
+
+// BackgroundNiceness is the scheduling priority given to external tools in background mode
+const BackgroundNiceness = 10
+
+// ResourceLimits constrains external processes such as Ghostscript
+type ResourceLimits struct {
+	BackgroundPriority bool
+	MaxMemoryMB        int
+}
+
+// StartCommand starts cmd and applies the resource limits to the new process
+func StartCommand(cmd *exec.Cmd, limits ResourceLimits) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Limits are best effort; a failure should not abort the command
+	applyLimits(cmd.Process.Pid, limits)
+	return nil
+}
+
+// RunCommand runs cmd with the resource limits applied and returns its combined output
+func RunCommand(cmd *exec.Cmd, limits ResourceLimits) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := StartCommand(cmd, limits); err != nil {
+		return nil, err
+	}
+
+	err := cmd.Wait()
+	return output.Bytes(), err
+}
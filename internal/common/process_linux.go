@@ -0,0 +1,19 @@
+package common
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyLimits lowers the priority of a running process and caps its address space
+func applyLimits(pid int, limits ResourceLimits) {
+	if limits.BackgroundPriority {
+		syscall.Setpriority(syscall.PRIO_PROCESS, pid, BackgroundNiceness)
+	}
+
+	if limits.MaxMemoryMB > 0 {
+		limit := uint64(limits.MaxMemoryMB) * 1024 * 1024
+		unix.Prlimit(pid, unix.RLIMIT_AS, &unix.Rlimit{Cur: limit, Max: limit}, nil)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package common
+
+// applyLimits is a no-op on platforms without POSIX process priorities
+func applyLimits(pid int, limits ResourceLimits) {}
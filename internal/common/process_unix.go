@@ -0,0 +1,16 @@
+//go:build unix && !linux
+
+package common
+
+import (
+	"syscall"
+)
+
+// applyLimits lowers the priority of a running process. Memory caps cannot be
+// set on another process here, so MaxMemoryMB only reaches Ghostscript, which
+// caps itself.
+func applyLimits(pid int, limits ResourceLimits) {
+	if limits.BackgroundPriority {
+		syscall.Setpriority(syscall.PRIO_PROCESS, pid, BackgroundNiceness)
+	}
+}
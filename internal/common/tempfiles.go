@@ -0,0 +1,67 @@
+package common
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// secureDelete makes RemoveTemp overwrite files before deleting them
+var secureDelete atomic.Bool
+
+// SetSecureDelete turns overwriting temporary copies of documents before
+// deletion on or off
+func SetSecureDelete(enabled bool) {
+	secureDelete.Store(enabled)
+}
+
+// RemoveTemp deletes a temporary copy of a document, overwriting it with
+// zeros first when secure delete is on. Overwriting is best effort: SSDs and
+// copy-on-write filesystems may keep the old blocks.
+func RemoveTemp(path string) error {
+	if secureDelete.Load() {
+		overwriteFile(path)
+	}
+	return os.Remove(path)
+}
+
+// RemoveTempDir deletes a temporary directory like RemoveTemp
+func RemoveTempDir(dir string) error {
+	if secureDelete.Load() {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && entry.Type().IsRegular() {
+				overwriteFile(path)
+			}
+			return nil
+		})
+	}
+	return os.RemoveAll(dir)
+}
+
+// overwriteFile replaces the contents of a regular file with zeros in place
+func overwriteFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return err
+	}
+
+	zeros := make([]byte, 64*1024)
+	for remaining := info.Size(); remaining > 0; {
+		n := int64(len(zeros))
+		if remaining < n {
+			n = remaining
+		}
+		if _, err := file.Write(zeros[:n]); err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	return file.Sync()
+}
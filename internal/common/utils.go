@@ -1,6 +1,11 @@
 package common
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+
 	"github.com/google/uuid"
 )
 
@@ -9,6 +14,10 @@ const (
 	DefaultCompressionLevel = "good_enough"
 	MaxConcurrencyLimit     = 8
 
+	// Files at or above LargeFileThreshold bytes share MaxConcurrentLargeFiles slots
+	LargeFileThreshold      = 200 * 1024 * 1024
+	MaxConcurrentLargeFiles = 2
+
 	// File operation constants
 	DefaultFilePermissions = 0755
 )
@@ -16,4 +25,41 @@ const (
 // GenerateUUID generates a new UUID string
 func GenerateUUID() string {
 	return uuid.New().String()
-}
\ No newline at end of file
+}
+
+// CopyFile copies the contents of src to dst, replacing dst if it exists
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// HashFile returns the hex-encoded SHA-256 digest of a file's contents
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,227 @@
+package compression
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// AutoLevel is the compression level that picks settings per file from an
+// analysis of its images
+const AutoLevel = "auto"
+
+const (
+	// autoCompactBytesPerPage is the size per page below which a file is
+	// already compact and only gets the gentle level
+	autoCompactBytesPerPage = 30 * 1024
+
+	// autoHighDPI is the image resolution above which downsampling pays off
+	autoHighDPI = 225
+
+	// autoImageDPI is the resolution auto downsamples colour and gray images to
+	autoImageDPI = 150
+
+	// autoMonoScanDPI keeps black-and-white scans sharp enough for small text
+	autoMonoScanDPI = 300
+)
+
+// AutoChoice records the settings the auto level picked for a file and why
+type AutoChoice struct {
+	Level    string   `json:"level"`
+	ImageDPI int      `json:"image_dpi"`
+	Reasons  []string `json:"reasons"`
+}
+
+// Apply returns a copy of options with the chosen settings
+func (c *AutoChoice) Apply(options *CompressionOptions) *CompressionOptions {
+	applied := *options
+	applied.ImageDPI = c.ImageDPI
+	return &applied
+}
+
+// autoProfile summarises what the auto level looks at
+type autoProfile struct {
+	pages       int
+	fonts       int
+	fileSize    int64
+	images      int
+	pagesImaged int
+	monoImages  int
+	jpegImages  int
+	medianDPI   float64
+}
+
+// ChooseAutoSettings analyses a PDF and picks the level and image resolution
+// for it. Auto never picks ultra, which trades visible quality for size. A
+// file that cannot be analysed gets the gentle level rather than an error.
+func (c *Compressor) ChooseAutoSettings(inputPath string, options *CompressionOptions) *AutoChoice {
+	fallbackDPI := options.ImageDPI
+	if fallbackDPI <= 0 {
+		fallbackDPI = autoImageDPI
+	}
+
+	profile, err := readAutoProfile(inputPath, options.InputPassword)
+	if err != nil {
+		c.logger.Debug("Could not analyse file for auto level", "file", inputPath, "error", err)
+		return &AutoChoice{
+			Level:    "good_enough",
+			ImageDPI: fallbackDPI,
+			Reasons:  []string{"the file could not be analysed, so the gentle level is used"},
+		}
+	}
+
+	choice := chooseAuto(profile, fallbackDPI)
+	c.logger.Debug("Auto level chose settings", "file", inputPath, "level", choice.Level, "dpi", choice.ImageDPI, "reasons", choice.Reasons)
+	return choice
+}
+
+// chooseAuto applies the auto rules to a profile
+func chooseAuto(profile *autoProfile, fallbackDPI int) *AutoChoice {
+	if profile.pages > 0 && profile.fileSize/int64(profile.pages) < autoCompactBytesPerPage {
+		return &AutoChoice{
+			Level:    "good_enough",
+			ImageDPI: fallbackDPI,
+			Reasons:  []string{fmt.Sprintf("already compact at %d KB per page", profile.fileSize/int64(profile.pages)/1024)},
+		}
+	}
+
+	if profile.images == 0 {
+		return &AutoChoice{
+			Level:    "good_enough",
+			ImageDPI: fallbackDPI,
+			Reasons:  []string{"no images; text and vector content only needs optimising"},
+		}
+	}
+
+	// Like Analyze, a scan has an image on (nearly) every page and no fonts
+	scanned := profile.fonts == 0 && float64(profile.pagesImaged) >= 0.8*float64(profile.pages)
+	dpiReason := fmt.Sprintf("images are around %.0f dpi", profile.medianDPI)
+	if profile.medianDPI < 72 {
+		dpiReason = "images are small graphics rather than full-page pictures"
+	}
+
+	switch {
+	case scanned && profile.monoImages*2 >= profile.images:
+		return &AutoChoice{
+			Level:    "aggressive",
+			ImageDPI: autoMonoScanDPI,
+			Reasons: []string{
+				"black-and-white scan",
+				fmt.Sprintf("kept at %d dpi so small print stays legible", autoMonoScanDPI),
+			},
+		}
+	case scanned:
+		resolution := fmt.Sprintf("downsampled to %d dpi, which keeps scanned text readable", autoImageDPI)
+		if profile.medianDPI <= autoImageDPI {
+			resolution = "already low resolution, so only re-encoded"
+		}
+		return &AutoChoice{
+			Level:    "aggressive",
+			ImageDPI: autoImageDPI,
+			Reasons:  []string{"scanned pages without a text layer", dpiReason, resolution},
+		}
+	case profile.medianDPI > autoHighDPI:
+		return &AutoChoice{
+			Level:    "aggressive",
+			ImageDPI: autoImageDPI,
+			Reasons: []string{
+				dpiReason + ", more than screens and office printers show",
+				fmt.Sprintf("downsampled to %d dpi", autoImageDPI),
+			},
+		}
+	case profile.jpegImages*5 >= profile.images*4:
+		return &AutoChoice{
+			Level:    "good_enough",
+			ImageDPI: fallbackDPI,
+			Reasons: []string{
+				dpiReason,
+				"images are already JPEG; compressing them again would add artefacts for little gain",
+			},
+		}
+	default:
+		return &AutoChoice{
+			Level:    "aggressive",
+			ImageDPI: autoImageDPI,
+			Reasons: []string{
+				dpiReason,
+				"images are stored without lossy compression, so JPEG encoding saves the most",
+			},
+		}
+	}
+}
+
+// readAutoProfile collects the page, font and image statistics of a PDF
+func readAutoProfile(inputPath, password string) (*autoProfile, error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+	info, err := api.PDFInfo(file, inputPath, nil, true, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	pageImages, err := api.Images(file, nil, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read images: %v", err)
+	}
+
+	profile := &autoProfile{
+		pages:    info.PageCount,
+		fonts:    len(info.Fonts),
+		fileSize: stat.Size(),
+	}
+	// The widest image stands for each page; logos and icons would otherwise
+	// drag the estimate down
+	pageDPI := make(map[int]float64)
+	for _, images := range pageImages {
+		for _, image := range images {
+			if image.Thumb || image.IsImgMask {
+				continue
+			}
+			profile.images++
+			if _, ok := pageDPI[image.PageNr]; !ok {
+				pageDPI[image.PageNr] = 0
+			}
+			if image.Bpc == 1 {
+				profile.monoImages++
+			}
+			if strings.Contains(image.Filter, "DCTDecode") || strings.Contains(image.Filter, "JPXDecode") {
+				profile.jpegImages++
+			}
+			if dpi, ok := imageDPI(info, image); ok {
+				pageDPI[image.PageNr] = max(pageDPI[image.PageNr], dpi)
+			}
+		}
+	}
+	profile.pagesImaged = len(pageDPI)
+
+	dpis := make([]float64, 0, len(pageDPI))
+	for _, dpi := range pageDPI {
+		dpis = append(dpis, dpi)
+	}
+	if len(dpis) > 0 {
+		slices.Sort(dpis)
+		profile.medianDPI = dpis[len(dpis)/2]
+	}
+
+	return profile, nil
+}
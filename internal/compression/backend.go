@@ -0,0 +1,114 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	// BackendGhostscript compresses with the embedded Ghostscript binary
+	BackendGhostscript = "ghostscript"
+	// BackendPdfcpu optimizes with the pure-Go pdfcpu library
+	BackendPdfcpu = "pdfcpu"
+	// BackendQpdf optimizes with an external qpdf binary
+	BackendQpdf = "qpdf"
+)
+
+// Backend is a compression engine
+type Backend interface {
+	Name() string
+	Available() bool
+	Capabilities() Capabilities
+	Compress(ctx context.Context, inputPath, outputPath, compressionLevel string, options *CompressionOptions, onProgress ProgressFunc) error
+}
+
+// Capabilities describes which compression options a backend supports
+type Capabilities struct {
+	ImageDownsampling      bool `json:"image_downsampling"`
+	Grayscale              bool `json:"grayscale"`
+	MetadataRemoval        bool `json:"metadata_removal"`
+	PageResizing           bool `json:"page_resizing"`
+	TransparencyFlattening bool `json:"transparency_flattening"`
+	Encryption             bool `json:"encryption"`
+	Decryption             bool `json:"decryption"`
+	OCR                    bool `json:"ocr"`
+	Progress               bool `json:"progress"`
+}
+
+// BackendInfo describes a registered backend for the frontend
+type BackendInfo struct {
+	Name         string       `json:"name"`
+	Available    bool         `json:"available"`
+	Capabilities Capabilities `json:"capabilities"`
+}
+
+// Backends lists the registered backends in order of preference
+func (c *Compressor) Backends() []BackendInfo {
+	infos := make([]BackendInfo, len(c.backends))
+	for i, backend := range c.backends {
+		infos[i] = BackendInfo{
+			Name:         backend.Name(),
+			Available:    backend.Available(),
+			Capabilities: backend.Capabilities(),
+		}
+	}
+	return infos
+}
+
+// DefaultBackend returns the name of the backend used when none is requested
+func (c *Compressor) DefaultBackend() string {
+	backend, err := c.selectBackend("")
+	if err != nil {
+		return ""
+	}
+	return backend.Name()
+}
+
+// selectBackend returns the named backend, or the first available one if name is empty
+func (c *Compressor) selectBackend(name string) (Backend, error) {
+	for _, backend := range c.backends {
+		if name != "" && backend.Name() != name {
+			continue
+		}
+		if backend.Available() {
+			return backend, nil
+		}
+		if name == BackendGhostscript {
+			return nil, common.NewError(common.ErrGhostscriptMissing, "ghostscript not found. Please install ghostscript to use this application")
+		}
+		if name != "" {
+			return nil, fmt.Errorf("compression backend %q is not available", name)
+		}
+	}
+
+	if name != "" {
+		return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown compression backend %q", name))
+	}
+	return nil, common.NewError(common.ErrGhostscriptMissing, "no compression backend available")
+}
+
+// checkCapabilities rejects options the backend cannot honour
+func checkCapabilities(backend Backend, options *CompressionOptions) error {
+	caps := backend.Capabilities()
+
+	switch {
+	case options.ConvertToGrayscale && !caps.Grayscale:
+		return fmt.Errorf("%s backend does not support grayscale conversion", backend.Name())
+	case options.RemoveMetadata && !caps.MetadataRemoval:
+		return fmt.Errorf("%s backend does not support removing metadata", backend.Name())
+	case options.ResizeTarget != "" && !caps.PageResizing:
+		return fmt.Errorf("%s backend does not support resizing pages", backend.Name())
+	case options.FlattenTransparency && !caps.TransparencyFlattening:
+		return fmt.Errorf("%s backend does not support flattening transparency", backend.Name())
+	case options.AddTextLayer && !caps.OCR:
+		return fmt.Errorf("%s backend cannot add an OCR text layer with the installed tools", backend.Name())
+	case (options.OwnerPassword != "" || options.UserPassword != "") && !caps.Encryption:
+		return fmt.Errorf("%s backend does not support encrypting output", backend.Name())
+	case options.InputPassword != "" && !caps.Decryption:
+		return fmt.Errorf("%s backend does not support password-protected input", backend.Name())
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package compression
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+
+	"kleinpdf/internal/common"
+)
+
+// readBookmarks returns the outline tree of a PDF, or nil if it has none
+func readBookmarks(path, password string) ([]pdfcpu.Bookmark, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	return api.Bookmarks(file, conf)
+}
+
+// restoreBookmarks re-applies the source outline tree when a backend dropped it.
+// Compression never changes page numbering, so source bookmarks stay valid.
+func (c *Compressor) restoreBookmarks(inputPath, outputPath string, options *CompressionOptions) error {
+	sourceBookmarks, err := readBookmarks(inputPath, options.InputPassword)
+	if err != nil {
+		return fmt.Errorf("failed to read source bookmarks: %v", err)
+	}
+	if len(sourceBookmarks) == 0 {
+		return nil
+	}
+
+	outputPassword := options.OwnerPassword
+	if outputPassword == "" {
+		outputPassword = options.UserPassword
+	}
+
+	outputBookmarks, err := readBookmarks(outputPath, outputPassword)
+	if err != nil {
+		return fmt.Errorf("failed to read output bookmarks: %v", err)
+	}
+	if len(outputBookmarks) > 0 {
+		return nil
+	}
+
+	c.logger.Debug("Restoring dropped bookmarks", "file", inputPath, "count", len(sourceBookmarks))
+
+	tempPath, err := c.tempFilePath(inputPath, "bookmarks")
+	if err != nil {
+		return err
+	}
+	defer common.RemoveTemp(tempPath)
+
+	conf := newPdfcpuConfiguration()
+	if outputPassword != "" {
+		conf.UserPW = options.UserPassword
+		conf.OwnerPW = outputPassword
+	}
+
+	if err := api.AddBookmarksFile(outputPath, tempPath, sourceBookmarks, true, conf); err != nil {
+		return fmt.Errorf("failed to add bookmarks: %v", err)
+	}
+
+	return os.Rename(tempPath, outputPath)
+}
@@ -1,131 +1,242 @@
 package compression
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
+
+	"kleinpdf/internal/common"
 )
 
 // Compressor handles PDF compression operations
 type Compressor struct {
+	ocrTool      string
+	ocrPath      string
+	officePath   string
+	htmlRenderer string
+	htmlPath     string
+	logger       *slog.Logger
+	backends     []Backend
+	pool         *ghostscriptPool
+
+	settingsMu      sync.RWMutex
 	ghostscriptPath string
-	logger          *slog.Logger
+	workingDir      string
+	limits          common.ResourceLimits
 }
 
 // NewCompressor creates a new compressor instance
 func NewCompressor(ghostscriptPath string, logger *slog.Logger) *Compressor {
-	return &Compressor{
+	ocrTool, ocrPath := detectOCRTool()
+	htmlRenderer, htmlPath := detectHTMLRenderer()
+	c := &Compressor{
 		ghostscriptPath: ghostscriptPath,
+		ocrTool:         ocrTool,
+		ocrPath:         ocrPath,
+		officePath:      detectOffice(),
+		htmlRenderer:    htmlRenderer,
+		htmlPath:        htmlPath,
 		logger:          logger,
+		pool:            newGhostscriptPool(common.MaxConcurrencyLimit),
 	}
-}
 
-// CompressFile compresses a PDF file using Ghostscript
-func (c *Compressor) CompressFile(inputPath, outputPath, compressionLevel string, options *CompressionOptions) error {
-	if c.ghostscriptPath == "" {
-		return fmt.Errorf("ghostscript not found. Please install ghostscript to use this application")
+	// Backends in order of preference when none is requested
+	c.backends = []Backend{
+		&ghostscriptBackend{c: c},
+		&pdfcpuBackend{c: c},
+		newQpdfBackend(c),
 	}
 
+	return c
+}
+
+// CompressFile compresses a PDF file with the backend named in options, or the
+// first available one. Cancelling ctx stops the backend; onProgress, if set,
+// receives per-page progress from backends that support it.
+func (c *Compressor) CompressFile(ctx context.Context, inputPath, outputPath, compressionLevel string, options *CompressionOptions, onProgress ProgressFunc) error {
 	if options == nil {
 		defaultOptions := DefaultCompressionOptions()
 		options = &defaultOptions
 	}
 
-	// Validate and set defaults for required fields if they are empty
-	if options.PDFVersion == "" {
-		options.PDFVersion = "1.4"
+	// Pick a concrete level for the auto level from the input itself
+	if compressionLevel == AutoLevel {
+		choice := c.ChooseAutoSettings(inputPath, options)
+		compressionLevel = choice.Level
+		options = choice.Apply(options)
 	}
-	if options.ImageDPI <= 0 {
-		options.ImageDPI = 150
+
+	// Only pdfcpu deduplicates resources without re-encoding images
+	if options.OptimizeOnly {
+		if err := checkOptimizeOnly(options); err != nil {
+			return err
+		}
+		optimizeOptions := *options
+		optimizeOptions.Backend = BackendPdfcpu
+		options = &optimizeOptions
 	}
-	if options.ImageQuality <= 0 {
-		options.ImageQuality = 85
+
+	backend, err := c.selectBackend(options.Backend)
+	if err != nil {
+		return err
 	}
 
-	// Handle grayscale conversion if needed
-	actualInputPath := inputPath
-	if options.ConvertToGrayscale {
-		tempGrayscalePath := strings.Replace(inputPath, ".pdf", "_grayscale_temp.pdf", 1)
+	if err := checkCapabilities(backend, options); err != nil {
+		return err
+	}
+
+	c.logger.Debug("Compressing file", "file", inputPath, "backend", backend.Name())
 
-		err := c.ConvertToGrayscale(inputPath, tempGrayscalePath)
+	// Drop attachments, scripts and media before any other pass copies them
+	if wantsStrip(options) {
+		tempStripPath, err := c.tempFilePath(inputPath, "strip")
 		if err != nil {
-			return fmt.Errorf("grayscale conversion failed: %v", err)
+			return err
 		}
+		defer common.RemoveTemp(tempStripPath)
 
-		actualInputPath = tempGrayscalePath
-		defer os.Remove(tempGrayscalePath) // Clean up temp file
-	}
+		if err := StripContent(inputPath, tempStripPath, options); err != nil {
+			return err
+		}
 
-	// Build Ghostscript command based on compression level
-	var pdfSettings string
-	switch compressionLevel {
-	case "ultra":
-		pdfSettings = "/screen"
-	case "aggressive":
-		pdfSettings = "/ebook"
-	default: // good_enough
-		pdfSettings = "/printer"
+		// The stripped copy is unencrypted
+		stripOptions := *options
+		stripOptions.InputPassword = ""
+		options = &stripOptions
+		inputPath = tempStripPath
 	}
 
-	args := []string{
-		"-sDEVICE=pdfwrite",
-		"-dPDFSETTINGS=" + pdfSettings,
-		"-dCompatibilityLevel=" + options.PDFVersion,
-		"-dNOPAUSE",
-		"-dQUIET",
-		"-dBATCH",
-		"-dAutoRotatePages=/None",
-		"-dColorImageDownsampleType=/Bicubic",
-		fmt.Sprintf("-dColorImageResolution=%d", options.ImageDPI),
-		"-dGrayImageDownsampleType=/Bicubic",
-		fmt.Sprintf("-dGrayImageResolution=%d", options.ImageDPI),
-		"-dMonoImageDownsampleType=/Bicubic",
-		fmt.Sprintf("-dMonoImageResolution=%d", options.ImageDPI),
-		"-dColorConversionStrategy=/sRGB",
-		fmt.Sprintf("-dEmbedAllFonts=%t", options.EmbedFonts),
-		"-dSubsetFonts=true",
-		"-dOptimize=true",
-		"-dDownsampleColorImages=true",
-		"-dDownsampleGrayImages=true",
-		"-dDownsampleMonoImages=true",
-	}
+	// Burn form fields into the pages and drop review annotations
+	if wantsFlatten(options) {
+		tempFlattenPath, err := c.tempFilePath(inputPath, "flatten")
+		if err != nil {
+			return err
+		}
+		defer common.RemoveTemp(tempFlattenPath)
 
-	// Add ultra-specific options
-	if compressionLevel == "ultra" {
-		args = append(args, "-dCompressFonts=true", "-dCompressStreams=true")
+		if err := FlattenAnnotations(inputPath, tempFlattenPath, options); err != nil {
+			return err
+		}
+
+		// The flattened copy is unencrypted
+		flattenOptions := *options
+		flattenOptions.InputPassword = ""
+		options = &flattenOptions
+		inputPath = tempFlattenPath
 	}
 
-	// Add metadata removal if enabled
-	if options.RemoveMetadata {
-		args = append(args, "-dPDFX", "-dUseCIEColor")
+	// Drop layers and annotations that are never shown on screen
+	if options.RemoveHiddenLayers {
+		tempLayersPath, err := c.tempFilePath(inputPath, "layers")
+		if err != nil {
+			return err
+		}
+		defer common.RemoveTemp(tempLayersPath)
+
+		if err := RemoveHiddenLayers(inputPath, tempLayersPath, options); err != nil {
+			return err
+		}
+
+		// The copy without hidden layers is unencrypted
+		layersOptions := *options
+		layersOptions.InputPassword = ""
+		options = &layersOptions
+		inputPath = tempLayersPath
 	}
 
-	// Add thumbnail generation if enabled
-	if options.GenerateThumbnails {
-		args = append(args, "-dGenerateThumbnails=true")
+	// Crop scan margins before compressing
+	if options.TrimMargins {
+		tempTrimPath, err := c.tempFilePath(inputPath, "trim")
+		if err != nil {
+			return err
+		}
+		defer common.RemoveTemp(tempTrimPath)
+
+		if err := c.TrimMargins(ctx, inputPath, tempTrimPath, options.InputPassword); err != nil {
+			return err
+		}
+
+		// The trimmed copy is unencrypted
+		trimOptions := *options
+		trimOptions.InputPassword = ""
+		options = &trimOptions
+		inputPath = tempTrimPath
 	}
 
-	args = append(args, "-sOutputFile="+outputPath, actualInputPath)
+	// Impose several pages per sheet before compressing
+	if options.PagesPerSheet > 1 {
+		tempNUpPath, err := c.tempFilePath(inputPath, "nup")
+		if err != nil {
+			return err
+		}
+		defer common.RemoveTemp(tempNUpPath)
+
+		if err := NUpFile(inputPath, tempNUpPath, options.PagesPerSheet, options.InputPassword); err != nil {
+			return err
+		}
+
+		// The imposed copy is unencrypted and its outline no longer matches the source
+		nupOptions := *options
+		nupOptions.InputPassword = ""
+		nupOptions.PreserveBookmarks = false
+		options = &nupOptions
+		inputPath = tempNUpPath
+	}
 
-	// Execute Ghostscript command
-	cmd := exec.Command(c.ghostscriptPath, args...)
-	output, err := cmd.CombinedOutput()
+	// Leave pages without high-resolution images untouched when asked
+	if options.SelectiveDPI > 0 {
+		err = c.compressSelectedPages(ctx, backend, inputPath, outputPath, compressionLevel, options, onProgress)
+	} else {
+		err = c.compressPages(ctx, backend, inputPath, outputPath, compressionLevel, options, onProgress)
+	}
 	if err != nil {
-		return fmt.Errorf("ghostscript failed: %v, output: %s", err, string(output))
+		return err
 	}
 
-	// Check if output file was created
-	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-		return fmt.Errorf("ghostscript did not create output file")
+	// Ghostscript can drop the outline tree; a missing TOC is worth a warning, not a failure
+	if options.PreserveBookmarks {
+		if err := c.restoreBookmarks(inputPath, outputPath, options); err != nil {
+			c.logger.Warn("Failed to preserve bookmarks", "file", inputPath, "error", err)
+		}
 	}
 
 	return nil
 }
 
+// compressPages runs the backend over a whole document
+func (c *Compressor) compressPages(ctx context.Context, backend Backend, inputPath, outputPath, compressionLevel string, options *CompressionOptions, onProgress ProgressFunc) error {
+	// Email presets iterate towards a target size; a structural pass has nothing to iterate
+	if preset, ok := LookupEmailPreset(compressionLevel); ok && !options.OptimizeOnly {
+		return c.compressToTarget(ctx, backend, inputPath, outputPath, preset, options, onProgress)
+	}
+	return backend.Compress(ctx, inputPath, outputPath, compressionLevel, options, onProgress)
+}
+
+// checkOptimizeOnly rejects options that would change how pages render
+func checkOptimizeOnly(options *CompressionOptions) error {
+	switch {
+	case options.ConvertToGrayscale:
+		return common.NewError(common.ErrInvalidRequest, "grayscale conversion is not available in optimize-only mode")
+	case options.AddTextLayer:
+		return common.NewError(common.ErrInvalidRequest, "OCR is not available in optimize-only mode")
+	case options.ResizeTarget != "":
+		return common.NewError(common.ErrInvalidRequest, "resizing pages is not available in optimize-only mode")
+	case options.TrimMargins:
+		return common.NewError(common.ErrInvalidRequest, "trimming margins is not available in optimize-only mode")
+	case options.PagesPerSheet > 1:
+		return common.NewError(common.ErrInvalidRequest, "printing several pages per sheet is not available in optimize-only mode")
+	case options.SelectiveDPI > 0:
+		return common.NewError(common.ErrInvalidRequest, "compressing selected pages is not available in optimize-only mode")
+	}
+	return nil
+}
+
 // ConvertToGrayscale converts a PDF to grayscale
-func (c *Compressor) ConvertToGrayscale(inputPath, outputPath string) error {
+func (c *Compressor) ConvertToGrayscale(ctx context.Context, inputPath, outputPath, password string) error {
 	args := []string{
 		"-sDEVICE=pdfwrite",
 		"-sProcessColorModel=DeviceGray",
@@ -135,26 +246,85 @@ func (c *Compressor) ConvertToGrayscale(inputPath, outputPath string) error {
 		"-dNOPAUSE",
 		"-dQUIET",
 		"-dBATCH",
-		"-sOutputFile=" + outputPath,
-		inputPath,
 	}
+	if password != "" {
+		args = append(args, "-sPDFPassword="+password)
+	}
+	args = append(args, "-sOutputFile="+outputPath, inputPath)
 
-	cmd := exec.Command(c.ghostscriptPath, args...)
-	output, err := cmd.CombinedOutput()
+	cmd := common.GhostscriptCommand(ctx, c.GetGhostscriptPath(), args...)
+	output, err := common.RunCommand(cmd, c.ResourceLimits())
 
 	if err != nil {
+		if ctx.Err() != nil {
+			os.Remove(outputPath)
+			return ctx.Err()
+		}
 		return fmt.Errorf("grayscale conversion failed: %v, output: %s", err, string(output))
 	}
 
 	return nil
 }
 
+// Close stops the persistent Ghostscript workers
+func (c *Compressor) Close() {
+	c.pool.close()
+}
+
 // IsAvailable checks if Ghostscript is available
 func (c *Compressor) IsAvailable() bool {
-	return c.ghostscriptPath != ""
+	return c.GetGhostscriptPath() != ""
 }
 
 // GetGhostscriptPath returns the path to Ghostscript executable
 func (c *Compressor) GetGhostscriptPath() string {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
 	return c.ghostscriptPath
-}
\ No newline at end of file
+}
+
+// SetGhostscriptPath switches to another Ghostscript executable
+func (c *Compressor) SetGhostscriptPath(path string) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.ghostscriptPath = path
+}
+
+// SetWorkingDir sets the directory for intermediate files. An empty value uses the system temp dir.
+func (c *Compressor) SetWorkingDir(dir string) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.workingDir = dir
+}
+
+// WorkingDir returns the directory for intermediate files
+func (c *Compressor) WorkingDir() string {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	return c.workingDir
+}
+
+// SetResourceLimits sets the priority and memory limits for external tools
+func (c *Compressor) SetResourceLimits(limits common.ResourceLimits) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.limits = limits
+}
+
+// ResourceLimits returns the limits applied to external tools
+func (c *Compressor) ResourceLimits() common.ResourceLimits {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	return c.limits
+}
+
+// tempFilePath reserves a uniquely named intermediate file in the working directory
+func (c *Compressor) tempFilePath(inputPath, suffix string) (string, error) {
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), ".pdf")
+	file, err := os.CreateTemp(c.WorkingDir(), baseName+"_*_"+suffix+"_temp.pdf")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	file.Close()
+	return file.Name(), nil
+}
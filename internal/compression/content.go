@@ -0,0 +1,196 @@
+package compression
+
+import (
+	"bytes"
+)
+
+// contentOp is one operator of a content stream with its operands. Start and
+// end are the byte range covering the operands and the operator.
+type contentOp struct {
+	operator string
+	operands []string
+	start    int
+	end      int
+}
+
+// contentScanner splits a decoded content stream into operators. It only
+// tokenizes as far as needed to find operator boundaries: strings, arrays,
+// dicts and inline image data are skipped without being interpreted.
+type contentScanner struct {
+	data []byte
+	pos  int
+}
+
+// isContentWhitespace reports whether b is PDF whitespace
+func isContentWhitespace(b byte) bool {
+	switch b {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// isContentDelimiter reports whether b ends a regular token
+func isContentDelimiter(b byte) bool {
+	switch b {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return isContentWhitespace(b)
+}
+
+// next returns the next operator, or false at the end of the stream
+func (s *contentScanner) next() (contentOp, bool) {
+	op := contentOp{start: -1}
+	for {
+		s.skipWhitespace()
+		if s.pos >= len(s.data) {
+			return op, false
+		}
+		if op.start < 0 {
+			op.start = s.pos
+		}
+
+		start := s.pos
+		switch c := s.data[s.pos]; {
+		case c == '%':
+			s.skipComment()
+			continue
+		case c == '(':
+			s.skipString()
+		case c == '<' && s.peek(1) == '<', c == '>' && s.peek(1) == '>':
+			s.pos += 2
+		case c == '<':
+			s.skipHexString()
+		case c == '[', c == ']', c == '{', c == '}', c == ')', c == '>':
+			s.pos++
+		case c == '/':
+			s.pos++
+			s.skipRegular()
+		default:
+			s.skipRegular()
+			if s.pos == start {
+				s.pos++ // Stray byte; never stall
+				continue
+			}
+			token := string(s.data[start:s.pos])
+			if !isContentOperator(token) {
+				op.operands = append(op.operands, token)
+				continue
+			}
+
+			op.operator = token
+			if token == "BI" {
+				s.skipInlineImage()
+			}
+			op.end = s.pos
+			return op, true
+		}
+		op.operands = append(op.operands, string(s.data[start:s.pos]))
+	}
+}
+
+// isContentOperator reports whether a regular token is an operator rather
+// than a number or keyword operand
+func isContentOperator(token string) bool {
+	switch token {
+	case "true", "false", "null":
+		return false
+	}
+	c := token[0]
+	return !(c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.')
+}
+
+func (s *contentScanner) peek(offset int) byte {
+	if s.pos+offset < len(s.data) {
+		return s.data[s.pos+offset]
+	}
+	return 0
+}
+
+func (s *contentScanner) skipWhitespace() {
+	for s.pos < len(s.data) && isContentWhitespace(s.data[s.pos]) {
+		s.pos++
+	}
+}
+
+func (s *contentScanner) skipRegular() {
+	for s.pos < len(s.data) && !isContentDelimiter(s.data[s.pos]) {
+		s.pos++
+	}
+}
+
+func (s *contentScanner) skipComment() {
+	for s.pos < len(s.data) && s.data[s.pos] != '\n' && s.data[s.pos] != '\r' {
+		s.pos++
+	}
+}
+
+// skipString skips a literal string, honouring escapes and balanced parentheses
+func (s *contentScanner) skipString() {
+	depth := 0
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				s.pos++
+				return
+			}
+		}
+		s.pos++
+	}
+}
+
+func (s *contentScanner) skipHexString() {
+	if end := bytes.IndexByte(s.data[s.pos:], '>'); end >= 0 {
+		s.pos += end + 1
+	} else {
+		s.pos = len(s.data)
+	}
+}
+
+// skipInlineImage moves past the parameters and binary data of an inline
+// image, up to and including the EI operator
+func (s *contentScanner) skipInlineImage() {
+	for {
+		s.skipWhitespace()
+		start := s.pos
+		if s.pos >= len(s.data) {
+			return
+		}
+		if s.data[s.pos] == '/' {
+			s.pos++
+		}
+		s.skipRegular()
+		if s.pos == start {
+			s.pos++
+			continue
+		}
+		if string(s.data[start:s.pos]) == "ID" {
+			break
+		}
+	}
+
+	// One whitespace byte separates ID from the data; the data ends at an EI
+	// token surrounded by whitespace
+	s.pos++
+	for s.pos < len(s.data) {
+		i := bytes.Index(s.data[s.pos:], []byte("EI"))
+		if i < 0 {
+			s.pos = len(s.data)
+			return
+		}
+		at := s.pos + i
+		s.pos = at + 2
+		before := at == 0 || isContentWhitespace(s.data[at-1])
+		after := s.pos >= len(s.data) || isContentDelimiter(s.data[s.pos])
+		if before && after {
+			return
+		}
+	}
+}
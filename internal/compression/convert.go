@@ -0,0 +1,43 @@
+package compression
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// ConvertibleImageFormats are the image formats ImageToPDF can read
+var ConvertibleImageFormats = map[string]bool{
+	"jpeg": true,
+	"png":  true,
+	"tiff": true,
+	"webp": true,
+}
+
+// ImageToPDF writes a PDF with the image on a page of its own size
+func ImageToPDF(inputPath, outputPath string) error {
+	image, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer image.Close()
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	// A full-page position sizes each page to its image
+	err = api.ImportImages(nil, output, []io.Reader{image}, pdfcpu.DefaultImportConfig(), newPdfcpuConfiguration())
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("image conversion failed: %v", err)
+	}
+	return nil
+}
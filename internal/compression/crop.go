@@ -0,0 +1,249 @@
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	// trimMarginPadding keeps a small border around the detected content, in points
+	trimMarginPadding = 6.0
+
+	// trimScanCoverage is the share of the page a content box must cover for
+	// the page to be treated as a scan, whose image fills the page
+	trimScanCoverage = 0.95
+
+	// trimScanDPI is the resolution scans are rendered at to find their content
+	trimScanDPI = 50
+
+	// trimScanThreshold is the gray level below which a pixel is content;
+	// paper tint and faint scanner noise are lighter
+	trimScanThreshold = 192
+
+	// trimScanSpeck is the fewest dark pixels a row or column needs to count
+	// as content, so stray dust does not stop the trim
+	trimScanSpeck = 2
+)
+
+var hiResBoundingBox = regexp.MustCompile(`%%HiResBoundingBox: (-?[\d.]+) (-?[\d.]+) (-?[\d.]+) (-?[\d.]+)`)
+
+// contentBoundingBoxes returns the bounding box of the marked content on each
+// page, as reported by Ghostscript's bbox device. Blank pages yield nil.
+func (c *Compressor) contentBoundingBoxes(ctx context.Context, inputPath, password string) ([]*types.Rectangle, error) {
+	ghostscriptPath := c.GetGhostscriptPath()
+	if ghostscriptPath == "" {
+		return nil, common.NewError(common.ErrGhostscriptMissing, "ghostscript not found. Please install ghostscript to use this application")
+	}
+
+	args := []string{"-sDEVICE=bbox", "-dNOPAUSE", "-dBATCH", "-dQUIET"}
+	if password != "" {
+		args = append(args, "-sPDFPassword="+password)
+	}
+	args = append(args, inputPath)
+
+	cmd := common.GhostscriptCommand(ctx, ghostscriptPath, args...)
+	output, err := common.RunCommand(cmd, c.ResourceLimits())
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, common.NewError(common.ErrGhostscriptCrash, fmt.Sprintf("bounding box detection failed: %v, output: %s", err, string(output)))
+	}
+
+	var boxes []*types.Rectangle
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		m := hiResBoundingBox.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		llx, _ := strconv.ParseFloat(m[1], 64)
+		lly, _ := strconv.ParseFloat(m[2], 64)
+		urx, _ := strconv.ParseFloat(m[3], 64)
+		ury, _ := strconv.ParseFloat(m[4], 64)
+		if urx <= llx || ury <= lly {
+			boxes = append(boxes, nil)
+			continue
+		}
+		boxes = append(boxes, types.NewRectangle(llx, lly, urx, ury))
+	}
+
+	return boxes, nil
+}
+
+// scanContentBoxes replaces the box of every page whose marked content fills
+// the page, as a scanned page image does, with the box of the dark pixels on
+// a render of it. Pages without dark pixels yield nil.
+func (c *Compressor) scanContentBoxes(ctx context.Context, pdfCtx *model.Context, inputPath, password string, boxes []*types.Rectangle) error {
+	var pages []int
+	for pageNr := 1; pageNr <= pdfCtx.PageCount; pageNr++ {
+		box := boxes[pageNr-1]
+		if box == nil {
+			continue
+		}
+		_, _, inherited, err := pdfCtx.PageDict(pageNr, false)
+		if err != nil {
+			return fmt.Errorf("failed to read page %d: %v", pageNr, err)
+		}
+		if media := inherited.MediaBox; media != nil && box.Width()*box.Height() >= trimScanCoverage*media.Width()*media.Height() {
+			pages = append(pages, pageNr)
+		}
+	}
+	if len(pages) == 0 {
+		return nil
+	}
+
+	tempDir, err := os.MkdirTemp(c.WorkingDir(), "kleinpdf_trim_*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer common.RemoveTempDir(tempDir)
+
+	images, err := c.renderGray(ctx, inputPath, filepath.Join(tempDir, "page"), password, pages, trimScanDPI)
+	if err != nil {
+		return err
+	}
+	for i, pageNr := range pages {
+		boxes[pageNr-1] = darkPixelBox(images[i], trimScanDPI)
+	}
+	return nil
+}
+
+// darkPixelBox returns the bounding box, in points from the bottom left, of
+// the rows and columns of a render that hold content
+func darkPixelBox(img *image.Gray, dpi int) *types.Rectangle {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	rows := make([]int, height)
+	cols := make([]int, width)
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+width]
+		for x, v := range row {
+			if v < trimScanThreshold {
+				rows[y]++
+				cols[x]++
+			}
+		}
+	}
+
+	top, bottom := firstContent(rows), lastContent(rows)
+	left, right := firstContent(cols), lastContent(cols)
+	if top < 0 || left < 0 {
+		return nil
+	}
+
+	// Widen by a pixel to cover content lost to the low resolution
+	scale := 72 / float64(dpi)
+	return types.NewRectangle(
+		float64(left-1)*scale,
+		float64(height-bottom-2)*scale,
+		float64(right+2)*scale,
+		float64(height-top+1)*scale,
+	)
+}
+
+// firstContent returns the index of the first count of at least
+// trimScanSpeck, or -1 when there is none
+func firstContent(counts []int) int {
+	for i, n := range counts {
+		if n >= trimScanSpeck {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastContent returns the index of the last count of at least trimScanSpeck,
+// or -1 when there is none
+func lastContent(counts []int) int {
+	for i := len(counts) - 1; i >= 0; i-- {
+		if counts[i] >= trimScanSpeck {
+			return i
+		}
+	}
+	return -1
+}
+
+// TrimMargins writes a copy of the input whose pages are cropped to their
+// content plus a small padding. Scanned pages, whose image fills the page,
+// are cropped to the dark pixels of a render instead. Blank and rotated pages
+// are left unchanged. The output is unencrypted even when password opens an
+// encrypted input.
+func (c *Compressor) TrimMargins(ctx context.Context, inputPath, outputPath, password string) error {
+	boxes, err := c.contentBoundingBoxes(ctx, inputPath, password)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	pdfCtx, err := api.ReadContext(file, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %v", err)
+	}
+	if pdfCtx.PageCount != len(boxes) {
+		return fmt.Errorf("bounding box detection found %d pages, document has %d", len(boxes), pdfCtx.PageCount)
+	}
+	if err := c.scanContentBoxes(ctx, pdfCtx, inputPath, password, boxes); err != nil {
+		return err
+	}
+
+	for pageNr := 1; pageNr <= pdfCtx.PageCount; pageNr++ {
+		box := boxes[pageNr-1]
+		if box == nil {
+			continue
+		}
+
+		pageDict, _, inherited, err := pdfCtx.PageDict(pageNr, false)
+		if err != nil {
+			return fmt.Errorf("failed to read page %d: %v", pageNr, err)
+		}
+		if inherited.MediaBox == nil || inherited.Rotate%360 != 0 {
+			continue
+		}
+
+		// The bbox device measures from the media box origin
+		media := inherited.MediaBox
+		cropBox := types.NewRectangle(
+			math.Max(media.LL.X, media.LL.X+box.LL.X-trimMarginPadding),
+			math.Max(media.LL.Y, media.LL.Y+box.LL.Y-trimMarginPadding),
+			math.Min(media.UR.X, media.LL.X+box.UR.X+trimMarginPadding),
+			math.Min(media.UR.Y, media.LL.Y+box.UR.Y+trimMarginPadding),
+		)
+		pageDict.Update("CropBox", cropBox.Array())
+	}
+
+	if password != "" {
+		pdfCtx.Cmd = model.DECRYPT
+	}
+
+	if err := api.WriteContextFile(pdfCtx, outputPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to write trimmed PDF: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// IsEncrypted reports whether a PDF has an /Encrypt dictionary in its trailer
+func IsEncrypted(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	marker := []byte("/Encrypt")
+	reader := bufio.NewReaderSize(file, 64*1024)
+	buf := make([]byte, 64*1024)
+	var tail []byte
+
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			// Keep the end of the previous chunk so markers split across reads are found
+			chunk := append(tail, buf[:n]...)
+			if bytes.Contains(chunk, marker) {
+				return true, nil
+			}
+			keep := len(marker) - 1
+			if len(chunk) < keep {
+				keep = len(chunk)
+			}
+			tail = append([]byte(nil), chunk[len(chunk)-keep:]...)
+		}
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// NeedsPassword reports whether a PDF cannot be opened without a password.
+// Many encrypted PDFs only restrict permissions and open with an empty user
+// password, so those are tried with pdfcpu before asking for one. Files
+// pdfcpu cannot read for other reasons are treated as needing one, so the
+// user can still supply it.
+func NeedsPassword(path string) (bool, error) {
+	encrypted, err := IsEncrypted(path)
+	if err != nil || !encrypted {
+		return false, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	conf.UserPW = ""
+	_, err = api.ReadContext(file, conf)
+	return err != nil, nil
+}
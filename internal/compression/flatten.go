@@ -0,0 +1,348 @@
+package compression
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"kleinpdf/internal/common"
+)
+
+// markupAnnotations are the review annotations, such as sticky notes and
+// highlights, that RemoveAnnotations drops. Links and form widgets stay.
+var markupAnnotations = map[string]bool{
+	"Text":           true,
+	"FreeText":       true,
+	"Line":           true,
+	"Square":         true,
+	"Circle":         true,
+	"Polygon":        true,
+	"PolyLine":       true,
+	"Highlight":      true,
+	"Underline":      true,
+	"Squiggly":       true,
+	"StrikeOut":      true,
+	"Caret":          true,
+	"Ink":            true,
+	"Stamp":          true,
+	"Popup":          true,
+	"FileAttachment": true,
+	"Sound":          true,
+	"Redact":         true,
+}
+
+// Annotation flags that keep a widget off the page
+const (
+	annotFlagHidden = 1 << 1
+	annotFlagNoView = 1 << 5
+)
+
+// wantsFlatten reports whether form flattening or annotation removal is requested
+func wantsFlatten(options *CompressionOptions) bool {
+	return options.FlattenForms || options.RemoveAnnotations
+}
+
+// flattenedWidget is a widget appearance to draw into the page content
+type flattenedWidget struct {
+	appearance types.IndirectRef
+	matrix     [6]float64
+}
+
+// NeedsAppearances reports whether a PDF's form asks the viewer to draw its
+// field appearances, so the stored appearances may not show the field
+// values. Password opens encrypted files.
+func NeedsAppearances(path, password string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	ctx, err := api.ReadContext(file, conf)
+	if err != nil {
+		return false, fmt.Errorf("failed to read PDF: %v", err)
+	}
+	return needAppearances(ctx)
+}
+
+// needAppearances reports whether the AcroForm NeedAppearances flag is set
+func needAppearances(ctx *model.Context) (bool, error) {
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return false, fmt.Errorf("failed to read catalog: %v", err)
+	}
+	form, err := ctx.DereferenceDict(catalog["AcroForm"])
+	if err != nil || form == nil {
+		return false, err
+	}
+	need := form.BooleanEntry("NeedAppearances")
+	return need != nil && *need, nil
+}
+
+// FlattenAnnotations writes a copy of the input whose form fields are drawn
+// into the page content as static appearances, or whose markup annotations
+// are removed, as selected in options. Fields without an appearance stream
+// disappear when flattened. Forms that ask the viewer to draw their
+// appearances are refused, since flattening them could drop field values.
+// The output is unencrypted even when options.InputPassword opens an
+// encrypted input.
+func FlattenAnnotations(inputPath, outputPath string, options *CompressionOptions) error {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if options.InputPassword != "" {
+		conf.UserPW = options.InputPassword
+		conf.OwnerPW = options.InputPassword
+	}
+
+	// Validation loads every object, so none are lost when writing
+	ctx, err := api.ReadAndValidate(file, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	if options.FlattenForms {
+		need, err := needAppearances(ctx)
+		if err != nil {
+			return err
+		}
+		if need {
+			return common.NewError(common.ErrInvalidRequest, "form fields have no up-to-date appearances and cannot be flattened")
+		}
+	}
+
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		page, _, inherited, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return fmt.Errorf("failed to read page %d: %v", pageNr, err)
+		}
+		if err := flattenPage(ctx, page, inherited, options); err != nil {
+			return fmt.Errorf("failed to flatten page %d: %v", pageNr, err)
+		}
+	}
+
+	if options.FlattenForms {
+		catalog, err := ctx.Catalog()
+		if err != nil {
+			return fmt.Errorf("failed to read catalog: %v", err)
+		}
+		catalog.Delete("AcroForm")
+	}
+
+	if options.InputPassword != "" {
+		ctx.Cmd = model.DECRYPT
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to write flattened PDF: %v", err)
+	}
+
+	return nil
+}
+
+// flattenPage removes the annotations selected in options from a page and
+// draws the appearances of its form widgets over the existing content
+func flattenPage(ctx *model.Context, page types.Dict, inherited *model.InheritedPageAttrs, options *CompressionOptions) error {
+	annots, err := ctx.DereferenceArray(page["Annots"])
+	if err != nil || annots == nil {
+		return err
+	}
+
+	kept := types.Array{}
+	var widgets []flattenedWidget
+	for _, obj := range annots {
+		annot, err := ctx.DereferenceDict(obj)
+		if err != nil || annot == nil {
+			kept = append(kept, obj)
+			continue
+		}
+
+		subtype := ""
+		if st := annot.Subtype(); st != nil {
+			subtype = *st
+		}
+		switch {
+		case options.RemoveAnnotations && markupAnnotations[subtype]:
+			continue
+		case options.FlattenForms && subtype == "Widget":
+			if widget, ok := widgetAppearance(ctx, annot); ok {
+				widgets = append(widgets, widget)
+			}
+			continue
+		}
+		kept = append(kept, obj)
+	}
+
+	if len(kept) == 0 {
+		page.Delete("Annots")
+	} else {
+		page.Update("Annots", kept)
+	}
+
+	if len(widgets) == 0 {
+		return nil
+	}
+	return drawWidgets(ctx, page, inherited, widgets)
+}
+
+// widgetAppearance returns the normal appearance of a visible widget and the
+// matrix that maps it onto the widget rectangle, following the placement
+// rules for annotation appearances
+func widgetAppearance(ctx *model.Context, annot types.Dict) (flattenedWidget, bool) {
+	if flags := annot.IntEntry("F"); flags != nil && *flags&(annotFlagHidden|annotFlagNoView) != 0 {
+		return flattenedWidget{}, false
+	}
+
+	ap, err := ctx.DereferenceDict(annot["AP"])
+	if err != nil || ap == nil {
+		return flattenedWidget{}, false
+	}
+
+	// Checkboxes and radio buttons keep one appearance per state
+	normal := ap["N"]
+	if states, err := ctx.DereferenceDict(normal); err == nil && states != nil {
+		state := annot.NameEntry("AS")
+		if state == nil {
+			return flattenedWidget{}, false
+		}
+		normal = states[*state]
+	}
+	ref, ok := normal.(types.IndirectRef)
+	if !ok {
+		return flattenedWidget{}, false
+	}
+	stream, _, err := ctx.DereferenceStreamDict(ref)
+	if err != nil || stream == nil {
+		return flattenedWidget{}, false
+	}
+
+	rectArray, err := ctx.DereferenceArray(annot["Rect"])
+	if err != nil || len(rectArray) != 4 {
+		return flattenedWidget{}, false
+	}
+	rect, err := ctx.RectForArray(rectArray)
+	if err != nil {
+		return flattenedWidget{}, false
+	}
+	bboxArray, err := ctx.DereferenceArray(stream.Dict["BBox"])
+	if err != nil || len(bboxArray) != 4 {
+		return flattenedWidget{}, false
+	}
+	bbox, err := ctx.RectForArray(bboxArray)
+	if err != nil {
+		return flattenedWidget{}, false
+	}
+
+	form := [6]float64{1, 0, 0, 1, 0, 0}
+	if matrix, err := ctx.DereferenceArray(stream.Dict["Matrix"]); err == nil && len(matrix) == 6 {
+		for i, obj := range matrix {
+			if form[i], err = ctx.DereferenceNumber(obj); err != nil {
+				return flattenedWidget{}, false
+			}
+		}
+	}
+
+	// Fit the bounding box, as transformed by the form matrix, into Rect
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{
+		{bbox.LL.X, bbox.LL.Y}, {bbox.UR.X, bbox.LL.Y},
+		{bbox.LL.X, bbox.UR.Y}, {bbox.UR.X, bbox.UR.Y},
+	} {
+		x := form[0]*corner[0] + form[2]*corner[1] + form[4]
+		y := form[1]*corner[0] + form[3]*corner[1] + form[5]
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	if maxX-minX <= 0 || maxY-minY <= 0 {
+		return flattenedWidget{}, false
+	}
+	sx := rect.Width() / (maxX - minX)
+	sy := rect.Height() / (maxY - minY)
+
+	// Appearance streams are Form XObjects, but some writers omit the Subtype
+	stream.Dict["Subtype"] = types.Name("Form")
+
+	return flattenedWidget{
+		appearance: ref,
+		matrix:     [6]float64{sx, 0, 0, sy, rect.LL.X - minX*sx, rect.LL.Y - minY*sy},
+	}, true
+}
+
+// drawWidgets registers the widget appearances as page XObjects and appends
+// a content stream that draws them, isolating the original graphics state
+func drawWidgets(ctx *model.Context, page types.Dict, inherited *model.InheritedPageAttrs, widgets []flattenedWidget) error {
+	resources, err := ctx.DereferenceDict(page["Resources"])
+	if err != nil {
+		return err
+	}
+	if resources == nil {
+		resources = types.NewDict()
+		if inherited != nil && inherited.Resources != nil {
+			resources = inherited.Resources.Clone().(types.Dict)
+		}
+		page["Resources"] = resources
+	}
+	xobjects, err := ctx.DereferenceDict(resources["XObject"])
+	if err != nil {
+		return err
+	}
+	if xobjects == nil {
+		xobjects = types.NewDict()
+		resources["XObject"] = xobjects
+	}
+
+	var draw strings.Builder
+	draw.WriteString("Q\n")
+	next := 0
+	for _, widget := range widgets {
+		name := ""
+		for name == "" || xobjects[name] != nil {
+			next++
+			name = fmt.Sprintf("KleinPDFField%d", next)
+		}
+		xobjects[name] = widget.appearance
+
+		m := widget.matrix
+		fmt.Fprintf(&draw, "q %.4f %.4f %.4f %.4f %.4f %.4f cm /%s Do Q\n", m[0], m[1], m[2], m[3], m[4], m[5], name)
+	}
+
+	save, err := ctx.StreamDictIndRef([]byte("q\n"))
+	if err != nil {
+		return err
+	}
+	overlay, err := ctx.StreamDictIndRef([]byte(draw.String()))
+	if err != nil {
+		return err
+	}
+
+	contents := types.Array{*save}
+	switch obj := page["Contents"].(type) {
+	case types.IndirectRef:
+		if existing, err := ctx.DereferenceArray(obj); err == nil && existing != nil {
+			contents = append(contents, existing...)
+		} else {
+			contents = append(contents, obj)
+		}
+	case types.Array:
+		contents = append(contents, obj...)
+	}
+	page["Contents"] = append(contents, *overlay)
+
+	return nil
+}
@@ -0,0 +1,355 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"kleinpdf/internal/common"
+)
+
+// ghostscriptBackend compresses with Ghostscript's pdfwrite device
+type ghostscriptBackend struct {
+	c *Compressor
+}
+
+func (b *ghostscriptBackend) Name() string {
+	return BackendGhostscript
+}
+
+func (b *ghostscriptBackend) Available() bool {
+	return b.c.IsAvailable()
+}
+
+func (b *ghostscriptBackend) Capabilities() Capabilities {
+	return Capabilities{
+		ImageDownsampling:      true,
+		Grayscale:              true,
+		MetadataRemoval:        true,
+		PageResizing:           true,
+		TransparencyFlattening: true,
+		Encryption:             true,
+		Decryption:             true,
+		OCR:                    b.c.IsOCRAvailable(),
+		Progress:               true,
+	}
+}
+
+// Compress runs Ghostscript, killing the process when ctx is cancelled
+func (b *ghostscriptBackend) Compress(ctx context.Context, inputPath, outputPath, compressionLevel string, options *CompressionOptions, onProgress ProgressFunc) error {
+	c := b.c
+
+	// Validate and set defaults for required fields if they are empty
+	if options.PDFVersion == "" {
+		options.PDFVersion = "1.4"
+	}
+	if options.ImageDPI <= 0 {
+		options.ImageDPI = 150
+	}
+	if options.ImageQuality <= 0 {
+		options.ImageQuality = 85
+	}
+
+	// Handle grayscale conversion if needed
+	actualInputPath := inputPath
+	if options.ConvertToGrayscale {
+		tempGrayscalePath, err := c.tempFilePath(inputPath, "grayscale")
+		if err != nil {
+			return err
+		}
+
+		err = c.ConvertToGrayscale(ctx, inputPath, tempGrayscalePath, options.InputPassword)
+		if err != nil {
+			return fmt.Errorf("grayscale conversion failed: %v", err)
+		}
+
+		actualInputPath = tempGrayscalePath
+		defer common.RemoveTemp(tempGrayscalePath) // Clean up temp file
+	}
+
+	// Add an OCR text layer if requested
+	if options.AddTextLayer {
+		if actualInputPath == inputPath && options.InputPassword != "" {
+			return fmt.Errorf("OCR is not supported for password-protected input")
+		}
+
+		tempOCRPath, err := c.tempFilePath(inputPath, "ocr")
+		if err != nil {
+			return err
+		}
+
+		err = c.AddTextLayer(ctx, actualInputPath, tempOCRPath)
+		if err != nil {
+			return fmt.Errorf("OCR failed: %v", err)
+		}
+
+		actualInputPath = tempOCRPath
+		defer common.RemoveTemp(tempOCRPath) // Clean up temp file
+	}
+
+	// Resolve the downsampling filter
+	downsampleType, err := ghostscriptDownsampleType(options.DownsampleType)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the mono image codec
+	monoImageFilter, err := ghostscriptMonoImageFilter(options.MonoImageCodec)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the color conversion strategy
+	colorStrategy, processColorModel, err := ghostscriptColorConversion(options.ColorConversion)
+	if err != nil {
+		return err
+	}
+
+	// Build Ghostscript command based on compression level
+	var pdfSettings string
+	switch compressionLevel {
+	case "ultra":
+		pdfSettings = "/screen"
+	case "aggressive":
+		pdfSettings = "/ebook"
+	default: // good_enough
+		pdfSettings = "/printer"
+	}
+
+	args := []string{
+		"-sDEVICE=pdfwrite",
+		"-dPDFSETTINGS=" + pdfSettings,
+		"-dCompatibilityLevel=" + options.PDFVersion,
+		"-dNOPAUSE",
+		"-dBATCH",
+		"-dAutoRotatePages=/None",
+		"-dColorImageDownsampleType=" + downsampleType,
+		fmt.Sprintf("-dColorImageResolution=%d", options.ImageDPI),
+		"-dGrayImageDownsampleType=" + downsampleType,
+		fmt.Sprintf("-dGrayImageResolution=%d", options.ImageDPI),
+		"-dMonoImageDownsampleType=" + downsampleType,
+		fmt.Sprintf("-dMonoImageResolution=%d", options.ImageDPI),
+		"-dColorConversionStrategy=" + colorStrategy,
+		fmt.Sprintf("-dEmbedAllFonts=%t", options.EmbedFonts),
+		"-dSubsetFonts=true",
+		"-dOptimize=true",
+		"-dDownsampleColorImages=true",
+		"-dDownsampleGrayImages=true",
+		"-dDownsampleMonoImages=true",
+	}
+
+	if processColorModel != "" {
+		args = append(args, "-sProcessColorModel="+processColorModel)
+	}
+
+	if options.DownsampleThreshold > 0 {
+		threshold := fmt.Sprintf("%g", options.DownsampleThreshold)
+		args = append(args,
+			"-dColorImageDownsampleThreshold="+threshold,
+			"-dGrayImageDownsampleThreshold="+threshold,
+			"-dMonoImageDownsampleThreshold="+threshold,
+		)
+	}
+
+	if monoImageFilter != "" {
+		args = append(args, "-dEncodeMonoImages=true", "-dMonoImageFilter="+monoImageFilter)
+	}
+
+	// Fit pages onto the requested media
+	if options.ResizeTarget != "" {
+		resizeArgs, err := ResizeArgs(actualInputPath, options.ResizeTarget, options.InputPassword)
+		if err != nil {
+			return err
+		}
+		args = append(args, resizeArgs...)
+	}
+
+	// Composite transparent objects into opaque ones for old printers and viewers
+	if options.FlattenTransparency {
+		args = append(args, "-dHaveTransparency=false")
+	}
+
+	// Store repeated images such as logos and letterheads only once. Always
+	// passed, since Ghostscript detects duplicates by default.
+	args = append(args, fmt.Sprintf("-dDetectDuplicateImages=%t", options.DeduplicateImages))
+
+	// Add ultra-specific options
+	if compressionLevel == "ultra" {
+		args = append(args, "-dCompressFonts=true", "-dCompressStreams=true")
+	}
+
+	// Drop XMP and info dates; the remaining info entries are blanked below
+	if options.RemoveMetadata {
+		args = append(args, "-dOmitXMP=true", "-dOmitInfoDate=true")
+	}
+
+	// Add thumbnail generation if enabled
+	if options.GenerateThumbnails {
+		args = append(args, "-dGenerateThumbnails=true")
+	}
+
+	// Encrypt output if a password is set. Ghostscript requires an owner
+	// password, so fall back to the user password when only that is given.
+	if options.OwnerPassword != "" || options.UserPassword != "" {
+		ownerPassword := options.OwnerPassword
+		if ownerPassword == "" {
+			ownerPassword = options.UserPassword
+		}
+		args = append(args,
+			"-sOwnerPassword="+ownerPassword,
+			"-dEncryptionR=3",
+			"-dKeyLength=128",
+			"-dPermissions=-3904",
+		)
+		if options.UserPassword != "" {
+			args = append(args, "-sUserPassword="+options.UserPassword)
+		}
+	}
+
+	// Decrypt the input unless an earlier pass already wrote a decrypted copy
+	if options.InputPassword != "" && actualInputPath == inputPath {
+		args = append(args, "-sPDFPassword="+options.InputPassword)
+	}
+
+	args = append(args, "-sOutputFile="+outputPath, actualInputPath)
+	if options.RemoveMetadata {
+		args = append(args, "-c", clearInfoPdfmark(), "-f")
+	}
+
+	// Small files skip the interpreter start-up cost on a persistent worker
+	ran := false
+	if job, ok := newGhostscriptJob(args); ok && isPoolable(actualInputPath) {
+		err := c.pool.run(ctx, c.GetGhostscriptPath(), c.ResourceLimits(), c.WorkingDir(), job, onProgress)
+		switch {
+		case err == nil:
+			ran = true
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case !errors.Is(err, errWorkerFailed):
+			return err
+		default:
+			// The worker broke down before finishing the file, so it starts over
+			c.logger.Warn("Ghostscript worker failed, compressing the file again in a new process", "file", inputPath, "error", err)
+			if onProgress != nil {
+				onProgress(0, 0, 0)
+			}
+		}
+	}
+	if !ran {
+		if err := c.spawnGhostscript(ctx, args, outputPath, onProgress); err != nil {
+			return err
+		}
+	}
+	// Check if output file was created
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		return fmt.Errorf("ghostscript did not create output file")
+	}
+
+	if options.RemoveMetadata {
+		password := options.OwnerPassword
+		if password == "" {
+			password = options.UserPassword
+		}
+		if err := stripMetadata(outputPath, password); err != nil {
+			os.Remove(outputPath)
+			return fmt.Errorf("metadata removal failed: %v", err)
+		}
+		if err := VerifyMetadataRemoved(outputPath, password); err != nil {
+			os.Remove(outputPath)
+			return fmt.Errorf("metadata removal failed: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// spawnGhostscript runs Ghostscript in its own process, streaming stdout for page progress
+func (c *Compressor) spawnGhostscript(ctx context.Context, args []string, outputPath string, onProgress ProgressFunc) error {
+	cmd := common.GhostscriptCommand(ctx, c.GetGhostscriptPath(), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open ghostscript output: %v", err)
+	}
+	if err := common.StartCommand(cmd, c.ResourceLimits()); err != nil {
+		return fmt.Errorf("failed to start ghostscript: %v", err)
+	}
+
+	output, totalPages := scanProgress(stdout, onProgress)
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			os.Remove(outputPath) // Discard partial output
+			return ctx.Err()
+		}
+		details := output + stderr.String()
+		code := common.ErrGhostscriptCrash
+		if strings.Contains(strings.ToLower(details), "password") {
+			code = common.ErrEncryptedInput
+		}
+		return common.NewError(code, fmt.Sprintf("ghostscript failed: %v, output: %s", err, details))
+	}
+
+	if onProgress != nil {
+		onProgress(totalPages, totalPages, 100)
+	}
+	return nil
+}
+
+// isPoolable reports whether a file is small enough for a persistent worker
+func isPoolable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Size() < pooledFileMaxSize
+}
+
+// ghostscriptDownsampleType maps a downsample option to its Ghostscript name
+func ghostscriptDownsampleType(downsampleType string) (string, error) {
+	switch downsampleType {
+	case "", "bicubic":
+		return "/Bicubic", nil
+	case "average":
+		return "/Average", nil
+	case "subsample":
+		return "/Subsample", nil
+	default:
+		return "", common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown downsample type %q", downsampleType))
+	}
+}
+
+// ghostscriptColorConversion maps a color conversion option to its Ghostscript
+// strategy and, for device conversions, the matching process color model
+func ghostscriptColorConversion(conversion string) (string, string, error) {
+	switch conversion {
+	case "", "srgb":
+		return "/sRGB", "", nil
+	case "cmyk":
+		return "/CMYK", "DeviceCMYK", nil
+	case "gray":
+		return "/Gray", "DeviceGray", nil
+	case "unchanged":
+		return "/LeaveColorUnchanged", "", nil
+	default:
+		return "", "", common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown color conversion %q", conversion))
+	}
+}
+
+// ghostscriptMonoImageFilter maps a mono codec option to its Ghostscript filter.
+// An empty result keeps the Ghostscript default for the PDFSETTINGS preset.
+func ghostscriptMonoImageFilter(codec string) (string, error) {
+	switch codec {
+	case "":
+		return "", nil
+	case "ccitt":
+		return "/CCITTFaxEncode", nil
+	case "flate":
+		return "/FlateEncode", nil
+	case "jbig2":
+		// Open-source Ghostscript builds ship a JBIG2 decoder but no encoder
+		return "", common.NewError(common.ErrInvalidRequest, "JBIG2 encoding is not supported by the bundled Ghostscript")
+	default:
+		return "", common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown mono image codec %q", codec))
+	}
+}
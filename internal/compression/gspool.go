@@ -0,0 +1,382 @@
+package compression
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	// pooledFileMaxSize is the largest input sent to a persistent worker; above
+	// it the interpreter start-up cost is negligible next to the compression
+	pooledFileMaxSize = 20 * 1024 * 1024
+
+	// maxJobsPerWorker retires a worker before interpreter memory can build up
+	maxJobsPerWorker = 100
+
+	// jobMarker prefixes the line a worker prints when a job finishes
+	jobMarker = "%%KLEINPDF_JOB_"
+)
+
+// ghostscriptInterpreterArgs are handled by the worker command line, not per job
+var ghostscriptInterpreterArgs = map[string]bool{
+	"-sDEVICE=pdfwrite": true,
+	"-dNOPAUSE":         true,
+	"-dBATCH":           true,
+	"-dQUIET":           true,
+	"-q":                true,
+}
+
+// ghostscriptInterpreterParams configure the PDF interpreter rather than the
+// pdfwrite device, so they cannot be set per job on a running worker
+var ghostscriptInterpreterParams = map[string]bool{
+	"PDFPassword":        true,
+	"PAPERSIZE":          true,
+	"DEVICEWIDTHPOINTS":  true,
+	"DEVICEHEIGHTPOINTS": true,
+	"FirstPage":          true,
+	"LastPage":           true,
+	"PageList":           true,
+}
+
+// psTokenPattern matches -d values that are safe to splice into PostScript as-is
+var psTokenPattern = regexp.MustCompile(`^/?[A-Za-z0-9.+-]+$`)
+
+// ghostscriptJob is a pdfwrite run expressed as device parameters
+type ghostscriptJob struct {
+	inputPath  string
+	outputPath string
+	preset     string   // PDFSETTINGS name, applied before params
+	params     []string // PostScript key/value pairs
+}
+
+// newGhostscriptJob translates pdfwrite command-line arguments into a job for
+// a persistent worker. It reports false for arguments only the command line
+// understands, such as input passwords, page sizing or trailing PostScript.
+func newGhostscriptJob(args []string) (*ghostscriptJob, bool) {
+	job := &ghostscriptJob{}
+	for _, arg := range args {
+		if ghostscriptInterpreterArgs[arg] {
+			continue
+		}
+		if !strings.HasPrefix(arg, "-") {
+			if job.inputPath != "" {
+				return nil, false
+			}
+			job.inputPath = arg
+			continue
+		}
+
+		key, value, ok := strings.Cut(arg[2:], "=")
+		if !ok || ghostscriptInterpreterParams[key] {
+			return nil, false
+		}
+
+		switch {
+		case arg[:2] == "-s" && key == "OutputFile":
+			job.outputPath = value
+		case arg[:2] == "-s":
+			job.params = append(job.params, "/"+key, psString(value))
+		case arg[:2] == "-d" && key == "PDFSETTINGS":
+			job.preset = value
+		case arg[:2] == "-d" && psTokenPattern.MatchString(value):
+			job.params = append(job.params, "/"+key, value)
+		default:
+			return nil, false
+		}
+	}
+
+	if job.inputPath == "" || job.outputPath == "" {
+		return nil, false
+	}
+	return job, true
+}
+
+// script returns the PostScript that runs the job and prints its end marker.
+// Each job gets a fresh pdfwrite device so settings never leak between files;
+// changing OutputFile closes the device, which writes the PDF trailer.
+func (j *ghostscriptJob) script(marker string) string {
+	var b strings.Builder
+	b.WriteString("/kleinpdfjob save def\n{\n")
+	b.WriteString("mark /OutputFile " + psString(j.outputPath))
+	if j.preset != "" {
+		b.WriteString(" /PDFSETTINGS " + j.preset)
+	}
+	b.WriteString(" (pdfwrite) finddevice copydevice putdeviceprops\n")
+	b.WriteString("mark " + strings.Join(j.params, " ") + " counttomark 2 add -1 roll putdeviceprops setdevice\n")
+	b.WriteString(psString(j.inputPath) + " (r) file runpdf\n")
+	b.WriteString("} stopped\n")
+	b.WriteString("{ /kleinpdferror $error /errorname get 256 string cvs def } { /kleinpdferror () def } ifelse\n")
+	b.WriteString("clear cleardictstack\n")
+	b.WriteString("mark /OutputFile " + psString(os.DevNull) + " currentdevice putdeviceprops pop nulldevice\n")
+	b.WriteString("(" + marker + " ) print kleinpdferror = flush\n")
+	b.WriteString("kleinpdfjob restore\n")
+	return b.String()
+}
+
+// psString quotes s as a PostScript string literal
+func psString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// errWorkerFailed marks failures of the worker process itself, as opposed to
+// Ghostscript rejecting the file, so the job can be retried in a new process
+var errWorkerFailed = errors.New("ghostscript worker failed")
+
+// ghostscriptWorker is a Ghostscript interpreter reading jobs from stdin. It
+// runs with SAFER and may only read and write files in its own staging
+// directory, so a crafted PDF cannot reach the rest of the disk.
+type ghostscriptWorker struct {
+	path       string
+	limits     common.ResourceLimits
+	workingDir string
+	stagingDir string
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	lines      *bufio.Scanner
+	jobs       int
+}
+
+// startGhostscriptWorker launches an interpreter that waits for jobs on stdin,
+// with a staging directory under workingDir. The interpreter is not quiet, so
+// it prints the same page lines a spawned process does.
+func startGhostscriptWorker(path string, limits common.ResourceLimits, workingDir string) (*ghostscriptWorker, error) {
+	stagingDir, err := os.MkdirTemp(workingDir, "kleinpdf-gsworker-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worker directory: %v", err)
+	}
+	// Ghostscript matches the permitted paths literally, so resolve symlinks
+	// such as /var -> /private/var on macOS
+	if resolved, err := filepath.EvalSymlinks(stagingDir); err == nil {
+		stagingDir = resolved
+	}
+	staged := filepath.Join(stagingDir, "*")
+
+	cmd := common.GhostscriptCommand(context.Background(), path,
+		"-dNOPAUSE", "-dSAFER",
+		"--permit-file-read="+staged,
+		"--permit-file-write="+staged,
+		"--permit-file-write="+os.DevNull,
+		"-sDEVICE=pdfwrite", "-sOutputFile="+os.DevNull,
+		"-")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return nil, err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := common.StartCommand(cmd, limits); err != nil {
+		os.RemoveAll(stagingDir)
+		return nil, err
+	}
+
+	lines := bufio.NewScanner(stdout)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &ghostscriptWorker{
+		path:       path,
+		limits:     limits,
+		workingDir: workingDir,
+		stagingDir: stagingDir,
+		cmd:        cmd,
+		stdin:      stdin,
+		lines:      lines,
+	}, nil
+}
+
+// run copies the input into the staging directory, executes the job there and
+// moves the output into place. The worker is killed when ctx is cancelled.
+func (w *ghostscriptWorker) run(ctx context.Context, job *ghostscriptJob, marker string, onProgress ProgressFunc) error {
+	w.jobs++
+
+	// The temp file cleaner may have removed an idle worker's directory
+	if err := os.MkdirAll(w.stagingDir, 0700); err != nil {
+		return fmt.Errorf("%w: %v", errWorkerFailed, err)
+	}
+	staged := *job
+	staged.inputPath = filepath.Join(w.stagingDir, "input.pdf")
+	staged.outputPath = filepath.Join(w.stagingDir, "output.pdf")
+	defer os.Remove(staged.inputPath)
+	defer os.Remove(staged.outputPath)
+
+	// A copy rather than a link, so the worker cannot write to the original
+	if err := common.CopyFile(job.inputPath, staged.inputPath); err != nil {
+		return fmt.Errorf("%w: failed to stage input: %v", errWorkerFailed, err)
+	}
+
+	if _, err := io.WriteString(w.stdin, staged.script(marker)); err != nil {
+		return fmt.Errorf("%w: failed to send job: %v", errWorkerFailed, err)
+	}
+
+	type result struct {
+		errorName string
+		output    string
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		progress := newProgressScanner(onProgress)
+		for w.lines.Scan() {
+			line := w.lines.Text()
+			if name, ok := strings.CutPrefix(line, marker+" "); ok {
+				done <- result{errorName: name, output: progress.output.String()}
+				return
+			}
+			progress.scan(line)
+		}
+		done <- result{output: progress.output.String(), err: fmt.Errorf("%w: process exited", errWorkerFailed)}
+	}()
+
+	select {
+	case <-ctx.Done():
+		w.close()
+		<-done
+		return ctx.Err()
+	case r := <-done:
+		if r.err != nil {
+			return fmt.Errorf("%w, output: %s", r.err, r.output)
+		}
+		if r.errorName != "" {
+			code := common.ErrGhostscriptCrash
+			if strings.Contains(strings.ToLower(r.output), "password") {
+				code = common.ErrEncryptedInput
+			}
+			return common.NewError(code, fmt.Sprintf("ghostscript failed: %s, output: %s", r.errorName, r.output))
+		}
+	}
+
+	if err := moveFile(staged.outputPath, job.outputPath); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	return nil
+}
+
+// close stops the interpreter and removes its staging directory
+func (w *ghostscriptWorker) close() {
+	w.stdin.Close()
+	w.cmd.Process.Kill()
+	w.cmd.Wait()
+	os.RemoveAll(w.stagingDir)
+}
+
+// moveFile renames src to dst, copying when they are on different volumes
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := common.CopyFile(src, dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// ghostscriptPool keeps idle Ghostscript interpreters around so batches of
+// small files do not pay the process start-up cost for every file
+type ghostscriptPool struct {
+	mu      sync.Mutex
+	idle    []*ghostscriptWorker
+	maxIdle int
+	closed  bool
+	nextJob atomic.Uint64
+}
+
+// newGhostscriptPool creates a pool that keeps at most maxIdle workers
+func newGhostscriptPool(maxIdle int) *ghostscriptPool {
+	return &ghostscriptPool{maxIdle: maxIdle}
+}
+
+// run executes job on an idle worker, starting one if none is free. Workers
+// for another binary, other limits or another working directory are discarded
+// rather than reused. Errors wrapping errWorkerFailed leave the file untried.
+func (p *ghostscriptPool) run(ctx context.Context, path string, limits common.ResourceLimits, workingDir string, job *ghostscriptJob, onProgress ProgressFunc) error {
+	worker, err := p.get(path, limits, workingDir)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errWorkerFailed, err)
+	}
+
+	marker := fmt.Sprintf("%s%d", jobMarker, p.nextJob.Add(1))
+	if err := worker.run(ctx, job, marker, onProgress); err != nil {
+		// A failed job may leave the interpreter in an unknown state
+		worker.close()
+		os.Remove(job.outputPath) // Discard partial output
+		return err
+	}
+
+	p.put(worker)
+	return nil
+}
+
+// get takes an idle worker matching path, limits and workingDir or starts a new one
+func (p *ghostscriptPool) get(path string, limits common.ResourceLimits, workingDir string) (*ghostscriptWorker, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("ghostscript pool is closed")
+	}
+	for len(p.idle) > 0 {
+		worker := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if worker.path == path && worker.limits == limits && worker.workingDir == workingDir {
+			p.mu.Unlock()
+			return worker, nil
+		}
+		worker.close()
+	}
+	p.mu.Unlock()
+
+	return startGhostscriptWorker(path, limits, workingDir)
+}
+
+// put returns a worker to the pool, or stops it when the pool is full
+func (p *ghostscriptPool) put(worker *ghostscriptWorker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || len(p.idle) >= p.maxIdle || worker.jobs >= maxJobsPerWorker {
+		worker.close()
+		return
+	}
+	p.idle = append(p.idle, worker)
+}
+
+// close stops all idle workers; workers still running a job stop when it ends
+func (p *ghostscriptPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, worker := range p.idle {
+		worker.close()
+	}
+	p.idle = nil
+}
@@ -0,0 +1,141 @@
+package compression
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// File kinds an input can be sniffed as
+const (
+	FileKindPDF     = "pdf"
+	FileKindImage   = "image"
+	FileKindOffice  = "office"
+	FileKindHTML    = "html"
+	FileKindUnknown = ""
+)
+
+// FileType is the sniffed type of an input file
+type FileType struct {
+	Kind   string `json:"kind"`
+	Format string `json:"format"` // pdf, jpeg, png, tiff, webp, gif, bmp, heic, docx, xlsx, pptx, odt, ods, odp, doc, rtf, html
+}
+
+// imageSignatures maps leading magic bytes to image formats
+var imageSignatures = []struct {
+	magic  []byte
+	format string
+}{
+	{[]byte{0xFF, 0xD8, 0xFF}, "jpeg"},
+	{[]byte("\x89PNG\r\n\x1a\n"), "png"},
+	{[]byte("II*\x00"), "tiff"},
+	{[]byte("MM\x00*"), "tiff"},
+	{[]byte("GIF87a"), "gif"},
+	{[]byte("GIF89a"), "gif"},
+	{[]byte("BM"), "bmp"},
+}
+
+// openDocumentFormats maps OpenDocument mimetype entries to formats
+var openDocumentFormats = map[string]string{
+	"application/vnd.oasis.opendocument.text":         "odt",
+	"application/vnd.oasis.opendocument.spreadsheet":  "ods",
+	"application/vnd.oasis.opendocument.presentation": "odp",
+}
+
+// SniffFileType identifies a file from its magic bytes rather than its name,
+// so misnamed files are caught before they reach Ghostscript. Like most PDF
+// readers, leading garbage before the PDF header is tolerated.
+func SniffFileType(path string) (FileType, error) {
+	head, err := readHead(path)
+	if err != nil {
+		return FileType{}, err
+	}
+
+	switch {
+	case bytes.Contains(head, []byte("%PDF-")):
+		return FileType{Kind: FileKindPDF, Format: "pdf"}, nil
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return FileType{Kind: FileKindImage, Format: "webp"}, nil
+	case len(head) >= 12 && string(head[4:8]) == "ftyp" && (string(head[8:12]) == "heic" || string(head[8:12]) == "heix" || string(head[8:12]) == "mif1"):
+		return FileType{Kind: FileKindImage, Format: "heic"}, nil
+	case bytes.HasPrefix(head, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}):
+		// Compound file: legacy Word, Excel or PowerPoint
+		return FileType{Kind: FileKindOffice, Format: "doc"}, nil
+	case bytes.HasPrefix(head, []byte(`{\rtf`)):
+		return FileType{Kind: FileKindOffice, Format: "rtf"}, nil
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return sniffZip(path)
+	case isHTML(head):
+		return FileType{Kind: FileKindHTML, Format: "html"}, nil
+	}
+
+	for _, sig := range imageSignatures {
+		if bytes.HasPrefix(head, sig.magic) {
+			return FileType{Kind: FileKindImage, Format: sig.format}, nil
+		}
+	}
+	return FileType{Kind: FileKindUnknown}, nil
+}
+
+// sniffZip tells Office Open XML and OpenDocument files from other zip archives
+func sniffZip(path string) (FileType, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return FileType{Kind: FileKindUnknown}, nil
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		switch {
+		case strings.HasPrefix(file.Name, "word/"):
+			return FileType{Kind: FileKindOffice, Format: "docx"}, nil
+		case strings.HasPrefix(file.Name, "xl/"):
+			return FileType{Kind: FileKindOffice, Format: "xlsx"}, nil
+		case strings.HasPrefix(file.Name, "ppt/"):
+			return FileType{Kind: FileKindOffice, Format: "pptx"}, nil
+		case file.Name == "mimetype":
+			content, err := file.Open()
+			if err != nil {
+				continue
+			}
+			mimetype, _ := io.ReadAll(io.LimitReader(content, 128))
+			content.Close()
+			if format, ok := openDocumentFormats[strings.TrimSpace(string(mimetype))]; ok {
+				return FileType{Kind: FileKindOffice, Format: format}, nil
+			}
+		}
+	}
+	return FileType{Kind: FileKindUnknown}, nil
+}
+
+// isHTML reports whether a file starts like an HTML document, after any
+// byte order mark, whitespace and comments
+func isHTML(head []byte) bool {
+	text := bytes.ToLower(bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n"))
+	for bytes.HasPrefix(text, []byte("<!--")) {
+		end := bytes.Index(text, []byte("-->"))
+		if end < 0 {
+			return false
+		}
+		text = bytes.TrimLeft(text[end+3:], " \t\r\n")
+	}
+	return bytes.HasPrefix(text, []byte("<!doctype html")) || bytes.HasPrefix(text, []byte("<html"))
+}
+
+// readHead returns up to the first kilobyte of a file
+func readHead(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 1024)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
@@ -0,0 +1,111 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"kleinpdf/internal/common"
+)
+
+// HealthStatus reports the Ghostscript version and whether a test compression succeeded
+type HealthStatus struct {
+	Version string `json:"version"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GhostscriptVersion returns the output of `gs --version` for the active binary
+func (c *Compressor) GhostscriptVersion(ctx context.Context) (string, error) {
+	ghostscriptPath := c.GetGhostscriptPath()
+	if ghostscriptPath == "" {
+		return "", common.NewError(common.ErrGhostscriptMissing, "ghostscript not found")
+	}
+
+	return ValidateGhostscript(ctx, ghostscriptPath)
+}
+
+// ValidateGhostscript checks that path is an executable Ghostscript and returns its version
+func ValidateGhostscript(ctx context.Context, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", common.WrapError(common.ErrGhostscriptMissing, fmt.Sprintf("ghostscript not found at %s", path), err)
+	}
+	if info.IsDir() {
+		return "", common.NewError(common.ErrGhostscriptMissing, fmt.Sprintf("%s is a directory, not a ghostscript executable", path))
+	}
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		return "", common.NewError(common.ErrGhostscriptMissing, fmt.Sprintf("%s is not executable", path))
+	}
+
+	output, err := common.GhostscriptCommand(ctx, path, "--version").Output()
+	if err != nil {
+		return "", common.WrapError(common.ErrGhostscriptMissing, fmt.Sprintf("failed to run %s --version", path), err)
+	}
+
+	version := strings.TrimSpace(string(output))
+	if version == "" {
+		return "", common.NewError(common.ErrGhostscriptMissing, fmt.Sprintf("%s did not report a ghostscript version", path))
+	}
+
+	return version, nil
+}
+
+// CheckHealth detects the Ghostscript version and compresses a one-page test
+// document, so a broken binary is reported at startup rather than on first use
+func (c *Compressor) CheckHealth(ctx context.Context) HealthStatus {
+	version, err := c.GhostscriptVersion(ctx)
+	if err != nil {
+		return HealthStatus{Error: err.Error()}
+	}
+
+	tempDir, err := os.MkdirTemp(c.WorkingDir(), "kleinpdf_health_*")
+	if err != nil {
+		return HealthStatus{Version: version, Error: fmt.Sprintf("failed to create temp directory: %v", err)}
+	}
+	defer os.RemoveAll(tempDir)
+
+	inputPath := filepath.Join(tempDir, "test.pdf")
+	if err := os.WriteFile(inputPath, minimalPDF(), 0644); err != nil {
+		return HealthStatus{Version: version, Error: fmt.Sprintf("failed to write test document: %v", err)}
+	}
+
+	options := DefaultCompressionOptions()
+	backend := &ghostscriptBackend{c: c}
+	if err := backend.Compress(ctx, inputPath, filepath.Join(tempDir, "test_compressed.pdf"), "good_enough", &options, nil); err != nil {
+		return HealthStatus{Version: version, Error: fmt.Sprintf("test compression failed: %v", err)}
+	}
+
+	return HealthStatus{Version: version, Healthy: true}
+}
+
+// minimalPDF builds a valid one-page PDF with a correct cross-reference table
+func minimalPDF() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 72 72] /Contents 4 0 R >>",
+		"<< /Length 15 >>\nstream\n0 0 m 72 72 l S\nendstream",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+
+	return buf.Bytes()
+}
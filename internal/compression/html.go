@@ -0,0 +1,167 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"kleinpdf/internal/common"
+)
+
+// htmlConversionTimeout bounds how long a page may take to load and print
+const htmlConversionTimeout = 2 * time.Minute
+
+// HTML renderers in order of preference
+const (
+	HTMLRendererChromium    = "chromium"
+	HTMLRendererWkhtmltopdf = "wkhtmltopdf"
+)
+
+// detectHTMLRenderer looks for a Chromium-based browser, which renders modern
+// pages faithfully, and falls back to wkhtmltopdf
+func detectHTMLRenderer() (name, path string) {
+	for _, browser := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "microsoft-edge"} {
+		if browserPath, err := exec.LookPath(browser); err == nil {
+			return HTMLRendererChromium, browserPath
+		}
+	}
+
+	var candidates []string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = []string{
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+			"/Applications/Brave Browser.app/Contents/MacOS/Brave Browser",
+		}
+	case "windows":
+		for _, dir := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)")} {
+			if dir != "" {
+				candidates = append(candidates,
+					filepath.Join(dir, "Google", "Chrome", "Application", "chrome.exe"),
+					filepath.Join(dir, "Microsoft", "Edge", "Application", "msedge.exe"))
+			}
+		}
+	}
+	for _, browserPath := range candidates {
+		if _, err := os.Stat(browserPath); err == nil {
+			return HTMLRendererChromium, browserPath
+		}
+	}
+
+	if wkhtmltopdfPath, err := exec.LookPath("wkhtmltopdf"); err == nil {
+		return HTMLRendererWkhtmltopdf, wkhtmltopdfPath
+	}
+	return "", ""
+}
+
+// IsHTMLAvailable checks if a browser or wkhtmltopdf can render web pages
+func (c *Compressor) IsHTMLAvailable() bool {
+	return c.htmlPath != ""
+}
+
+// ConvertHTMLToPDF renders a web page, given as an http(s) URL or a local HTML
+// file, to PDF
+func (c *Compressor) ConvertHTMLToPDF(ctx context.Context, source, outputPath string) error {
+	if c.htmlPath == "" {
+		return fmt.Errorf("converting web pages needs Chrome, Chromium, Edge or wkhtmltopdf, and none is installed")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, htmlConversionTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch c.htmlRenderer {
+	case HTMLRendererChromium:
+		profileDir, err := os.MkdirTemp(c.WorkingDir(), "kleinpdf-browser-")
+		if err != nil {
+			return fmt.Errorf("failed to create browser profile: %v", err)
+		}
+		defer os.RemoveAll(profileDir)
+
+		// A file:// page could pull any local file into the PDF, so a local
+		// page is served from a loopback origin limited to its own folder
+		source := source
+		if !isWebURL(source) {
+			pageURL, stop, err := serveLocalPage(source)
+			if err != nil {
+				return err
+			}
+			defer stop()
+			source = pageURL
+		}
+
+		cmd = exec.CommandContext(ctx, c.htmlPath,
+			"--headless=new", "--disable-gpu", "--no-first-run", "--no-default-browser-check",
+			"--user-data-dir="+profileDir,
+			"--no-pdf-header-footer",
+			"--virtual-time-budget=10000", // Let scripts finish rendering before printing
+			"--print-to-pdf="+outputPath,
+			source)
+	default:
+		// A page must not pull other local files, such as SSH keys, into a
+		// PDF that gets shared; a local page may only load its own folder
+		args := []string{"--quiet", "--disable-local-file-access"}
+		if !isWebURL(source) {
+			args = append(args, "--allow", filepath.Dir(source))
+		}
+		cmd = exec.CommandContext(ctx, c.htmlPath, append(args, source, outputPath)...)
+	}
+
+	output, err := common.RunCommand(cmd, c.ResourceLimits())
+	if err != nil {
+		os.Remove(outputPath)
+		if ctx.Err() == context.DeadlineExceeded {
+			return common.NewError(common.ErrTimeout, "the page took too long to render")
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%s failed: %v, output: %s", c.htmlRenderer, err, string(output))
+	}
+
+	if info, err := os.Stat(outputPath); err != nil || info.Size() == 0 {
+		os.Remove(outputPath)
+		return fmt.Errorf("%s did not create a PDF, output: %s", c.htmlRenderer, string(output))
+	}
+	return nil
+}
+
+// isWebURL reports whether source is an http(s) URL rather than a local file
+func isWebURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// serveLocalPage serves the folder of a local HTML file on a loopback port
+// and returns the page's URL there. Files outside the folder, including
+// through symlinks, cannot be reached.
+func serveLocalPage(path string) (string, func(), error) {
+	root, err := os.OpenRoot(filepath.Dir(path))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open page folder: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		root.Close()
+		return "", nil, fmt.Errorf("failed to serve page: %v", err)
+	}
+
+	server := &http.Server{Handler: http.FileServerFS(root.FS())}
+	go server.Serve(listener)
+
+	pageURL := (&url.URL{Scheme: "http", Host: listener.Addr().String(), Path: "/" + filepath.Base(path)}).String()
+	stop := func() {
+		server.Close()
+		root.Close()
+	}
+	return pageURL, stop, nil
+}
@@ -0,0 +1,460 @@
+package compression
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// maxFormDepth bounds the walk through nested form XObjects
+const maxFormDepth = 16
+
+// hiddenLayers rewrites content that belongs to hidden optional content groups
+type hiddenLayers struct {
+	ctx    *model.Context
+	hidden map[int]bool // Object numbers of hidden OCGs
+	forms  map[int]bool // Form XObjects already rewritten
+}
+
+// RemoveHiddenLayers writes a copy of the input without the content that is
+// never shown on screen: optional content groups that are off in the default
+// configuration or marked print-only, and annotations that are hidden or
+// print-only. Images that are no longer drawn stay referenced from the page
+// resources until the backend drops unused resources. The output is
+// unencrypted even when options.InputPassword opens an encrypted input.
+func RemoveHiddenLayers(inputPath, outputPath string, options *CompressionOptions) error {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if options.InputPassword != "" {
+		conf.UserPW = options.InputPassword
+		conf.OwnerPW = options.InputPassword
+	}
+
+	// Validation loads every object, so none are lost when writing
+	ctx, err := api.ReadAndValidate(file, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return fmt.Errorf("failed to read catalog: %v", err)
+	}
+	properties, err := ctx.DereferenceDict(catalog["OCProperties"])
+	if err != nil {
+		return fmt.Errorf("failed to read optional content: %v", err)
+	}
+
+	layers := &hiddenLayers{
+		ctx:    ctx,
+		hidden: hiddenGroups(ctx, properties),
+		forms:  make(map[int]bool),
+	}
+
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		page, _, inherited, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return fmt.Errorf("failed to read page %d: %v", pageNr, err)
+		}
+		if err := layers.rewritePage(page, pageNr, inherited); err != nil {
+			return fmt.Errorf("failed to remove hidden content on page %d: %v", pageNr, err)
+		}
+	}
+
+	if properties != nil && len(layers.hidden) > 0 {
+		layers.pruneProperties(catalog, properties)
+	}
+
+	if options.InputPassword != "" {
+		ctx.Cmd = model.DECRYPT
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to write PDF without hidden layers: %v", err)
+	}
+
+	return nil
+}
+
+// hiddenGroups returns the OCGs that are off when the document is opened,
+// either through the default configuration or because their usage marks
+// them as invisible on screen, as print-only layers are
+func hiddenGroups(ctx *model.Context, properties types.Dict) map[int]bool {
+	hidden := make(map[int]bool)
+	if properties == nil {
+		return hidden
+	}
+
+	config, _ := ctx.DereferenceDict(properties["D"])
+	baseOff := false
+	on, off := map[int]bool{}, map[int]bool{}
+	if config != nil {
+		if base := config.NameEntry("BaseState"); base != nil && *base == "OFF" {
+			baseOff = true
+		}
+		on = refSet(ctx, config["ON"])
+		off = refSet(ctx, config["OFF"])
+	}
+
+	groups, _ := ctx.DereferenceArray(properties["OCGs"])
+	for _, obj := range groups {
+		ref, ok := obj.(types.IndirectRef)
+		if !ok {
+			continue
+		}
+		objNr := ref.ObjectNumber.Value()
+
+		visible := !baseOff
+		switch {
+		case off[objNr]:
+			visible = false
+		case on[objNr]:
+			visible = true
+		}
+		if visible && printOnly(ctx, ref) {
+			visible = false
+		}
+		if !visible {
+			hidden[objNr] = true
+		}
+	}
+	return hidden
+}
+
+// printOnly reports whether an OCG's usage turns it off for viewing
+func printOnly(ctx *model.Context, ref types.IndirectRef) bool {
+	group, err := ctx.DereferenceDict(ref)
+	if err != nil || group == nil {
+		return false
+	}
+	usage, err := ctx.DereferenceDict(group["Usage"])
+	if err != nil || usage == nil {
+		return false
+	}
+	view, err := ctx.DereferenceDict(usage["View"])
+	if err != nil || view == nil {
+		return false
+	}
+	state := view.NameEntry("ViewState")
+	return state != nil && *state == "OFF"
+}
+
+// refSet returns the object numbers of the references in an array
+func refSet(ctx *model.Context, obj types.Object) map[int]bool {
+	set := make(map[int]bool)
+	refs, _ := ctx.DereferenceArray(obj)
+	addRefs(set, refs)
+	return set
+}
+
+// addRefs records the object numbers of the indirect references in refs
+func addRefs(set map[int]bool, refs types.Array) {
+	for _, obj := range refs {
+		if ref, ok := obj.(types.IndirectRef); ok {
+			set[ref.ObjectNumber.Value()] = true
+		}
+	}
+}
+
+// isHidden reports whether an /OC entry, an OCG or an optional content
+// membership dict, is off. Visibility expressions are treated as visible.
+func (l *hiddenLayers) isHidden(obj types.Object) bool {
+	if len(l.hidden) == 0 || obj == nil {
+		return false
+	}
+	if ref, ok := obj.(types.IndirectRef); ok && l.hidden[ref.ObjectNumber.Value()] {
+		return true
+	}
+
+	membership, err := l.ctx.DereferenceDict(obj)
+	if err != nil || membership == nil {
+		return false
+	}
+	if t := membership.Type(); t == nil || *t != "OCMD" {
+		return false
+	}
+	if _, found := membership.Find("VE"); found {
+		return false
+	}
+
+	var groups types.Array
+	switch ocgs := membership["OCGs"].(type) {
+	case types.IndirectRef:
+		if arr, err := l.ctx.DereferenceArray(ocgs); err == nil {
+			groups = arr
+		} else {
+			groups = types.Array{ocgs}
+		}
+	case types.Array:
+		groups = ocgs
+	}
+	if len(groups) == 0 {
+		return false
+	}
+
+	hiddenCount := 0
+	for _, group := range groups {
+		if ref, ok := group.(types.IndirectRef); ok && l.hidden[ref.ObjectNumber.Value()] {
+			hiddenCount++
+		}
+	}
+
+	policy := "AnyOn"
+	if p := membership.NameEntry("P"); p != nil {
+		policy = *p
+	}
+	switch policy {
+	case "AllOn":
+		return hiddenCount > 0
+	case "AnyOff":
+		return hiddenCount == 0
+	case "AllOff":
+		return hiddenCount < len(groups)
+	default: // AnyOn
+		return hiddenCount == len(groups)
+	}
+}
+
+// rewritePage removes hidden content from a page and its form XObjects and
+// drops annotations that are never shown on screen
+func (l *hiddenLayers) rewritePage(page types.Dict, pageNr int, inherited *model.InheritedPageAttrs) error {
+	resources, err := l.ctx.DereferenceDict(page["Resources"])
+	if err != nil {
+		return err
+	}
+	if resources == nil && inherited != nil {
+		resources = inherited.Resources
+	}
+
+	if len(l.hidden) > 0 {
+		if _, found := page.Find("Contents"); found {
+			content, err := l.ctx.PageContent(page, pageNr)
+			if err != nil {
+				return err
+			}
+			if rewritten, changed := l.rewriteContent(content, resources); changed {
+				ref, err := l.ctx.StreamDictIndRef(rewritten)
+				if err != nil {
+					return err
+				}
+				page["Contents"] = *ref
+			}
+		}
+		if err := l.rewriteForms(resources, 0); err != nil {
+			return err
+		}
+	}
+
+	annots, err := l.ctx.DereferenceArray(page["Annots"])
+	if err != nil || annots == nil {
+		return err
+	}
+	kept := types.Array{}
+	for _, obj := range annots {
+		annot, err := l.ctx.DereferenceDict(obj)
+		if err != nil || annot == nil {
+			kept = append(kept, obj)
+			continue
+		}
+		if flags := annot.IntEntry("F"); flags != nil && *flags&(annotFlagHidden|annotFlagNoView) != 0 {
+			continue
+		}
+		if l.isHidden(annot["OC"]) {
+			continue
+		}
+		kept = append(kept, obj)
+	}
+	if len(kept) == 0 {
+		page.Delete("Annots")
+	} else {
+		page.Update("Annots", kept)
+	}
+	return nil
+}
+
+// rewriteForms removes hidden content from the visible form XObjects in resources
+func (l *hiddenLayers) rewriteForms(resources types.Dict, depth int) error {
+	if resources == nil || depth > maxFormDepth {
+		return nil
+	}
+	xobjects, err := l.ctx.DereferenceDict(resources["XObject"])
+	if err != nil || xobjects == nil {
+		return err
+	}
+
+	for _, obj := range xobjects {
+		ref, ok := obj.(types.IndirectRef)
+		if !ok || l.forms[ref.ObjectNumber.Value()] {
+			continue
+		}
+		l.forms[ref.ObjectNumber.Value()] = true
+
+		entry, found := l.ctx.FindTableEntryForIndRef(&ref)
+		if !found || entry == nil {
+			continue
+		}
+		form, ok := entry.Object.(types.StreamDict)
+		if !ok || l.isHidden(form.Dict["OC"]) {
+			continue
+		}
+		if subtype := form.Dict.Subtype(); subtype == nil || *subtype != "Form" {
+			continue
+		}
+
+		formResources, err := l.ctx.DereferenceDict(form.Dict["Resources"])
+		if err != nil {
+			return err
+		}
+		if formResources == nil {
+			formResources = resources // Old writers let forms use the page resources
+		}
+
+		if err := form.Decode(); err != nil {
+			continue // Content with filters pdfcpu cannot decode is left alone
+		}
+		if rewritten, changed := l.rewriteContent(form.Content, formResources); changed {
+			form.Content = rewritten
+			if err := form.Encode(); err != nil {
+				return err
+			}
+			entry.Object = form
+		}
+
+		if err := l.rewriteForms(formResources, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteContent cuts hidden marked-content sections and XObjects from a
+// decoded content stream. It reports false when nothing was removed.
+func (l *hiddenLayers) rewriteContent(content []byte, resources types.Dict) ([]byte, bool) {
+	var properties, xobjects types.Dict
+	if resources != nil {
+		properties, _ = l.ctx.DereferenceDict(resources["Properties"])
+		xobjects, _ = l.ctx.DereferenceDict(resources["XObject"])
+	}
+
+	var cuts [][2]int
+	depth, hiddenDepth, hiddenStart := 0, 0, -1
+	scanner := &contentScanner{data: content}
+	for {
+		op, ok := scanner.next()
+		if !ok {
+			break
+		}
+
+		switch op.operator {
+		case "BMC", "BDC":
+			depth++
+			if hiddenStart >= 0 || op.operator != "BDC" || len(op.operands) != 2 || op.operands[0] != "/OC" {
+				continue
+			}
+			name, ok := strings.CutPrefix(op.operands[1], "/")
+			if ok && properties != nil && l.isHidden(properties[name]) {
+				hiddenStart, hiddenDepth = op.start, depth
+			}
+		case "EMC":
+			if hiddenStart >= 0 && depth == hiddenDepth {
+				cuts = append(cuts, [2]int{hiddenStart, op.end})
+				hiddenStart = -1
+			}
+			if depth > 0 {
+				depth--
+			}
+		case "Do":
+			if hiddenStart >= 0 || len(op.operands) != 1 || xobjects == nil {
+				continue
+			}
+			name, _ := strings.CutPrefix(op.operands[0], "/")
+			if l.xobjectHidden(xobjects[name]) {
+				cuts = append(cuts, [2]int{op.start, op.end})
+			}
+		}
+	}
+
+	if len(cuts) == 0 {
+		return content, false
+	}
+
+	// Cuts are in stream order and never overlap
+	var out []byte
+	last := 0
+	for _, cut := range cuts {
+		out = append(out, content[last:cut[0]]...)
+		out = append(out, ' ')
+		last = cut[1]
+	}
+	out = append(out, content[last:]...)
+	return out, true
+}
+
+// xobjectHidden reports whether an image or form XObject belongs to a hidden layer
+func (l *hiddenLayers) xobjectHidden(obj types.Object) bool {
+	ref, ok := obj.(types.IndirectRef)
+	if !ok {
+		return false
+	}
+	stream, _, err := l.ctx.DereferenceStreamDict(ref)
+	if err != nil || stream == nil {
+		return false
+	}
+	return l.isHidden(stream.Dict["OC"])
+}
+
+// pruneProperties removes the hidden OCGs from the layer list and every
+// configuration, dropping OCProperties when no layer is left
+func (l *hiddenLayers) pruneProperties(catalog, properties types.Dict) {
+	groups, _ := l.ctx.DereferenceArray(properties["OCGs"])
+	groups = l.withoutHidden(groups)
+	if len(groups) == 0 {
+		catalog.Delete("OCProperties")
+		return
+	}
+	properties["OCGs"] = groups
+
+	configs := types.Array{properties["D"]}
+	if alternates, err := l.ctx.DereferenceArray(properties["Configs"]); err == nil {
+		configs = append(configs, alternates...)
+	}
+	for _, obj := range configs {
+		config, err := l.ctx.DereferenceDict(obj)
+		if err != nil || config == nil {
+			continue
+		}
+		for _, key := range []string{"ON", "OFF", "Locked", "Order", "RBGroups"} {
+			if arr, err := l.ctx.DereferenceArray(config[key]); err == nil && arr != nil {
+				config[key] = l.withoutHidden(arr)
+			}
+		}
+	}
+}
+
+// withoutHidden returns arr without references to hidden OCGs, filtering
+// nested arrays such as the grouped entries of Order and RBGroups
+func (l *hiddenLayers) withoutHidden(arr types.Array) types.Array {
+	kept := types.Array{}
+	for _, obj := range arr {
+		switch o := obj.(type) {
+		case types.IndirectRef:
+			if l.hidden[o.ObjectNumber.Value()] {
+				continue
+			}
+		case types.Array:
+			obj = l.withoutHidden(o)
+		}
+		kept = append(kept, obj)
+	}
+	return kept
+}
@@ -0,0 +1,139 @@
+package compression
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// infoMetadataKeys are the document info entries cleared when metadata is removed
+var infoMetadataKeys = []string{"Title", "Author", "Subject", "Keywords", "Creator", "Producer", "CreationDate", "ModDate"}
+
+// writerInfoKeys are stamped by the tool that last wrote the file, whatever
+// the input held, so they describe the rewrite rather than the document
+var writerInfoKeys = []string{"Producer", "CreationDate", "ModDate"}
+
+// clearInfoPdfmark returns a DOCINFO pdfmark that blanks the document info
+// entries. Ghostscript applies it after the input, overriding copied values.
+func clearInfoPdfmark() string {
+	var mark strings.Builder
+	mark.WriteString("[")
+	for _, key := range infoMetadataKeys {
+		if key == "CreationDate" || key == "ModDate" {
+			continue // Dropped with -dOmitInfoDate
+		}
+		mark.WriteString(" /" + key + " ()")
+	}
+	mark.WriteString(" /DOCINFO pdfmark")
+	return mark.String()
+}
+
+// stripMetadata rewrites a PDF in place without its XMP metadata stream and
+// document info entries. Ghostscript writes its own Producer and XMP whatever
+// it is asked, so its output goes through this pass. Password opens and
+// re-encrypts encrypted files.
+func stripMetadata(path, password string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	ctx, err := api.ReadContext(file, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read output: %v", err)
+	}
+	file.Close()
+
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return fmt.Errorf("failed to read catalog: %v", err)
+	}
+	catalog.Delete("Metadata")
+	ctx.Info = nil
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.metadata")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+	err = api.WriteContext(ctx, tempFile)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	return nil
+}
+
+// VerifyMetadataRemoved checks that a PDF has no XMP metadata stream and no
+// non-empty document info entries other than the ones its writer stamps.
+// Password opens encrypted files.
+func VerifyMetadataRemoved(path, password string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	ctx, err := api.ReadContext(file, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read output: %v", err)
+	}
+
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return fmt.Errorf("failed to read catalog: %v", err)
+	}
+	if _, found := catalog.Find("Metadata"); found {
+		return fmt.Errorf("output still contains an XMP metadata stream")
+	}
+
+	if ctx.Info == nil {
+		return nil
+	}
+
+	info, err := ctx.DereferenceDict(*ctx.Info)
+	if err != nil || info == nil {
+		return err
+	}
+
+	for _, key := range infoMetadataKeys {
+		if slices.Contains(writerInfoKeys, key) {
+			continue
+		}
+		obj, found := info.Find(key)
+		if !found || obj == nil {
+			continue
+		}
+		value, err := types.StringOrHexLiteral(obj)
+		if err == nil && value != nil && *value == "" {
+			continue
+		}
+		return fmt.Errorf("output still contains %s metadata", key)
+	}
+
+	return nil
+}
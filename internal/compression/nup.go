@@ -0,0 +1,36 @@
+package compression
+
+import (
+	"fmt"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+
+	"kleinpdf/internal/common"
+)
+
+// NUpFile places n pages of the input on each sheet of the output. Supported
+// values are 2, 4 and 8; password opens encrypted input.
+func NUpFile(inputPath, outputPath string, n int, password string) error {
+	switch n {
+	case 2, 4, 8:
+	default:
+		return common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unsupported pages per sheet %d: use 2, 4 or 8", n))
+	}
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	nup, err := api.PDFNUpConfig(n, "", conf)
+	if err != nil {
+		return fmt.Errorf("invalid n-up configuration: %v", err)
+	}
+
+	if err := api.NUpFile([]string{inputPath}, outputPath, nil, nup, conf); err != nil {
+		return fmt.Errorf("n-up failed: %v", err)
+	}
+
+	return nil
+}
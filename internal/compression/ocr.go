@@ -0,0 +1,99 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"kleinpdf/internal/common"
+)
+
+// detectOCRTool looks for an OCR tool on PATH, preferring ocrmypdf over plain tesseract
+func detectOCRTool() (name, path string) {
+	for _, tool := range []string{"ocrmypdf", "tesseract"} {
+		if toolPath, err := exec.LookPath(tool); err == nil {
+			return tool, toolPath
+		}
+	}
+	return "", ""
+}
+
+// IsOCRAvailable checks if an OCR tool is available
+func (c *Compressor) IsOCRAvailable() bool {
+	return c.ocrPath != ""
+}
+
+// GetOCRTool returns the name of the detected OCR tool
+func (c *Compressor) GetOCRTool() string {
+	return c.ocrTool
+}
+
+// AddTextLayer runs OCR on a PDF and writes a searchable copy to outputPath
+func (c *Compressor) AddTextLayer(ctx context.Context, inputPath, outputPath string) error {
+	switch c.ocrTool {
+	case "ocrmypdf":
+		return c.runOCRmyPDF(ctx, inputPath, outputPath)
+	case "tesseract":
+		return c.runTesseract(ctx, inputPath, outputPath)
+	default:
+		return fmt.Errorf("OCR requested but neither ocrmypdf nor tesseract is installed")
+	}
+}
+
+// runOCRmyPDF adds a text layer while keeping existing page content
+func (c *Compressor) runOCRmyPDF(ctx context.Context, inputPath, outputPath string) error {
+	cmd := exec.CommandContext(ctx, c.ocrPath, "--skip-text", "--output-type", "pdf", inputPath, outputPath)
+	output, err := common.RunCommand(cmd, c.ResourceLimits())
+	if err != nil {
+		if ctx.Err() != nil {
+			os.Remove(outputPath)
+			return ctx.Err()
+		}
+		return fmt.Errorf("ocrmypdf failed: %v, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// runTesseract rasterizes the PDF with Ghostscript and rebuilds it with tesseract.
+// Pages become images with an invisible text layer.
+func (c *Compressor) runTesseract(ctx context.Context, inputPath, outputPath string) error {
+	tempDir, err := os.MkdirTemp(c.WorkingDir(), "kleinpdf-ocr-")
+	if err != nil {
+		return fmt.Errorf("failed to create OCR temp directory: %v", err)
+	}
+	defer common.RemoveTempDir(tempDir)
+
+	imagePath := filepath.Join(tempDir, "pages.tiff")
+	args := []string{
+		"-sDEVICE=tiff24nc",
+		"-r300",
+		"-dNOPAUSE",
+		"-dQUIET",
+		"-dBATCH",
+		"-sOutputFile=" + imagePath,
+		inputPath,
+	}
+	cmd := common.GhostscriptCommand(ctx, c.GetGhostscriptPath(), args...)
+	if output, err := common.RunCommand(cmd, c.ResourceLimits()); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("OCR rasterization failed: %v, output: %s", err, string(output))
+	}
+
+	// tesseract appends the .pdf extension itself
+	outputBase := strings.TrimSuffix(outputPath, ".pdf")
+	cmd = exec.CommandContext(ctx, c.ocrPath, imagePath, outputBase, "pdf")
+	if output, err := common.RunCommand(cmd, c.ResourceLimits()); err != nil {
+		if ctx.Err() != nil {
+			os.Remove(outputPath)
+			return ctx.Err()
+		}
+		return fmt.Errorf("tesseract failed: %v, output: %s", err, string(output))
+	}
+
+	return nil
+}
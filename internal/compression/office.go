@@ -0,0 +1,111 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"kleinpdf/internal/common"
+)
+
+// ConvertibleOfficeFormats are the document formats LibreOffice converts
+var ConvertibleOfficeFormats = map[string]bool{
+	"docx": true,
+	"xlsx": true,
+	"pptx": true,
+	"doc":  true,
+	"rtf":  true,
+	"odt":  true,
+	"ods":  true,
+	"odp":  true,
+}
+
+// detectOffice looks for LibreOffice on PATH and in its default install
+// location, since apps started from the Finder do not inherit the shell PATH
+func detectOffice() string {
+	for _, name := range []string{"soffice", "libreoffice"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+
+	var candidates []string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = []string{"/Applications/LibreOffice.app/Contents/MacOS/soffice"}
+	case "windows":
+		for _, dir := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)")} {
+			if dir != "" {
+				candidates = append(candidates, filepath.Join(dir, "LibreOffice", "program", "soffice.exe"))
+			}
+		}
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// IsOfficeAvailable checks if LibreOffice is available for document conversion
+func (c *Compressor) IsOfficeAvailable() bool {
+	return c.officePath != ""
+}
+
+// ConvertOfficeToPDF converts a Word, Excel, PowerPoint or OpenDocument file
+// to PDF with headless LibreOffice
+func (c *Compressor) ConvertOfficeToPDF(ctx context.Context, inputPath, outputPath string) error {
+	if c.officePath == "" {
+		return fmt.Errorf("converting documents needs LibreOffice, which is not installed")
+	}
+
+	tempDir, err := os.MkdirTemp(c.WorkingDir(), "kleinpdf-office-")
+	if err != nil {
+		return fmt.Errorf("failed to create conversion temp directory: %v", err)
+	}
+	defer common.RemoveTempDir(tempDir)
+
+	// A private profile lets conversions run next to each other and next to
+	// a LibreOffice window the user already has open
+	profile := filepath.ToSlash(filepath.Join(tempDir, "profile"))
+	if !strings.HasPrefix(profile, "/") {
+		profile = "/" + profile
+	}
+	profileURL := (&url.URL{Scheme: "file", Path: profile}).String()
+
+	outDir := filepath.Join(tempDir, "out")
+	cmd := exec.CommandContext(ctx, c.officePath,
+		"--headless", "--norestore", "--nologo", "--nodefault",
+		"-env:UserInstallation="+profileURL,
+		"--convert-to", "pdf",
+		"--outdir", outDir,
+		inputPath)
+	output, err := common.RunCommand(cmd, c.ResourceLimits())
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("LibreOffice failed: %v, output: %s", err, string(output))
+	}
+
+	// LibreOffice names the PDF after the input and exits cleanly even when
+	// it could not read the document
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	convertedPath := filepath.Join(outDir, baseName+".pdf")
+	if _, err := os.Stat(convertedPath); err != nil {
+		return fmt.Errorf("LibreOffice did not create a PDF, output: %s", string(output))
+	}
+
+	if err := os.Rename(convertedPath, outputPath); err != nil {
+		if err := common.CopyFile(convertedPath, outputPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"kleinpdf/internal/common"
+)
+
+// newPdfcpuConfiguration returns a pdfcpu configuration that never touches the user config dir
+func newPdfcpuConfiguration() *model.Configuration {
+	api.DisableConfigDir()
+	return model.NewDefaultConfiguration()
+}
+
+// pdfcpuBackend optimizes with the pure-Go pdfcpu library. It removes redundant
+// objects and compresses streams but cannot downsample images like Ghostscript.
+type pdfcpuBackend struct {
+	c *Compressor
+}
+
+func (b *pdfcpuBackend) Name() string {
+	return BackendPdfcpu
+}
+
+func (b *pdfcpuBackend) Available() bool {
+	return true
+}
+
+func (b *pdfcpuBackend) Capabilities() Capabilities {
+	return Capabilities{
+		Encryption: true,
+		Decryption: true,
+		OCR:        b.c.ocrTool == "ocrmypdf",
+	}
+}
+
+// Compress optimizes the file in-process; ctx is only checked before starting
+func (b *pdfcpuBackend) Compress(ctx context.Context, inputPath, outputPath, compressionLevel string, options *CompressionOptions, onProgress ProgressFunc) error {
+	c := b.c
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	actualInputPath := inputPath
+	if options.AddTextLayer {
+		if options.InputPassword != "" {
+			return fmt.Errorf("OCR is not supported for password-protected input")
+		}
+
+		tempOCRPath, err := c.tempFilePath(inputPath, "ocr")
+		if err != nil {
+			return err
+		}
+		if err := c.AddTextLayer(ctx, inputPath, tempOCRPath); err != nil {
+			return fmt.Errorf("OCR failed: %v", err)
+		}
+		actualInputPath = tempOCRPath
+		defer common.RemoveTemp(tempOCRPath)
+	}
+
+	conf := newPdfcpuConfiguration()
+	if options.InputPassword != "" {
+		conf.UserPW = options.InputPassword
+		conf.OwnerPW = options.InputPassword
+	}
+	// Pages that share identical content streams can share one copy
+	if options.OptimizeOnly {
+		conf.OptimizeDuplicateContentStreams = true
+	}
+
+	if err := api.OptimizeFile(actualInputPath, outputPath, conf); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("pdfcpu optimization failed: %v", err)
+	}
+
+	if options.OwnerPassword != "" || options.UserPassword != "" {
+		ownerPassword := options.OwnerPassword
+		if ownerPassword == "" {
+			ownerPassword = options.UserPassword
+		}
+		encryptConf := newPdfcpuConfiguration()
+		encryptConf.UserPW = options.UserPassword
+		encryptConf.OwnerPW = ownerPassword
+		if err := api.EncryptFile(outputPath, "", encryptConf); err != nil {
+			os.Remove(outputPath)
+			return fmt.Errorf("pdfcpu encryption failed: %v", err)
+		}
+	}
+
+	return nil
+}
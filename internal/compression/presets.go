@@ -0,0 +1,119 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// CompressionLevels are the built-in quality levels, from gentle to aggressive
+var CompressionLevels = []string{"good_enough", "aggressive", "ultra"}
+
+// IsKnownLevel reports whether level is a built-in level, the auto level or
+// an email preset
+func IsKnownLevel(level string) bool {
+	if level == AutoLevel {
+		return true
+	}
+	for _, known := range CompressionLevels {
+		if level == known {
+			return true
+		}
+	}
+	_, ok := LookupEmailPreset(level)
+	return ok
+}
+
+// EmailPreset targets a common attachment size limit
+type EmailPreset struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	TargetBytes int64  `json:"target_bytes"`
+	MinDPI      int    `json:"min_dpi"`
+}
+
+// EmailPresets are selectable as compression levels
+var EmailPresets = []EmailPreset{
+	{Name: "email_10mb", Label: "Email (10 MB)", TargetBytes: 10 * 1000 * 1000, MinDPI: 72},
+	{Name: "email_20mb", Label: "Email (20 MB)", TargetBytes: 20 * 1000 * 1000, MinDPI: 96},
+	{Name: "email_25mb", Label: "Email (25 MB)", TargetBytes: 25 * 1000 * 1000, MinDPI: 100},
+}
+
+// presetAttempt is one step of the target-size iteration
+type presetAttempt struct {
+	level string
+	dpi   int
+}
+
+// presetAttempts go from gentle to aggressive; DPIs below the preset floor are skipped
+var presetAttempts = []presetAttempt{
+	{"good_enough", 150},
+	{"aggressive", 150},
+	{"aggressive", 120},
+	{"ultra", 100},
+	{"ultra", 72},
+}
+
+// LookupEmailPreset returns the preset named by a compression level
+func LookupEmailPreset(level string) (EmailPreset, bool) {
+	for _, preset := range EmailPresets {
+		if preset.Name == level {
+			return preset, true
+		}
+	}
+	return EmailPreset{}, false
+}
+
+// compressToTarget compresses with increasingly aggressive settings until the
+// output fits the preset target. If no attempt fits, the most aggressive output is kept.
+func (c *Compressor) compressToTarget(ctx context.Context, backend Backend, inputPath, outputPath string, preset EmailPreset, options *CompressionOptions, onProgress ProgressFunc) error {
+	// Without downsampling every attempt would produce the same output
+	attempts := presetAttempts
+	if !backend.Capabilities().ImageDownsampling {
+		attempts = attempts[:1]
+	}
+
+	var lastSize int64
+	var previous presetAttempt
+	for _, attempt := range attempts {
+		dpi := attempt.dpi
+		if dpi < preset.MinDPI {
+			dpi = preset.MinDPI
+		}
+		if previous.level == attempt.level && previous.dpi == dpi {
+			continue
+		}
+		previous = presetAttempt{attempt.level, dpi}
+
+		attemptOptions := *options
+		attemptOptions.ImageDPI = dpi
+
+		if err := backend.Compress(ctx, inputPath, outputPath, attempt.level, &attemptOptions, onProgress); err != nil {
+			return err
+		}
+
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to read output size: %v", err)
+		}
+		lastSize = info.Size()
+
+		c.logger.Debug("Target size attempt",
+			"file", inputPath,
+			"preset", preset.Name,
+			"level", attempt.level,
+			"dpi", dpi,
+			"size", lastSize)
+
+		if lastSize <= preset.TargetBytes {
+			return nil
+		}
+	}
+
+	c.logger.Info("Could not reach preset target size",
+		"file", inputPath,
+		"preset", preset.Name,
+		"target", preset.TargetBytes,
+		"size", lastSize)
+	return nil
+}
@@ -0,0 +1,77 @@
+package compression
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProgressFunc receives page progress while Ghostscript processes a file
+type ProgressFunc func(page, totalPages int, percent float64)
+
+var (
+	pageRangePattern = regexp.MustCompile(`^Processing pages (\d+) through (\d+)\.`)
+	pagePattern      = regexp.MustCompile(`^Page (\d+)$`)
+)
+
+// progressScanner turns Ghostscript output lines into page progress and keeps
+// the output for error reporting
+type progressScanner struct {
+	onProgress ProgressFunc
+	output     strings.Builder
+	firstPage  int
+	totalPages int
+}
+
+// newProgressScanner creates a scanner reporting to onProgress, which may be nil
+func newProgressScanner(onProgress ProgressFunc) *progressScanner {
+	return &progressScanner{onProgress: onProgress, firstPage: 1}
+}
+
+// scan records one line of output
+func (s *progressScanner) scan(line string) {
+	s.output.WriteString(line)
+	s.output.WriteByte('\n')
+
+	if s.onProgress == nil {
+		return
+	}
+
+	if m := pageRangePattern.FindStringSubmatch(line); m != nil {
+		first, _ := strconv.Atoi(m[1])
+		last, _ := strconv.Atoi(m[2])
+		if last >= first {
+			s.firstPage, s.totalPages = first, last-first+1
+			s.onProgress(0, s.totalPages, 0)
+		}
+		return
+	}
+
+	if m := pagePattern.FindStringSubmatch(line); m != nil && s.totalPages > 0 {
+		page, _ := strconv.Atoi(m[1])
+		// "Page N" is printed when Ghostscript starts a page, so N-1 pages are done
+		done := page - s.firstPage
+		s.onProgress(page-s.firstPage+1, s.totalPages, float64(done)/float64(s.totalPages)*100)
+	}
+}
+
+// scanProgress reads Ghostscript stdout line by line, reporting page progress
+// and returning the collected output for error reporting along with the page count.
+// Output after an overlong line is drained so Ghostscript never blocks on a
+// full pipe.
+func scanProgress(r io.Reader, onProgress ProgressFunc) (string, int) {
+	s := newProgressScanner(onProgress)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		s.scan(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		s.scan(fmt.Sprintf("(output truncated: %v)", err))
+	}
+	io.Copy(io.Discard, r)
+	return s.output.String(), s.totalPages
+}
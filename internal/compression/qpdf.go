@@ -0,0 +1,81 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"kleinpdf/internal/common"
+)
+
+// qpdfBackend optimizes with an external qpdf binary. Like pdfcpu it rewrites
+// structure and recompresses streams without touching images.
+type qpdfBackend struct {
+	c    *Compressor
+	path string
+}
+
+// newQpdfBackend looks up qpdf on PATH
+func newQpdfBackend(c *Compressor) *qpdfBackend {
+	path, _ := exec.LookPath("qpdf")
+	return &qpdfBackend{c: c, path: path}
+}
+
+func (b *qpdfBackend) Name() string {
+	return BackendQpdf
+}
+
+func (b *qpdfBackend) Available() bool {
+	return b.path != ""
+}
+
+func (b *qpdfBackend) Capabilities() Capabilities {
+	return Capabilities{
+		Encryption: true,
+		Decryption: true,
+	}
+}
+
+// Compress runs qpdf, killing the process when ctx is cancelled
+func (b *qpdfBackend) Compress(ctx context.Context, inputPath, outputPath, compressionLevel string, options *CompressionOptions, onProgress ProgressFunc) error {
+	args := []string{
+		"--object-streams=generate",
+		"--compress-streams=y",
+		"--recompress-flate",
+		"--compression-level=9",
+	}
+
+	if options.InputPassword != "" {
+		args = append(args, "--password="+options.InputPassword)
+	}
+
+	if options.OwnerPassword != "" || options.UserPassword != "" {
+		ownerPassword := options.OwnerPassword
+		if ownerPassword == "" {
+			ownerPassword = options.UserPassword
+		}
+		args = append(args, "--encrypt", options.UserPassword, ownerPassword, "256", "--")
+	}
+
+	args = append(args, inputPath, outputPath)
+
+	cmd := exec.CommandContext(ctx, b.path, args...)
+	output, err := common.RunCommand(cmd, b.c.ResourceLimits())
+	if err != nil {
+		if ctx.Err() != nil {
+			os.Remove(outputPath)
+			return ctx.Err()
+		}
+		// Exit code 3 means success with warnings
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 3 {
+			return fmt.Errorf("qpdf failed: %v, output: %s", err, string(output))
+		}
+	}
+
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		return fmt.Errorf("qpdf did not create output file")
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	// qualityDPI is the resolution pages are rendered at for scoring; high
+	// enough to show JPEG artefacts, low enough to stay quick
+	qualityDPI = 100
+
+	// qualityWindow is the side of the square windows SSIM is computed over
+	qualityWindow = 8
+
+	// qualityWarningSSIM is the score below which the loss is likely visible
+	qualityWarningSSIM = 0.9
+
+	// maxPSNR stands in for identical renders, whose PSNR is infinite
+	maxPSNR = 100.0
+)
+
+// SSIM stabilising constants for 8-bit samples
+var (
+	ssimC1 = math.Pow(0.01*255, 2)
+	ssimC2 = math.Pow(0.03*255, 2)
+)
+
+// QualityScore compares renders of sample pages from an input and its output
+type QualityScore struct {
+	SSIM    float64 `json:"ssim"` // Mean structural similarity, 1 when identical
+	PSNR    float64 `json:"psnr"` // Peak signal-to-noise ratio in dB
+	Pages   []int   `json:"pages"`
+	Warning string  `json:"warning,omitempty"`
+}
+
+// MeasureQuality renders the first, middle and last page of both files in
+// grayscale at the same resolution and scores how closely the output matches
+// the input. Options that change the page geometry, such as resizing or n-up,
+// make the renders incomparable and return an error.
+func (c *Compressor) MeasureQuality(ctx context.Context, inputPath, outputPath string, pageCount int, options *CompressionOptions) (*QualityScore, error) {
+	pages := qualitySamplePages(pageCount)
+
+	tempDir, err := os.MkdirTemp(c.WorkingDir(), "kleinpdf_quality_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer common.RemoveTempDir(tempDir)
+
+	inputImages, err := c.renderGray(ctx, inputPath, filepath.Join(tempDir, "input"), options.InputPassword, pages, qualityDPI)
+	if err != nil {
+		return nil, err
+	}
+
+	outputPassword := options.OwnerPassword
+	if outputPassword == "" {
+		outputPassword = options.UserPassword
+	}
+	outputImages, err := c.renderGray(ctx, outputPath, filepath.Join(tempDir, "output"), outputPassword, pages, qualityDPI)
+	if err != nil {
+		return nil, err
+	}
+
+	var ssimTotal, squaredError float64
+	var pixels int
+	for i := range pages {
+		in, out := inputImages[i], outputImages[i]
+		if in.Bounds().Size() != out.Bounds().Size() {
+			return nil, fmt.Errorf("page %d changed size from %v to %v", pages[i], in.Bounds().Size(), out.Bounds().Size())
+		}
+		ssimTotal += meanSSIM(in, out)
+		squaredError += sumSquaredError(in, out)
+		pixels += in.Bounds().Dx() * in.Bounds().Dy()
+	}
+
+	score := &QualityScore{
+		SSIM:  ssimTotal / float64(len(pages)),
+		PSNR:  maxPSNR,
+		Pages: pages,
+	}
+	if mse := squaredError / float64(pixels); mse > 0 {
+		score.PSNR = min(10*math.Log10(255*255/mse), maxPSNR)
+	}
+	if score.SSIM < qualityWarningSSIM {
+		score.Warning = fmt.Sprintf("quality score %.2f suggests visible loss of detail; try a gentler level", score.SSIM)
+	}
+	return score, nil
+}
+
+// qualitySamplePages returns the first, middle and last page numbers
+func qualitySamplePages(pageCount int) []int {
+	if pageCount < 1 {
+		return []int{1}
+	}
+	pages := []int{1}
+	for _, page := range []int{(pageCount + 1) / 2, pageCount} {
+		if page != pages[len(pages)-1] {
+			pages = append(pages, page)
+		}
+	}
+	return pages
+}
+
+// renderGray renders the given pages of a PDF to grayscale PNGs at dpi with
+// one Ghostscript run and decodes them in page order
+func (c *Compressor) renderGray(ctx context.Context, path, prefix, password string, pages []int, dpi int) ([]*image.Gray, error) {
+	ghostscriptPath := c.GetGhostscriptPath()
+	if ghostscriptPath == "" {
+		return nil, common.NewError(common.ErrGhostscriptMissing, "ghostscript not found. Please install ghostscript to use this application")
+	}
+
+	pageList := make([]string, len(pages))
+	for i, page := range pages {
+		pageList[i] = strconv.Itoa(page)
+	}
+
+	args := []string{
+		"-sDEVICE=pnggray",
+		"-dNOPAUSE",
+		"-dBATCH",
+		"-dQUIET",
+		"-dTextAlphaBits=4",
+		"-dGraphicsAlphaBits=4",
+		fmt.Sprintf("-r%d", dpi),
+		"-sPageList=" + strings.Join(pageList, ","),
+		"-sOutputFile=" + prefix + "_%d.png",
+	}
+	if password != "" {
+		args = append(args, "-sPDFPassword="+password)
+	}
+	args = append(args, path)
+
+	cmd := common.GhostscriptCommand(ctx, ghostscriptPath, args...)
+	if output, err := common.RunCommand(cmd, c.ResourceLimits()); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, common.NewError(common.ErrGhostscriptCrash, fmt.Sprintf("rendering pages failed: %v, output: %s", err, string(output)))
+	}
+
+	images := make([]*image.Gray, len(pages))
+	for i := range pages {
+		img, err := readGrayPNG(fmt.Sprintf("%s_%d.png", prefix, i+1))
+		if err != nil {
+			return nil, err
+		}
+		images[i] = img
+	}
+	return images, nil
+}
+
+// readGrayPNG decodes a PNG into a grayscale image
+func readGrayPNG(path string) (*image.Gray, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read render: %v", err)
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode render: %v", err)
+	}
+	if gray, ok := img.(*image.Gray); ok {
+		return gray, nil
+	}
+
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray.Set(x-bounds.Min.X, y-bounds.Min.Y, img.At(x, y))
+		}
+	}
+	return gray, nil
+}
+
+// meanSSIM averages the structural similarity of two same-sized images over
+// non-overlapping windows
+func meanSSIM(a, b *image.Gray) float64 {
+	width, height := a.Bounds().Dx(), a.Bounds().Dy()
+	n := float64(qualityWindow * qualityWindow)
+
+	var total float64
+	windows := 0
+	for y := 0; y+qualityWindow <= height; y += qualityWindow {
+		for x := 0; x+qualityWindow <= width; x += qualityWindow {
+			var sumA, sumB, sumAA, sumBB, sumAB float64
+			for wy := y; wy < y+qualityWindow; wy++ {
+				rowA := a.Pix[wy*a.Stride:]
+				rowB := b.Pix[wy*b.Stride:]
+				for wx := x; wx < x+qualityWindow; wx++ {
+					pa, pb := float64(rowA[wx]), float64(rowB[wx])
+					sumA += pa
+					sumB += pb
+					sumAA += pa * pa
+					sumBB += pb * pb
+					sumAB += pa * pb
+				}
+			}
+			meanA, meanB := sumA/n, sumB/n
+			varA := sumAA/n - meanA*meanA
+			varB := sumBB/n - meanB*meanB
+			covariance := sumAB/n - meanA*meanB
+
+			total += (2*meanA*meanB + ssimC1) * (2*covariance + ssimC2) /
+				((meanA*meanA + meanB*meanB + ssimC1) * (varA + varB + ssimC2))
+			windows++
+		}
+	}
+
+	if windows == 0 {
+		return 1
+	}
+	return total / float64(windows)
+}
+
+// sumSquaredError returns the summed squared difference of two same-sized images
+func sumSquaredError(a, b *image.Gray) float64 {
+	width, height := a.Bounds().Dx(), a.Bounds().Dy()
+	var sum float64
+	for y := 0; y < height; y++ {
+		rowA := a.Pix[y*a.Stride : y*a.Stride+width]
+		rowB := b.Pix[y*b.Stride : y*b.Stride+width]
+		for x := range rowA {
+			d := float64(rowA[x]) - float64(rowB[x])
+			sum += d * d
+		}
+	}
+	return sum
+}
@@ -0,0 +1,69 @@
+package compression
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+
+	"kleinpdf/internal/common"
+)
+
+// resizePaperSizes are the named targets accepted by ResizeArgs, as Ghostscript paper names
+var resizePaperSizes = map[string]string{
+	"a3":     "a3",
+	"a4":     "a4",
+	"a5":     "a5",
+	"letter": "letter",
+	"legal":  "legal",
+}
+
+// ResizeArgs returns the Ghostscript arguments that fit every page onto the
+// target media. The target is a paper size (A4, Letter, ...) or a percentage
+// such as "50%", which scales relative to the first page of the input.
+func ResizeArgs(inputPath, target, password string) ([]string, error) {
+	target = strings.ToLower(strings.TrimSpace(target))
+
+	if paperSize, ok := resizePaperSizes[target]; ok {
+		return []string{"-sPAPERSIZE=" + paperSize, "-dFIXEDMEDIA", "-dPDFFitPage"}, nil
+	}
+
+	if !strings.HasSuffix(target, "%") {
+		return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown resize target %q", target))
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(target, "%"), 64)
+	if err != nil || percent <= 0 || percent > 1000 {
+		return nil, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("invalid resize scale %q", target))
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	dims, err := api.PageDims(file, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page size: %v", err)
+	}
+	if len(dims) == 0 {
+		return nil, fmt.Errorf("document has no pages")
+	}
+
+	scale := percent / 100
+	return []string{
+		fmt.Sprintf("-dDEVICEWIDTHPOINTS=%d", int(dims[0].Width*scale+0.5)),
+		fmt.Sprintf("-dDEVICEHEIGHTPOINTS=%d", int(dims[0].Height*scale+0.5)),
+		"-dFIXEDMEDIA",
+		"-dPDFFitPage",
+	}, nil
+}
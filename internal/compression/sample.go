@@ -0,0 +1,96 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math/rand"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+const (
+	// sampleDPI is the resolution of the sample pages, typical of a scan and
+	// high enough for every level to downsample
+	sampleDPI = 300
+
+	// Sample pages are A4 at sampleDPI
+	sampleWidth  = 2480
+	sampleHeight = 3508
+)
+
+// WriteSamplePDF writes a document of photo-like A4 pages at 300 dpi to path.
+// The content is generated from a fixed seed, so every sample is the same and
+// timings taken on different machines compare fairly.
+func WriteSamplePDF(path string, pages int) error {
+	images := make([]io.Reader, pages)
+	for i := range images {
+		data, err := samplePageJPEG(int64(i + 1))
+		if err != nil {
+			return err
+		}
+		images[i] = bytes.NewReader(data)
+	}
+
+	output, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	imp := pdfcpu.DefaultImportConfig()
+	imp.Pos = types.Center
+	imp.DPI = sampleDPI
+	imp.Scale = 1
+	imp.ScaleAbs = true
+
+	err = api.ImportImages(nil, output, images, imp, newPdfcpuConfiguration())
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write sample PDF: %v", err)
+	}
+	return nil
+}
+
+// samplePageJPEG draws a page of gradients, blocks of colour and light grain,
+// which compresses like a photo rather than a flat graphic
+func samplePageJPEG(seed int64) ([]byte, error) {
+	rng := rand.New(rand.NewSource(seed))
+	img := image.NewRGBA(image.Rect(0, 0, sampleWidth, sampleHeight))
+
+	for y := 0; y < sampleHeight; y++ {
+		for x := 0; x < sampleWidth; x++ {
+			grain := uint8(rng.Intn(24))
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(x*200/sampleWidth) + grain,
+				G: uint8(y*200/sampleHeight) + grain,
+				B: uint8((x+y)*100/(sampleWidth+sampleHeight)) + 100 + grain,
+				A: 255,
+			})
+		}
+	}
+
+	for i := 0; i < 24; i++ {
+		x, y := rng.Intn(sampleWidth-400), rng.Intn(sampleHeight-400)
+		fill := color.RGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 255}
+		for by := y; by < y+100+rng.Intn(300); by++ {
+			for bx := x; bx < x+400; bx++ {
+				img.SetRGBA(bx, by, fill)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, fmt.Errorf("failed to encode sample page: %v", err)
+	}
+	return buf.Bytes(), nil
+}
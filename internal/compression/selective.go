@@ -0,0 +1,323 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"kleinpdf/internal/common"
+)
+
+// checkSelective rejects options that cannot apply to only some pages
+func checkSelective(options *CompressionOptions) error {
+	switch {
+	case options.OwnerPassword != "" || options.UserPassword != "":
+		return common.NewError(common.ErrInvalidRequest, "encrypting the output is not available when compressing selected pages")
+	case options.ResizeTarget != "":
+		return common.NewError(common.ErrInvalidRequest, "resizing pages is not available when compressing selected pages")
+	case options.ConvertToGrayscale:
+		return common.NewError(common.ErrInvalidRequest, "grayscale conversion is not available when compressing selected pages")
+	}
+	return nil
+}
+
+// compressSelectedPages compresses only the pages with an image above
+// options.SelectiveDPI and copies the other pages through untouched, so
+// vector diagrams and text pages keep their exact content. Email presets aim
+// the compressed pages, not the whole file, at the target size.
+func (c *Compressor) compressSelectedPages(ctx context.Context, backend Backend, inputPath, outputPath, compressionLevel string, options *CompressionOptions, onProgress ProgressFunc) error {
+	if err := checkSelective(options); err != nil {
+		return err
+	}
+
+	pageDPI, err := readPageImageDPI(inputPath, options.InputPassword)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if options.InputPassword != "" {
+		conf.UserPW = options.InputPassword
+		conf.OwnerPW = options.InputPassword
+	}
+	conf.Cmd = model.EXTRACTPAGES
+	source, err := api.ReadValidateAndOptimize(file, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	var selected []int
+	for pageNr := 1; pageNr <= source.PageCount; pageNr++ {
+		if pageDPI[pageNr] > float64(options.SelectiveDPI) {
+			selected = append(selected, pageNr)
+		}
+	}
+
+	c.logger.Debug("Selected pages to compress", "file", inputPath, "threshold_dpi", options.SelectiveDPI, "selected", len(selected), "pages", source.PageCount)
+
+	switch len(selected) {
+	case 0:
+		return c.rewriteUnselected(ctx, inputPath, outputPath, compressionLevel, options, onProgress)
+	case source.PageCount:
+		return c.compressPages(ctx, backend, inputPath, outputPath, compressionLevel, options, onProgress)
+	}
+
+	// Compress the selected pages as one document
+	tempSelectedPath, err := c.tempFilePath(inputPath, "selected")
+	if err != nil {
+		return err
+	}
+	defer common.RemoveTemp(tempSelectedPath)
+	if err := writePages(source, selected, tempSelectedPath); err != nil {
+		return err
+	}
+
+	tempCompressedPath, err := c.tempFilePath(inputPath, "selected_compressed")
+	if err != nil {
+		return err
+	}
+	defer common.RemoveTemp(tempCompressedPath)
+
+	// The extracted pages are unencrypted
+	selectedOptions := *options
+	selectedOptions.InputPassword = ""
+	if err := c.compressPages(ctx, backend, tempSelectedPath, tempCompressedPath, compressionLevel, &selectedOptions, onProgress); err != nil {
+		return err
+	}
+
+	compressedFile, err := os.Open(tempCompressedPath)
+	if err != nil {
+		return err
+	}
+	defer compressedFile.Close()
+	compressedConf := newPdfcpuConfiguration()
+	compressedConf.Cmd = model.EXTRACTPAGES
+	compressed, err := api.ReadValidateAndOptimize(compressedFile, compressedConf)
+	if err != nil {
+		return fmt.Errorf("failed to read compressed pages: %v", err)
+	}
+	if compressed.PageCount != len(selected) {
+		return fmt.Errorf("compressed pages came back with %d pages instead of %d", compressed.PageCount, len(selected))
+	}
+
+	// Swap the compressed pages' content into the source document, which
+	// keeps its outline, links, forms and other document-level structures
+	for i, pageNr := range selected {
+		if err := swapPageContent(source, pageNr, compressed, i+1); err != nil {
+			return err
+		}
+	}
+
+	// The output is written unencrypted, as the other paths write it
+	if source.Encrypt != nil {
+		source.Cmd = model.DECRYPT
+	}
+	if err := api.WriteContextFile(source, outputPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to write PDF: %v", err)
+	}
+
+	if options.RemoveMetadata {
+		if err := stripMetadata(outputPath, ""); err != nil {
+			os.Remove(outputPath)
+			return fmt.Errorf("metadata removal failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// swapPageContent replaces the content, resources and boxes of page pageNr
+// in dest with those of page fromNr in from. The page keeps its annotations
+// and its place in the page tree, so links and outline entries pointing at
+// it still work.
+func swapPageContent(dest *model.Context, pageNr int, from *model.Context, fromNr int) error {
+	page, _, _, err := dest.PageDict(pageNr, false)
+	if err != nil || page == nil {
+		return fmt.Errorf("failed to read page %d: %v", pageNr, err)
+	}
+	fromPage, _, inherited, err := from.PageDict(fromNr, false)
+	if err != nil || fromPage == nil || inherited == nil {
+		return fmt.Errorf("failed to read compressed page %d: %v", fromNr, err)
+	}
+
+	copied := make(map[int]types.IndirectRef)
+	contents, err := copyObject(from, dest, fromPage["Contents"], copied)
+	if err != nil {
+		return err
+	}
+	resources, err := copyObject(from, dest, inherited.Resources, copied)
+	if err != nil {
+		return err
+	}
+
+	page["Contents"] = contents
+	page["Resources"] = resources
+	if inherited.MediaBox != nil {
+		page["MediaBox"] = inherited.MediaBox.Array()
+	}
+	if inherited.CropBox != nil {
+		page["CropBox"] = inherited.CropBox.Array()
+	}
+	page["Rotate"] = types.Integer(inherited.Rotate)
+	return nil
+}
+
+// copyObject copies obj from one document into another, adding every object
+// it refers to under a new object number. copied maps the object numbers
+// already copied, which also ends reference cycles.
+func copyObject(from, dest *model.Context, obj types.Object, copied map[int]types.IndirectRef) (types.Object, error) {
+	switch obj := obj.(type) {
+	case types.IndirectRef:
+		if ref, ok := copied[obj.ObjectNumber.Value()]; ok {
+			return ref, nil
+		}
+		ref, err := dest.IndRefForNewObject(types.Dict{})
+		if err != nil {
+			return nil, err
+		}
+		copied[obj.ObjectNumber.Value()] = *ref
+
+		target, err := from.Dereference(obj)
+		if err != nil {
+			return nil, err
+		}
+		value, err := copyObject(from, dest, target, copied)
+		if err != nil {
+			return nil, err
+		}
+		dest.Table[ref.ObjectNumber.Value()].Object = value
+		return *ref, nil
+
+	case types.Dict:
+		dict := types.NewDict()
+		for key, value := range obj {
+			copiedValue, err := copyObject(from, dest, value, copied)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = copiedValue
+		}
+		return dict, nil
+
+	case types.StreamDict:
+		dict, err := copyObject(from, dest, obj.Dict, copied)
+		if err != nil {
+			return nil, err
+		}
+		stream := obj
+		stream.Dict = dict.(types.Dict)
+		return stream, nil
+
+	case types.Array:
+		array := make(types.Array, len(obj))
+		for i, value := range obj {
+			copiedValue, err := copyObject(from, dest, value, copied)
+			if err != nil {
+				return nil, err
+			}
+			array[i] = copiedValue
+		}
+		return array, nil
+	}
+	return obj, nil
+}
+
+// rewriteUnselected writes a document with no page to compress through
+// pdfcpu's lossless optimisation, so it still gets the same structural
+// clean-up, decryption and metadata removal as the pages around compressed ones
+func (c *Compressor) rewriteUnselected(ctx context.Context, inputPath, outputPath, compressionLevel string, options *CompressionOptions, onProgress ProgressFunc) error {
+	backend, err := c.selectBackend(BackendPdfcpu)
+	if err != nil {
+		return err
+	}
+	if err := backend.Compress(ctx, inputPath, outputPath, compressionLevel, options, onProgress); err != nil {
+		return err
+	}
+	if options.RemoveMetadata {
+		if err := stripMetadata(outputPath, ""); err != nil {
+			os.Remove(outputPath)
+			return fmt.Errorf("metadata removal failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// writePages writes the given pages of a document to path
+func writePages(ctx *model.Context, pages []int, path string) error {
+	extracted, err := pdfcpu.ExtractPages(ctx, pages, false)
+	if err != nil {
+		return fmt.Errorf("failed to extract pages: %v", err)
+	}
+	if err := api.WriteContextFile(extracted, path); err != nil {
+		return fmt.Errorf("failed to write pages: %v", err)
+	}
+	return nil
+}
+
+// readPageImageDPI returns the effective resolution of the widest image on
+// each page that has one
+func readPageImageDPI(inputPath, password string) (map[int]float64, error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+	info, err := api.PDFInfo(file, inputPath, nil, false, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	pageImages, err := api.Images(file, nil, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read images: %v", err)
+	}
+
+	pageDPI := make(map[int]float64)
+	for _, images := range pageImages {
+		for _, image := range images {
+			if image.Thumb || image.IsImgMask {
+				continue
+			}
+			dpi, ok := imageDPI(info, image)
+			if !ok {
+				continue
+			}
+			pageDPI[image.PageNr] = max(pageDPI[image.PageNr], dpi)
+		}
+	}
+	return pageDPI, nil
+}
+
+// imageDPI estimates the resolution of an image assuming it spans the width
+// of its page, as Analyze does. It is exact for scans and too low for images
+// drawn narrower than the page.
+func imageDPI(info *pdfcpu.PDFInfo, image model.Image) (float64, bool) {
+	if image.PageNr < 1 || image.PageNr > len(info.PageBoundaries) {
+		return 0, false
+	}
+	mediaBox := info.PageBoundaries[image.PageNr-1].MediaBox()
+	if mediaBox == nil || mediaBox.Width() <= 0 {
+		return 0, false
+	}
+	return float64(image.Width) / (mediaBox.Width() / 72), true
+}
@@ -0,0 +1,84 @@
+package compression
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// maxFieldDepth bounds the walk through nested form fields
+const maxFieldDepth = 32
+
+// IsSigned reports whether a PDF carries a digital signature. Rewriting such
+// a file, as every compression does, invalidates the signature. Password
+// opens encrypted files.
+func IsSigned(path, password string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+
+	ctx, err := api.ReadContext(file, conf)
+	if err != nil {
+		return false, fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return false, fmt.Errorf("failed to read catalog: %v", err)
+	}
+
+	obj, found := catalog.Find("AcroForm")
+	if !found {
+		return false, nil
+	}
+	form, err := ctx.DereferenceDict(obj)
+	if err != nil || form == nil {
+		return false, err
+	}
+
+	// SignaturesExist is the first SigFlags bit
+	if flags := form.IntEntry("SigFlags"); flags != nil && *flags&1 != 0 {
+		return true, nil
+	}
+
+	fields, err := ctx.DereferenceArray(form["Fields"])
+	if err != nil {
+		return false, err
+	}
+	return hasSignedField(ctx, fields, 0), nil
+}
+
+// hasSignedField reports whether any field in fields, or their kids, is a
+// signature field with a value
+func hasSignedField(ctx *model.Context, fields types.Array, depth int) bool {
+	if depth > maxFieldDepth {
+		return false
+	}
+	for _, obj := range fields {
+		field, err := ctx.DereferenceDict(obj)
+		if err != nil || field == nil {
+			continue
+		}
+		if ft := field.NameEntry("FT"); ft != nil && *ft == "Sig" {
+			if v, found := field.Find("V"); found && v != nil {
+				return true
+			}
+		}
+		kids, err := ctx.DereferenceArray(field["Kids"])
+		if err == nil && hasSignedField(ctx, kids, depth+1) {
+			return true
+		}
+	}
+	return false
+}
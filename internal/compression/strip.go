@@ -0,0 +1,185 @@
+package compression
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// multimediaAnnotations are the annotation subtypes that embed audio, video or 3D content
+var multimediaAnnotations = map[string]bool{
+	"RichMedia": true,
+	"Screen":    true,
+	"Movie":     true,
+	"Sound":     true,
+	"3D":        true,
+}
+
+// wantsStrip reports whether any attachment, script or media removal is requested
+func wantsStrip(options *CompressionOptions) bool {
+	return options.RemoveAttachments || options.RemoveJavaScript || options.RemoveMultimedia
+}
+
+// StripContent writes a copy of the input without the embedded files,
+// JavaScript or multimedia annotations selected in options. Objects only
+// reachable from the removed entries are not written. The output is
+// unencrypted even when options.InputPassword opens an encrypted input.
+func StripContent(inputPath, outputPath string, options *CompressionOptions) error {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if options.InputPassword != "" {
+		conf.UserPW = options.InputPassword
+		conf.OwnerPW = options.InputPassword
+	}
+
+	// Validation loads every object, so none are lost when writing
+	ctx, err := api.ReadAndValidate(file, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return fmt.Errorf("failed to read catalog: %v", err)
+	}
+
+	if options.RemoveAttachments {
+		removeNameTree(ctx, catalog, "EmbeddedFiles")
+		catalog.Delete("Collection") // Portfolio layout for the embedded files
+	}
+	if options.RemoveJavaScript {
+		removeNameTree(ctx, catalog, "JavaScript")
+		catalog.Delete("AA")
+		if isJavaScriptAction(ctx, catalog["OpenAction"]) {
+			catalog.Delete("OpenAction")
+		}
+		if form, err := ctx.DereferenceDict(catalog["AcroForm"]); err == nil && form != nil {
+			form.Delete("XFA") // XFA forms carry their own scripts
+			if fields, err := ctx.DereferenceArray(form["Fields"]); err == nil {
+				stripFieldActions(ctx, fields, 0)
+			}
+		}
+	}
+
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		page, _, _, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return fmt.Errorf("failed to read page %d: %v", pageNr, err)
+		}
+		if options.RemoveJavaScript {
+			page.Delete("AA")
+		}
+		if err := stripAnnotations(ctx, page, options); err != nil {
+			return fmt.Errorf("failed to strip annotations on page %d: %v", pageNr, err)
+		}
+	}
+
+	if options.InputPassword != "" {
+		ctx.Cmd = model.DECRYPT
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to write stripped PDF: %v", err)
+	}
+
+	return nil
+}
+
+// removeNameTree drops a name tree from the catalog's Names dict. pdfcpu
+// writes name trees from its own cache, so that entry goes too.
+func removeNameTree(ctx *model.Context, catalog types.Dict, name string) {
+	delete(ctx.Names, name)
+
+	names, err := ctx.DereferenceDict(catalog["Names"])
+	if err != nil || names == nil {
+		return
+	}
+	names.Delete(name)
+	if names.Len() == 0 {
+		catalog.Delete("Names")
+	}
+}
+
+// stripAnnotations drops file attachment and multimedia annotations from a
+// page and removes script actions from the ones that remain
+func stripAnnotations(ctx *model.Context, page types.Dict, options *CompressionOptions) error {
+	annots, err := ctx.DereferenceArray(page["Annots"])
+	if err != nil || annots == nil {
+		return err
+	}
+
+	kept := types.Array{}
+	for _, obj := range annots {
+		annot, err := ctx.DereferenceDict(obj)
+		if err != nil || annot == nil {
+			kept = append(kept, obj)
+			continue
+		}
+
+		subtype := ""
+		if st := annot.Subtype(); st != nil {
+			subtype = *st
+		}
+		if options.RemoveAttachments && subtype == "FileAttachment" {
+			continue
+		}
+		if options.RemoveMultimedia && multimediaAnnotations[subtype] {
+			continue
+		}
+
+		if options.RemoveJavaScript {
+			annot.Delete("AA")
+			if isJavaScriptAction(ctx, annot["A"]) {
+				annot.Delete("A")
+			}
+		}
+		kept = append(kept, obj)
+	}
+
+	if len(kept) == 0 {
+		page.Delete("Annots")
+	} else {
+		page.Update("Annots", kept)
+	}
+	return nil
+}
+
+// stripFieldActions removes the additional actions, such as keystroke and
+// calculation scripts, from form fields and their kids
+func stripFieldActions(ctx *model.Context, fields types.Array, depth int) {
+	if depth > maxFieldDepth {
+		return
+	}
+	for _, obj := range fields {
+		field, err := ctx.DereferenceDict(obj)
+		if err != nil || field == nil {
+			continue
+		}
+		field.Delete("AA")
+		if kids, err := ctx.DereferenceArray(field["Kids"]); err == nil {
+			stripFieldActions(ctx, kids, depth+1)
+		}
+	}
+}
+
+// isJavaScriptAction reports whether obj is an action dict that runs JavaScript
+func isJavaScriptAction(ctx *model.Context, obj types.Object) bool {
+	if obj == nil {
+		return false
+	}
+	action, err := ctx.DereferenceDict(obj)
+	if err != nil || action == nil {
+		return false
+	}
+	s := action.NameEntry("S")
+	return s != nil && *s == "JavaScript"
+}
@@ -0,0 +1,93 @@
+package compression
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"kleinpdf/internal/common"
+)
+
+// textRetentionThreshold is the share of input words the output must keep
+// before the check warns that selectable text was lost
+const textRetentionThreshold = 0.9
+
+// TextIntegrity compares the selectable text of an input and its compressed output
+type TextIntegrity struct {
+	InputWords  int     `json:"input_words"`
+	OutputWords int     `json:"output_words"`
+	Retained    float64 `json:"retained"` // Percentage of input words found in the output
+	Warning     string  `json:"warning,omitempty"`
+}
+
+// VerifyText extracts the text of both files and warns when the output lost a
+// noticeable share of the input's words, as happens when fonts are rasterized.
+// Inputs without selectable text always pass.
+func (c *Compressor) VerifyText(ctx context.Context, inputPath, outputPath string, options *CompressionOptions) (*TextIntegrity, error) {
+	inputText, err := c.extractText(ctx, inputPath, options.InputPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	outputPassword := options.OwnerPassword
+	if outputPassword == "" {
+		outputPassword = options.UserPassword
+	}
+	outputText, err := c.extractText(ctx, outputPath, outputPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TextIntegrity{
+		InputWords:  len(strings.Fields(inputText)),
+		OutputWords: len(strings.Fields(outputText)),
+		Retained:    100,
+	}
+	if result.InputWords == 0 {
+		return result, nil
+	}
+
+	retained := float64(result.OutputWords) / float64(result.InputWords)
+	result.Retained = min(retained, 1) * 100
+	if retained < textRetentionThreshold {
+		result.Warning = fmt.Sprintf("output keeps %d of %d words of selectable text; search and copy may not work", result.OutputWords, result.InputWords)
+	}
+	return result, nil
+}
+
+// extractText returns the text of a PDF using Ghostscript's txtwrite device
+func (c *Compressor) extractText(ctx context.Context, path, password string) (string, error) {
+	ghostscriptPath := c.GetGhostscriptPath()
+	if ghostscriptPath == "" {
+		return "", common.NewError(common.ErrGhostscriptMissing, "ghostscript not found. Please install ghostscript to use this application")
+	}
+
+	// Text goes to a file because Ghostscript mixes warnings into stdout
+	textFile, err := os.CreateTemp(c.WorkingDir(), "kleinpdf-text-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	textFile.Close()
+	defer common.RemoveTemp(textFile.Name())
+
+	args := []string{"-sDEVICE=txtwrite", "-dNOPAUSE", "-dBATCH", "-dQUIET", "-sOutputFile=" + textFile.Name()}
+	if password != "" {
+		args = append(args, "-sPDFPassword="+password)
+	}
+	args = append(args, path)
+
+	cmd := common.GhostscriptCommand(ctx, ghostscriptPath, args...)
+	if output, err := common.RunCommand(cmd, c.ResourceLimits()); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", common.NewError(common.ErrGhostscriptCrash, fmt.Sprintf("text extraction failed: %v, output: %s", err, string(output)))
+	}
+
+	text, err := os.ReadFile(textFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read extracted text: %v", err)
+	}
+	return string(text), nil
+}
@@ -2,13 +2,46 @@ package compression
 
 // CompressionOptions holds advanced compression options for PDF processing
 type CompressionOptions struct {
-	ImageDPI           int    `json:"image_dpi"`
-	ImageQuality       int    `json:"image_quality"`
-	PDFVersion         string `json:"pdf_version"`
-	RemoveMetadata     bool   `json:"remove_metadata"`
-	EmbedFonts         bool   `json:"embed_fonts"`
-	GenerateThumbnails bool   `json:"generate_thumbnails"`
-	ConvertToGrayscale bool   `json:"convert_to_grayscale"`
+	ImageDPI            int     `json:"image_dpi"`
+	ImageQuality        int     `json:"image_quality"`
+	PDFVersion          string  `json:"pdf_version"`
+	RemoveMetadata      bool    `json:"remove_metadata"`
+	EmbedFonts          bool    `json:"embed_fonts"`
+	GenerateThumbnails  bool    `json:"generate_thumbnails"`
+	ConvertToGrayscale  bool    `json:"convert_to_grayscale"`
+	AddTextLayer        bool    `json:"add_text_layer"`
+	OwnerPassword       string  `json:"owner_password"`
+	UserPassword        string  `json:"user_password"`
+	InputPassword       string  `json:"input_password,omitempty"`
+	Backend             string  `json:"backend"`
+	DownsampleType      string  `json:"downsample_type"`
+	DownsampleThreshold float64 `json:"downsample_threshold"`
+	MonoImageCodec      string  `json:"mono_image_codec"`
+	DeduplicateImages   bool    `json:"deduplicate_images"`
+	PreserveBookmarks   bool    `json:"preserve_bookmarks"`
+	ColorConversion     string  `json:"color_conversion"`
+	ResizeTarget        string  `json:"resize_target"`
+	PagesPerSheet       int     `json:"pages_per_sheet"`
+	TrimMargins         bool    `json:"trim_margins"`
+	VerifyText          bool    `json:"verify_text"`
+	MeasureQuality      bool    `json:"measure_quality"`
+	RemoveAttachments   bool    `json:"remove_attachments"`
+	RemoveJavaScript    bool    `json:"remove_javascript"`
+	RemoveMultimedia    bool    `json:"remove_multimedia"`
+	FlattenForms        bool    `json:"flatten_forms"`
+	RemoveAnnotations   bool    `json:"remove_annotations"`
+	RemoveHiddenLayers  bool    `json:"remove_hidden_layers"`
+	FlattenTransparency bool    `json:"flatten_transparency"`
+
+	// SelectiveDPI, when set, compresses only pages with an image above this
+	// resolution and copies the other pages through untouched, so vector
+	// diagrams in mixed documents keep their exact content
+	SelectiveDPI int `json:"selective_dpi"`
+
+	// OptimizeOnly restricts compression to a structural pdfcpu pass: object
+	// streams, unused-object removal and resource deduplication. Images and
+	// page content are copied as they are, so pages stay pixel-identical.
+	OptimizeOnly bool `json:"optimize_only"`
 }
 
 // DefaultCompressionOptions returns default compression options
@@ -21,5 +54,21 @@ func DefaultCompressionOptions() CompressionOptions {
 		EmbedFonts:         true,
 		GenerateThumbnails: false,
 		ConvertToGrayscale: false,
+		AddTextLayer:       false,
+		DownsampleType:     "bicubic",
+		DeduplicateImages:  true,
+		PreserveBookmarks:  true,
+		ColorConversion:    "srgb",
 	}
-}
\ No newline at end of file
+}
+
+// ChangesContent reports whether options ask for more than a smaller file,
+// such as encryption, a text layer or removed content. Keeping the original
+// in place of a larger output would silently undo such a request.
+func (o *CompressionOptions) ChangesContent() bool {
+	return o.OwnerPassword != "" || o.UserPassword != "" ||
+		o.AddTextLayer || o.RemoveMetadata || o.ConvertToGrayscale ||
+		o.ResizeTarget != "" || o.PagesPerSheet > 1 || o.TrimMargins ||
+		o.FlattenTransparency || o.RemoveHiddenLayers ||
+		wantsStrip(o) || wantsFlatten(o)
+}
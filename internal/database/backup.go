@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Backup writes a consistent copy of the live database to path using
+// SQLite's online backup, so the app keeps working while it runs
+func (d *Database) Backup(path string) error {
+	tempPath := path + ".tmp"
+	os.Remove(tempPath)
+
+	err := withSQLiteFile(tempPath, func(dest *sqlite3.SQLiteConn) error {
+		return d.withSQLiteConn(func(src *sqlite3.SQLiteConn) error {
+			return copySQLite(dest, src)
+		})
+	})
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("database backup failed: %v", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// Restore replaces the live database with the backup at path, then migrates
+// it in case the backup was made by an older version
+func (d *Database) Restore(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	if err := checkIntegrity(path); err != nil {
+		return fmt.Errorf("database restore failed: %v", err)
+	}
+
+	err := withSQLiteFile(path, func(src *sqlite3.SQLiteConn) error {
+		return d.withSQLiteConn(func(dest *sqlite3.SQLiteConn) error {
+			return copySQLite(dest, src)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("database restore failed: %v", err)
+	}
+
+	return d.migrate("")
+}
+
+// withSQLiteConn runs fn on a raw connection to the live database
+func (d *Database) withSQLiteConn(fn func(*sqlite3.SQLiteConn) error) error {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	return withRawConn(sqlDB, fn)
+}
+
+// withSQLiteFile runs fn on a raw connection to the database file at path
+func withSQLiteFile(path string, fn func(*sqlite3.SQLiteConn) error) error {
+	sqlDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	return withRawConn(sqlDB, fn)
+}
+
+// withRawConn runs fn on the driver connection behind one pooled connection
+func withRawConn(sqlDB *sql.DB, fn func(*sqlite3.SQLiteConn) error) error {
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("unexpected database driver %T", driverConn)
+		}
+		return fn(sqliteConn)
+	})
+}
+
+// copySQLite copies every page of src into dest in a single backup step
+func copySQLite(dest, src *sqlite3.SQLiteConn) error {
+	backup, err := dest.Backup("main", src, "main")
+	if err != nil {
+		return err
+	}
+	if _, err := backup.Step(-1); err != nil {
+		backup.Finish()
+		return err
+	}
+	return backup.Finish()
+}
+
+// checkIntegrity rejects files that are not intact SQLite databases
+func checkIntegrity(path string) error {
+	sqlDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	var result string
+	if err := sqlDB.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
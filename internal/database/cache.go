@@ -0,0 +1,34 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetCachedCompression finds a previous output for the input and settings hashes
+func (d *Database) GetCachedCompression(inputHash, settingsHash string) (*CompressionCache, error) {
+	var entry CompressionCache
+	err := d.db.Where("input_hash = ? AND settings_hash = ?", inputHash, settingsHash).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// SaveCachedCompression records the latest output for the input and settings hashes
+func (d *Database) SaveCachedCompression(entry *CompressionCache) error {
+	return d.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "input_hash"}, {Name: "settings_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"output_path", "output_size", "output_hash", "updated_at"}),
+	}).Create(entry).Error
+}
+
+// DeleteCachedCompression removes a stale cache entry
+func (d *Database) DeleteCachedCompression(id uint) error {
+	return d.db.Delete(&CompressionCache{}, id).Error
+}
@@ -7,7 +7,11 @@ import (
 
 // Database handles database operations
 type Database struct {
-	db *gorm.DB
+	db       *gorm.DB
+	warnings []string
+
+	// validLevel reports whether a compression level name is known
+	validLevel func(string) bool
 }
 
 // NewDatabase creates a new database instance
@@ -19,15 +23,25 @@ func NewDatabase(dbPath string) (*Database, error) {
 
 	database := &Database{db: db}
 
-	// Auto-migrate the schema
-	err = db.AutoMigrate(&UserPreferences{})
-	if err != nil {
+	// Auto-migrate the schema, backing up databases from older versions first
+	if err := database.migrate(dbPath); err != nil {
 		return nil, err
 	}
 
 	return database, nil
 }
 
+// Warnings returns problems found while opening the database that did not stop it from working
+func (d *Database) Warnings() []string {
+	return d.warnings
+}
+
+// SetLevelValidator sets the check applied to the default compression level
+// preference. Without one, any non-empty level is accepted.
+func (d *Database) SetLevelValidator(valid func(string) bool) {
+	d.validLevel = valid
+}
+
 // GetPreferences gets the current user preferences
 func (d *Database) GetPreferences() (*UserPreferencesData, error) {
 	prefs, err := d.getOrCreatePreferences()
@@ -103,12 +117,206 @@ func (d *Database) UpdatePreferences(data map[string]interface{}) error {
 		}
 	}
 
-	// Save updated preferences
-	if err := prefs.SetPreferences(currentPrefs); err != nil {
+	if val, ok := data["compression_backend"]; ok {
+		if backend, ok := val.(string); ok {
+			currentPrefs.CompressionBackend = backend
+		}
+	}
+
+	if val, ok := data["working_dir"]; ok {
+		if dir, ok := val.(string); ok {
+			currentPrefs.WorkingDir = dir
+		}
+	}
+
+	if val, ok := data["background_priority"]; ok {
+		if background, ok := val.(bool); ok {
+			currentPrefs.BackgroundPriority = background
+		}
+	}
+
+	if val, ok := data["max_memory_mb"]; ok {
+		if memory, ok := val.(float64); ok {
+			currentPrefs.MaxMemoryMB = int(memory)
+		}
+	}
+
+	if val, ok := data["downsample_type"]; ok {
+		if downsampleType, ok := val.(string); ok {
+			currentPrefs.DownsampleType = downsampleType
+		}
+	}
+
+	if val, ok := data["downsample_threshold"]; ok {
+		if threshold, ok := val.(float64); ok {
+			currentPrefs.DownsampleThreshold = threshold
+		}
+	}
+
+	if val, ok := data["mono_image_codec"]; ok {
+		if codec, ok := val.(string); ok {
+			currentPrefs.MonoImageCodec = codec
+		}
+	}
+
+	if val, ok := data["deduplicate_images"]; ok {
+		if deduplicate, ok := val.(bool); ok {
+			currentPrefs.DeduplicateImages = deduplicate
+		}
+	}
+
+	if val, ok := data["preserve_bookmarks"]; ok {
+		if preserve, ok := val.(bool); ok {
+			currentPrefs.PreserveBookmarks = preserve
+		}
+	}
+
+	if val, ok := data["color_conversion"]; ok {
+		if conversion, ok := val.(string); ok {
+			currentPrefs.ColorConversion = conversion
+		}
+	}
+
+	if val, ok := data["ghostscript_path"]; ok {
+		if path, ok := val.(string); ok {
+			currentPrefs.GhostscriptPath = path
+		}
+	}
+
+	if val, ok := data["log_level"]; ok {
+		if level, ok := val.(string); ok {
+			currentPrefs.LogLevel = level
+		}
+	}
+
+	if val, ok := data["crash_reports_enabled"]; ok {
+		if enabled, ok := val.(bool); ok {
+			currentPrefs.CrashReportsEnabled = enabled
+		}
+	}
+
+	if val, ok := data["crash_report_url"]; ok {
+		if url, ok := val.(string); ok {
+			currentPrefs.CrashReportURL = url
+		}
+	}
+
+	if val, ok := data["keep_original_backups"]; ok {
+		if keep, ok := val.(bool); ok {
+			currentPrefs.KeepOriginalBackups = keep
+		}
+	}
+
+	if val, ok := data["backup_retention_days"]; ok {
+		if days, ok := val.(float64); ok {
+			currentPrefs.BackupRetentionDays = int(days)
+		}
+	}
+
+	if val, ok := data["menu_bar_mode"]; ok {
+		if enabled, ok := val.(bool); ok {
+			currentPrefs.MenuBarMode = enabled
+		}
+	}
+
+	if val, ok := data["smtp_host"]; ok {
+		if host, ok := val.(string); ok {
+			currentPrefs.SMTPHost = host
+		}
+	}
+
+	if val, ok := data["smtp_port"]; ok {
+		if port, ok := val.(float64); ok {
+			currentPrefs.SMTPPort = int(port)
+		}
+	}
+
+	if val, ok := data["smtp_username"]; ok {
+		if username, ok := val.(string); ok {
+			currentPrefs.SMTPUsername = username
+		}
+	}
+
+	if val, ok := data["smtp_from"]; ok {
+		if from, ok := val.(string); ok {
+			currentPrefs.SMTPFrom = from
+		}
+	}
+
+	if val, ok := data["smtp_security"]; ok {
+		if security, ok := val.(string); ok {
+			currentPrefs.SMTPSecurity = security
+		}
+	}
+
+	if val, ok := data["collision_strategy"]; ok {
+		if strategy, ok := val.(string); ok {
+			currentPrefs.CollisionStrategy = strategy
+		}
+	}
+
+	if val, ok := data["preserve_file_metadata"]; ok {
+		if enabled, ok := val.(bool); ok {
+			currentPrefs.PreserveFileMetadata = enabled
+		}
+	}
+
+	if val, ok := data["skip_signed_pdfs"]; ok {
+		if enabled, ok := val.(bool); ok {
+			currentPrefs.SkipSignedPDFs = enabled
+		}
+	}
+
+	if val, ok := data["sign_output"]; ok {
+		if enabled, ok := val.(bool); ok {
+			currentPrefs.SignOutput = enabled
+		}
+	}
+
+	if val, ok := data["signing_certificate_path"]; ok {
+		if value, ok := val.(string); ok {
+			currentPrefs.SigningCertificatePath = value
+		}
+	}
+
+	if val, ok := data["signing_keychain_identity"]; ok {
+		if value, ok := val.(string); ok {
+			currentPrefs.SigningKeychainIdentity = value
+		}
+	}
+
+	if val, ok := data["signing_reason"]; ok {
+		if value, ok := val.(string); ok {
+			currentPrefs.SigningReason = value
+		}
+	}
+
+	if val, ok := data["secure_delete_temp"]; ok {
+		if enabled, ok := val.(bool); ok {
+			currentPrefs.SecureDeleteTemp = enabled
+		}
+	}
+
+	if val, ok := data["max_concurrency"]; ok {
+		if workers, ok := val.(float64); ok {
+			currentPrefs.MaxConcurrency = int(workers)
+		}
+	}
+
+	if val, ok := data["event_stream_port"]; ok {
+		if port, ok := val.(float64); ok {
+			currentPrefs.EventStreamPort = int(port)
+		}
+	}
+
+	// Reject out-of-range or unknown values before saving
+	if err := validatePreferences(&currentPrefs, data, d.validLevel); err != nil {
 		return err
 	}
 
-	return d.db.Save(prefs).Error
+	// Save updated preferences
+	prefs.SetPreferences(currentPrefs)
+	return d.db.Select("*").Save(prefs).Error
 }
 
 // getOrCreatePreferences gets existing preferences or creates default ones
@@ -125,10 +333,7 @@ func (d *Database) getOrCreatePreferences() (*UserPreferences, error) {
 				ID: 1,
 			}
 
-			defaultPrefs := DefaultPreferences()
-			if err := prefs.SetPreferences(defaultPrefs); err != nil {
-				return nil, err
-			}
+			prefs.SetPreferences(DefaultPreferences())
 
 			if err := d.db.Create(&prefs).Error; err != nil {
 				return nil, err
@@ -0,0 +1,29 @@
+package database
+
+// ListDestinations loads every upload destination, ordered by name
+func (d *Database) ListDestinations() ([]Destination, error) {
+	var destinations []Destination
+	if err := d.db.Order("name, id").Find(&destinations).Error; err != nil {
+		return nil, err
+	}
+	return destinations, nil
+}
+
+// GetDestination loads a single upload destination
+func (d *Database) GetDestination(id uint) (*Destination, error) {
+	var destination Destination
+	if err := d.db.First(&destination, id).Error; err != nil {
+		return nil, err
+	}
+	return &destination, nil
+}
+
+// SaveDestination creates a destination, or updates it when ID is set
+func (d *Database) SaveDestination(destination *Destination) error {
+	return d.db.Save(destination).Error
+}
+
+// DeleteDestination removes an upload destination
+func (d *Database) DeleteDestination(id uint) error {
+	return d.db.Delete(&Destination{}, id).Error
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SaveHistoryEntries records finished files in the compression history
+func (d *Database) SaveHistoryEntries(entries []HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return d.db.Create(&entries).Error
+}
+
+// GetHistoryEntry loads a single history entry
+func (d *Database) GetHistoryEntry(id uint) (*HistoryEntry, error) {
+	var entry HistoryEntry
+	if err := d.db.First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// MarkHistoryUndone records that the compression of an entry was reverted
+func (d *Database) MarkHistoryUndone(id uint) error {
+	return d.db.Model(&HistoryEntry{}).Where("id = ?", id).Update("status", "undone").Error
+}
+
+// GetLatestBatchHistory loads the entries of the most recently recorded batch,
+// or nil when the history is empty
+func (d *Database) GetLatestBatchHistory() ([]HistoryEntry, error) {
+	var latest HistoryEntry
+	err := d.db.Order("created_at DESC, id DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	if err := d.db.Where("batch_id = ?", latest.BatchID).Order("id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetHistorySince loads history entries created at or after since, oldest first
+func (d *Database) GetHistorySince(since time.Time) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	if err := d.db.Where("created_at >= ?", since).Order("created_at").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SearchHistory returns a page of history entries matching filter, newest
+// first, together with the total number of matches
+func (d *Database) SearchHistory(filter HistoryFilter, offset, limit int) ([]HistoryEntry, int64, error) {
+	query := d.db.Model(&HistoryEntry{})
+	if filter.Query != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Query) + "%"
+		query = query.Where("original_filename LIKE ? ESCAPE '\\'", pattern)
+	}
+	if filter.Level != "" {
+		query = query.Where("compression_level = ?", filter.Level)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []HistoryEntry
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// likeEscaper escapes LIKE wildcards so a search matches them literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
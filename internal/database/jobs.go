@@ -0,0 +1,61 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// SaveBatchJob persists a batch and its files as pending, replacing any earlier record with the same ID
+func (d *Database) SaveBatchJob(batchID, requestJSON string, files []string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteBatchJob(tx, batchID); err != nil {
+			return err
+		}
+
+		job := BatchJob{ID: batchID, RequestJSON: requestJSON}
+		for _, file := range files {
+			job.Files = append(job.Files, BatchJobFile{FilePath: file, Status: "pending"})
+		}
+
+		return tx.Create(&job).Error
+	})
+}
+
+// UpdateBatchJobFile records the status of a file in a persisted batch
+func (d *Database) UpdateBatchJobFile(batchID, filePath, status string) error {
+	return d.db.Model(&BatchJobFile{}).
+		Where("batch_id = ? AND file_path = ?", batchID, filePath).
+		Update("status", status).Error
+}
+
+// DeleteBatchJob removes a finished or discarded batch
+func (d *Database) DeleteBatchJob(batchID string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return deleteBatchJob(tx, batchID)
+	})
+}
+
+// GetBatchJob loads a persisted batch with its files
+func (d *Database) GetBatchJob(batchID string) (*BatchJob, error) {
+	var job BatchJob
+	if err := d.db.Preload("Files").First(&job, "id = ?", batchID).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetBatchJobs loads all persisted batches, i.e. those interrupted before finishing
+func (d *Database) GetBatchJobs() ([]BatchJob, error) {
+	var jobs []BatchJob
+	if err := d.db.Preload("Files").Order("created_at").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// deleteBatchJob removes a batch and its files within a transaction
+func deleteBatchJob(tx *gorm.DB, batchID string) error {
+	if err := tx.Where("batch_id = ?", batchID).Delete(&BatchJobFile{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&BatchJob{}, "id = ?", batchID).Error
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// schemaVersion is stored in PRAGMA user_version after migrating. Bump it
+// whenever a model changes so existing databases are backed up first.
+const schemaVersion = 15
+
+// migrate brings the schema up to date. An existing database with an older
+// schema version is first backed up next to dbPath, unless dbPath is empty.
+func (d *Database) migrate(dbPath string) error {
+	var version int
+	if err := d.db.Raw("PRAGMA user_version").Scan(&version).Error; err != nil {
+		return err
+	}
+
+	if version < schemaVersion && dbPath != "" {
+		var tables int
+		d.db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables)
+		if tables > 0 {
+			backupPath := fmt.Sprintf("%s.pre-migration-v%d.bak", dbPath, version)
+			if err := d.Backup(backupPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	err := d.db.AutoMigrate(&UserPreferences{}, &CompressionCache{}, &BatchJob{}, &BatchJobFile{}, &HistoryEntry{}, &LifetimeStats{}, &Destination{}, &OutputFolder{})
+	if err != nil {
+		return err
+	}
+
+	if err := d.migratePreferencesJSON(); err != nil {
+		return err
+	}
+
+	if version != schemaVersion {
+		return d.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)).Error
+	}
+	return nil
+}
+
+// migratePreferencesJSON moves preferences saved as a JSON blob by older
+// versions into their columns. Keys missing from the blob keep their defaults.
+// A blob that cannot be parsed is reset to defaults and reported as a warning;
+// the pre-migration backup still holds it.
+func (d *Database) migratePreferencesJSON() error {
+	var rows []UserPreferences
+	if err := d.db.Where("preferences_json IS NOT NULL AND preferences_json != ''").Find(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		prefs := DefaultPreferences()
+		if err := json.Unmarshal([]byte(row.PreferencesJSON), &prefs); err != nil {
+			d.warnings = append(d.warnings, fmt.Sprintf("saved preferences could not be read and were reset to defaults: %v", err))
+			prefs = DefaultPreferences()
+		}
+
+		row.UserPreferencesData = prefs
+		row.PreferencesJSON = ""
+		if err := d.db.Select("*").Save(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
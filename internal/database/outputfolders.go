@@ -0,0 +1,17 @@
+package database
+
+import "gorm.io/gorm/clause"
+
+// RememberOutputFolder records a folder the user picked
+func (d *Database) RememberOutputFolder(path string) error {
+	return d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&OutputFolder{Path: path}).Error
+}
+
+// IsOutputFolder reports whether the user has picked path before
+func (d *Database) IsOutputFolder(path string) (bool, error) {
+	var count int64
+	if err := d.db.Model(&OutputFolder{}).Where("path = ?", path).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,37 @@
+package database
+
+import "fmt"
+
+// LegacyPasswords returns the passwords older versions kept in a plain column
+// of table, by row ID, so they can be moved to the system keychain
+func (d *Database) LegacyPasswords(table, column string) (map[uint]string, error) {
+	if !d.db.Migrator().HasColumn(table, column) {
+		return nil, nil
+	}
+
+	var rows []struct {
+		ID       uint
+		Password string
+	}
+	query := fmt.Sprintf("SELECT id, %s AS password FROM %s WHERE %s IS NOT NULL AND %s != ''", column, table, column, column)
+	if err := d.db.Raw(query).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	passwords := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		passwords[row.ID] = row.Password
+	}
+	return passwords, nil
+}
+
+// ClearLegacyPassword blanks a password column once the keychain holds the
+// password, and vacuums so the old value does not linger in free pages and
+// later backups
+func (d *Database) ClearLegacyPassword(table, column string, id uint) error {
+	query := fmt.Sprintf("UPDATE %s SET %s = '' WHERE id = ?", table, column)
+	if err := d.db.Exec(query, id).Error; err != nil {
+		return err
+	}
+	return d.db.Exec("VACUUM").Error
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// lifetimeStatsID is the primary key of the single lifetime stats row
+const lifetimeStatsID = 1
+
+// GetLifetimeStats loads the lifetime totals, which are zero before the first batch
+func (d *Database) GetLifetimeStats() (*LifetimeStats, error) {
+	var stats LifetimeStats
+	err := d.db.First(&stats, lifetimeStatsID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &LifetimeStats{ID: lifetimeStatsID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// AddLifetimeStats adds a batch to the lifetime totals and returns the new totals
+func (d *Database) AddLifetimeStats(files, dataSaved int64) (*LifetimeStats, error) {
+	var stats LifetimeStats
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.FirstOrCreate(&stats, LifetimeStats{ID: lifetimeStatsID}).Error; err != nil {
+			return err
+		}
+
+		stats.TotalFilesCompressed += files
+		stats.TotalDataSaved += dataSaved
+		return tx.Save(&stats).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
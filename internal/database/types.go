@@ -1,29 +1,158 @@
 package database
 
 import (
-	"encoding/json"
 	"time"
 )
 
-// UserPreferences database model
+// UserPreferences database model. Each preference has its own column;
+// PreferencesJSON only holds the blob written by older versions until it is migrated.
 type UserPreferences struct {
-	ID              uint      `gorm:"primaryKey" json:"id"`
-	PreferencesJSON string    `gorm:"type:text" json:"preferences_json"`
+	ID uint `gorm:"primaryKey" json:"id"`
+	UserPreferencesData
+	PreferencesJSON string    `gorm:"type:text" json:"-"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
+// CompressionCache maps an input hash and compression settings to a previous output
+type CompressionCache struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	InputHash    string    `gorm:"uniqueIndex:idx_cache_key" json:"input_hash"`
+	SettingsHash string    `gorm:"uniqueIndex:idx_cache_key" json:"settings_hash"`
+	OutputPath   string    `json:"output_path"`
+	OutputSize   int64     `json:"output_size"`
+	OutputHash   string    `json:"output_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BatchJob is a compression batch persisted so it can be resumed after a restart
+type BatchJob struct {
+	ID          string         `gorm:"primaryKey" json:"id"`
+	RequestJSON string         `gorm:"type:text" json:"request_json"`
+	Files       []BatchJobFile `gorm:"foreignKey:BatchID;constraint:OnDelete:CASCADE" json:"files"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// BatchJobFile tracks the state of a single file in a persisted batch
+type BatchJobFile struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BatchID   string    `gorm:"index" json:"batch_id"`
+	FilePath  string    `json:"file_path"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HistoryEntry records the outcome of compressing a single file
+type HistoryEntry struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	BatchID          string    `gorm:"index" json:"batch_id"`
+	OriginalFilename string    `json:"original_filename"`
+	OriginalPath     string    `json:"original_path"`
+	CompressedPath   string    `json:"compressed_path"`
+	OriginalSize     int64     `json:"original_size"`
+	CompressedSize   int64     `json:"compressed_size"`
+	CompressionRatio float64   `json:"compression_ratio"`
+	CompressionLevel string    `json:"compression_level"`
+	Status           string    `json:"status"`
+	Error            string    `json:"error,omitempty"`
+	DurationSeconds  float64   `json:"duration_seconds"`
+	ThroughputMBps   float64   `json:"throughput_mbps"`
+	OriginalHash     string    `json:"original_hash,omitempty"`
+	BackupPath       string    `json:"backup_path,omitempty"`
+	OptionsJSON      string    `gorm:"type:text" json:"options_json,omitempty"`
+	CreatedAt        time.Time `gorm:"index" json:"created_at"`
+}
+
+// Destination is a remote SFTP or WebDAV folder compressed files can be uploaded to
+type Destination struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	Name               string    `json:"name"`
+	Protocol           string    `json:"protocol"` // sftp or webdav
+	Host               string    `json:"host"`
+	Port               int       `json:"port"`
+	URL                string    `json:"url"`
+	Username           string    `json:"username"`
+	Password           string    `gorm:"-" json:"password,omitempty"` // Kept in the system keychain
+	KeyPath            string    `json:"key_path"`
+	HostKeyFingerprint string    `json:"host_key_fingerprint"`
+	RemoteDir          string    `json:"remote_dir"`
+	AllowInsecureHTTP  bool      `json:"allow_insecure_http"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// OutputFolder is a folder the user picked in a dialog. Automation URLs may
+// only write to these.
+type OutputFolder struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Path      string    `gorm:"uniqueIndex" json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HistoryFilter narrows a history search. Empty fields match everything; To is exclusive.
+type HistoryFilter struct {
+	Query  string    `json:"query"`
+	Level  string    `json:"level"`
+	Status string    `json:"status"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+// LifetimeStats holds the totals across all sessions in a single row
+type LifetimeStats struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	TotalFilesCompressed int64     `json:"total_files_compressed"`
+	TotalDataSaved       int64     `json:"total_data_saved"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
 // UserPreferencesData represents user preferences data
 type UserPreferencesData struct {
-	DefaultCompressionLevel string `json:"default_compression_level"`
-	ImageDPI                int    `json:"image_dpi"`
-	ImageQuality            int    `json:"image_quality"`
-	RemoveMetadata          bool   `json:"remove_metadata"`
-	EmbedFonts              bool   `json:"embed_fonts"`
-	GenerateThumbnails      bool   `json:"generate_thumbnails"`
-	ConvertToGrayscale      bool   `json:"convert_to_grayscale"`
-	PDFVersion              string `json:"pdf_version"`
-	AdvancedOptionsExpanded bool   `json:"advanced_options_expanded"`
+	DefaultCompressionLevel string  `json:"default_compression_level"`
+	ImageDPI                int     `json:"image_dpi"`
+	ImageQuality            int     `json:"image_quality"`
+	RemoveMetadata          bool    `json:"remove_metadata"`
+	EmbedFonts              bool    `json:"embed_fonts"`
+	GenerateThumbnails      bool    `json:"generate_thumbnails"`
+	ConvertToGrayscale      bool    `json:"convert_to_grayscale"`
+	PDFVersion              string  `json:"pdf_version"`
+	AdvancedOptionsExpanded bool    `json:"advanced_options_expanded"`
+	CompressionBackend      string  `json:"compression_backend"`
+	WorkingDir              string  `json:"working_dir"`
+	BackgroundPriority      bool    `json:"background_priority"`
+	MaxMemoryMB             int     `json:"max_memory_mb"`
+	DownsampleType          string  `json:"downsample_type"`
+	DownsampleThreshold     float64 `json:"downsample_threshold"`
+	MonoImageCodec          string  `json:"mono_image_codec"`
+	DeduplicateImages       bool    `json:"deduplicate_images"`
+	PreserveBookmarks       bool    `json:"preserve_bookmarks"`
+	ColorConversion         string  `json:"color_conversion"`
+	GhostscriptPath         string  `json:"ghostscript_path"`
+	LogLevel                string  `json:"log_level"`
+	CrashReportsEnabled     bool    `json:"crash_reports_enabled"`
+	CrashReportURL          string  `json:"crash_report_url"`
+	KeepOriginalBackups     bool    `json:"keep_original_backups"`
+	BackupRetentionDays     int     `json:"backup_retention_days"`
+	MenuBarMode             bool    `json:"menu_bar_mode"`
+	SMTPHost                string  `json:"smtp_host"`
+	SMTPPort                int     `json:"smtp_port"`
+	SMTPUsername            string  `json:"smtp_username"`
+	SMTPPassword            string  `gorm:"-" json:"smtp_password"` // Kept in the system keychain
+	SMTPFrom                string  `json:"smtp_from"`
+	SMTPSecurity            string  `json:"smtp_security"`
+	CollisionStrategy       string  `json:"collision_strategy"`
+	PreserveFileMetadata    bool    `json:"preserve_file_metadata"`
+	SkipSignedPDFs          bool    `json:"skip_signed_pdfs"`
+	SignOutput              bool    `json:"sign_output"`
+	SigningCertificatePath  string  `json:"signing_certificate_path"`
+	SigningKeychainIdentity string  `json:"signing_keychain_identity"`
+	SigningPassword         string  `gorm:"-" json:"signing_password"` // Kept in the system keychain
+	SigningReason           string  `json:"signing_reason"`
+	SecureDeleteTemp        bool    `json:"secure_delete_temp"`
+	MaxConcurrency          int     `json:"max_concurrency"`
+	EventStreamPort         int     `json:"event_stream_port"`
 }
 
 // DefaultPreferences returns default user preferences
@@ -38,30 +167,25 @@ func DefaultPreferences() UserPreferencesData {
 		ConvertToGrayscale:      false,
 		PDFVersion:              "1.4",
 		AdvancedOptionsExpanded: false,
+		DownsampleType:          "bicubic",
+		DownsampleThreshold:     1.5,
+		DeduplicateImages:       true,
+		PreserveBookmarks:       true,
+		ColorConversion:         "srgb",
+		LogLevel:                "info",
+		BackupRetentionDays:     30,
+		SMTPSecurity:            "starttls",
+		CollisionStrategy:       "rename",
+		PreserveFileMetadata:    true,
 	}
 }
 
 // GetPreferences returns the user preferences data
 func (up *UserPreferences) GetPreferences() UserPreferencesData {
-	if up.PreferencesJSON == "" {
-		return DefaultPreferences()
-	}
-
-	var prefs UserPreferencesData
-	if err := json.Unmarshal([]byte(up.PreferencesJSON), &prefs); err != nil {
-		return DefaultPreferences()
-	}
-
-	return prefs
+	return up.UserPreferencesData
 }
 
 // SetPreferences sets the user preferences data
-func (up *UserPreferences) SetPreferences(prefs UserPreferencesData) error {
-	data, err := json.Marshal(prefs)
-	if err != nil {
-		return err
-	}
-
-	up.PreferencesJSON = string(data)
-	return nil
-}
\ No newline at end of file
+func (up *UserPreferences) SetPreferences(prefs UserPreferencesData) {
+	up.UserPreferencesData = prefs
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	minImageDPI = 36
+	maxImageDPI = 1200
+)
+
+// PreferenceError reports a preference value that was rejected
+type PreferenceError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e *PreferenceError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// invalidPreference builds the coded error returned for a rejected value
+func invalidPreference(field, reason string) error {
+	return common.WrapError(common.ErrInvalidRequest, "invalid preference", &PreferenceError{Field: field, Reason: reason})
+}
+
+// validatePreferences checks the preferences named by the keys of an update.
+// Values that were already stored are left alone so an old invalid value
+// cannot block unrelated changes. validLevel checks compression level names.
+func validatePreferences(prefs *UserPreferencesData, data map[string]interface{}, validLevel func(string) bool) error {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := checkPreferenceType(key, data[key]); err != nil {
+			return err
+		}
+		if err := validatePreference(prefs, key, validLevel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPreferenceType rejects a value whose JSON type does not match the
+// preference field, which would otherwise be dropped without notice
+func checkPreferenceType(key string, value interface{}) error {
+	field, ok := preferenceFields[key]
+	if !ok {
+		return nil
+	}
+
+	var valid bool
+	var expected string
+	switch field.Type.Kind() {
+	case reflect.Bool:
+		_, valid = value.(bool)
+		expected = "boolean"
+	case reflect.String:
+		_, valid = value.(string)
+		expected = "string"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		_, valid = value.(float64)
+		expected = "number"
+	default:
+		valid = true
+	}
+
+	if !valid {
+		return invalidPreference(key, fmt.Sprintf("expected a %s, got %T", expected, value))
+	}
+	return nil
+}
+
+// preferenceFields maps JSON preference keys to their struct fields
+var preferenceFields = func() map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	t := reflect.TypeOf(UserPreferencesData{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fields[name] = field
+	}
+	return fields
+}()
+
+// validatePreference checks a single preference value
+func validatePreference(prefs *UserPreferencesData, key string, validLevel func(string) bool) error {
+	switch key {
+	case "default_compression_level":
+		level := prefs.DefaultCompressionLevel
+		if level == "" || (validLevel != nil && !validLevel(level)) {
+			return invalidPreference(key, fmt.Sprintf("unknown compression level %q", prefs.DefaultCompressionLevel))
+		}
+	case "image_dpi":
+		if prefs.ImageDPI < minImageDPI || prefs.ImageDPI > maxImageDPI {
+			return invalidPreference(key, fmt.Sprintf("must be between %d and %d", minImageDPI, maxImageDPI))
+		}
+	case "image_quality":
+		if prefs.ImageQuality < 1 || prefs.ImageQuality > 100 {
+			return invalidPreference(key, "must be between 1 and 100")
+		}
+	case "pdf_version":
+		switch prefs.PDFVersion {
+		case "1.3", "1.4", "1.5", "1.6", "1.7", "2.0":
+		default:
+			return invalidPreference(key, fmt.Sprintf("unsupported PDF version %q", prefs.PDFVersion))
+		}
+	case "compression_backend":
+		// The backend names of the compression package; empty picks automatically
+		switch prefs.CompressionBackend {
+		case "", "ghostscript", "pdfcpu", "qpdf":
+		default:
+			return invalidPreference(key, fmt.Sprintf("unknown compression backend %q", prefs.CompressionBackend))
+		}
+	case "downsample_type":
+		switch prefs.DownsampleType {
+		case "", "bicubic", "average", "subsample":
+		default:
+			return invalidPreference(key, fmt.Sprintf("unknown downsample type %q", prefs.DownsampleType))
+		}
+	case "downsample_threshold":
+		if prefs.DownsampleThreshold != 0 && prefs.DownsampleThreshold < 1 {
+			return invalidPreference(key, "must be 0 or at least 1")
+		}
+	case "mono_image_codec":
+		switch prefs.MonoImageCodec {
+		case "", "ccitt", "flate":
+		default:
+			return invalidPreference(key, fmt.Sprintf("unsupported mono image codec %q", prefs.MonoImageCodec))
+		}
+	case "color_conversion":
+		switch prefs.ColorConversion {
+		case "", "srgb", "cmyk", "gray", "unchanged":
+		default:
+			return invalidPreference(key, fmt.Sprintf("unknown color conversion %q", prefs.ColorConversion))
+		}
+	case "log_level":
+		switch prefs.LogLevel {
+		case "", "debug", "info", "warn", "error":
+		default:
+			return invalidPreference(key, fmt.Sprintf("unknown log level %q", prefs.LogLevel))
+		}
+	case "max_memory_mb":
+		if prefs.MaxMemoryMB < 0 {
+			return invalidPreference(key, "must not be negative")
+		}
+	case "max_concurrency":
+		if prefs.MaxConcurrency < 0 || prefs.MaxConcurrency > common.MaxConcurrencyLimit {
+			return invalidPreference(key, fmt.Sprintf("must be between 0 and %d", common.MaxConcurrencyLimit))
+		}
+	case "event_stream_port":
+		if prefs.EventStreamPort != 0 && (prefs.EventStreamPort < 1024 || prefs.EventStreamPort > 65535) {
+			return invalidPreference(key, "must be 0 to turn the event stream off, or between 1024 and 65535")
+		}
+	case "backup_retention_days":
+		if prefs.BackupRetentionDays < 0 {
+			return invalidPreference(key, "must not be negative")
+		}
+	case "menu_bar_mode":
+		if prefs.MenuBarMode && runtime.GOOS != "darwin" {
+			return invalidPreference(key, "menu bar mode is only available on macOS")
+		}
+	case "smtp_port":
+		if prefs.SMTPPort < 0 || prefs.SMTPPort > 65535 {
+			return invalidPreference(key, "must be between 0 and 65535")
+		}
+	case "smtp_security":
+		switch prefs.SMTPSecurity {
+		case "", "starttls", "tls", "none":
+		default:
+			return invalidPreference(key, fmt.Sprintf("unknown SMTP security %q", prefs.SMTPSecurity))
+		}
+	case "collision_strategy":
+		switch prefs.CollisionStrategy {
+		case "", "rename", "overwrite", "skip":
+		default:
+			return invalidPreference(key, fmt.Sprintf("unknown collision strategy %q", prefs.CollisionStrategy))
+		}
+	case "sign_output":
+		if prefs.SignOutput && prefs.SigningCertificatePath == "" && prefs.SigningKeychainIdentity == "" {
+			return invalidPreference(key, "choose a certificate file or keychain identity first")
+		}
+	case "signing_certificate_path":
+		if prefs.SigningCertificatePath != "" {
+			if info, err := os.Stat(prefs.SigningCertificatePath); err != nil || info.IsDir() {
+				return invalidPreference(key, fmt.Sprintf("certificate %q does not exist", prefs.SigningCertificatePath))
+			}
+		}
+	case "signing_keychain_identity":
+		if prefs.SigningKeychainIdentity != "" && runtime.GOOS != "darwin" {
+			return invalidPreference(key, "keychain identities are only available on macOS")
+		}
+	case "smtp_from":
+		if prefs.SMTPFrom == "" {
+			return nil
+		}
+		if _, err := mail.ParseAddress(prefs.SMTPFrom); err != nil {
+			return invalidPreference(key, fmt.Sprintf("invalid email address %q", prefs.SMTPFrom))
+		}
+	case "working_dir":
+		if prefs.WorkingDir == "" {
+			return nil
+		}
+		info, err := os.Stat(prefs.WorkingDir)
+		if err != nil || !info.IsDir() {
+			return invalidPreference(key, fmt.Sprintf("folder %q does not exist", prefs.WorkingDir))
+		}
+	case "crash_report_url":
+		if prefs.CrashReportURL == "" {
+			return nil
+		}
+		parsed, err := url.Parse(prefs.CrashReportURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return invalidPreference(key, "must be an http or https URL")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	// ProtocolSFTP uploads over SSH
+	ProtocolSFTP = "sftp"
+	// ProtocolWebDAV uploads with HTTP PUT
+	ProtocolWebDAV = "webdav"
+)
+
+// Config describes a remote folder compressed files are uploaded to
+type Config struct {
+	Protocol string
+	// Host and Port address an SFTP server; Port defaults to 22
+	Host string
+	Port int
+	// URL is the WebDAV server root
+	URL      string
+	Username string
+	// Password authenticates the user, or unlocks KeyPath for SFTP
+	Password string
+	// KeyPath is an SSH private key used instead of a password
+	KeyPath string
+	// HostKeyFingerprint pins the SFTP server key, as printed by
+	// ssh-keygen -lf (SHA256:...). Without it ~/.ssh/known_hosts is used.
+	HostKeyFingerprint string
+	// RemoteDir is the folder files are written to, created if missing
+	RemoteDir string
+	// AllowInsecureHTTP permits a plain http WebDAV URL, which sends the
+	// password unencrypted
+	AllowInsecureHTTP bool
+}
+
+// Uploader writes files to a remote folder
+type Uploader interface {
+	// Upload copies localPath into the remote folder as name and returns the remote location
+	Upload(ctx context.Context, localPath, name string) (string, error)
+	Close() error
+}
+
+// Validate checks that the config has what its protocol needs
+func (c Config) Validate() error {
+	switch c.Protocol {
+	case ProtocolSFTP:
+		if c.Host == "" {
+			return common.NewError(common.ErrInvalidRequest, "SFTP destinations need a host")
+		}
+		if c.Username == "" {
+			return common.NewError(common.ErrInvalidRequest, "SFTP destinations need a username")
+		}
+		if c.Port < 0 || c.Port > 65535 {
+			return common.NewError(common.ErrInvalidRequest, fmt.Sprintf("invalid port %d", c.Port))
+		}
+	case ProtocolWebDAV:
+		parsed, err := url.Parse(c.URL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return common.NewError(common.ErrInvalidRequest, "WebDAV destinations need an http or https URL")
+		}
+		if parsed.Scheme == "http" && !c.AllowInsecureHTTP {
+			return common.NewError(common.ErrInvalidRequest, "http WebDAV URLs send the password unencrypted; use https or allow insecure http for this destination")
+		}
+	default:
+		return common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown destination protocol %q", c.Protocol))
+	}
+	return nil
+}
+
+// Open connects to a destination
+func Open(ctx context.Context, config Config) (Uploader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	switch config.Protocol {
+	case ProtocolSFTP:
+		return openSFTP(ctx, config)
+	default:
+		return openWebDAV(ctx, config)
+	}
+}
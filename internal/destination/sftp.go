@@ -0,0 +1,177 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpDialTimeout bounds connecting and the SSH handshake
+const sftpDialTimeout = 30 * time.Second
+
+// sftpUploader uploads over a single SSH connection
+type sftpUploader struct {
+	ssh       *ssh.Client
+	client    *sftp.Client
+	remoteDir string
+}
+
+// openSFTP connects and authenticates to an SFTP server
+func openSFTP(ctx context.Context, config Config) (*sftpUploader, error) {
+	auth, err := sftpAuth(config)
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := sftpHostKeyCallback(config)
+	if err != nil {
+		return nil, err
+	}
+
+	port := config.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(port))
+
+	dialCtx, cancel := context.WithTimeout(ctx, sftpDialTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(dialCtx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	// Bound the handshake too; the deadline is cleared once it succeeds
+	if deadline, ok := dialCtx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            config.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SSH handshake with %s failed: %w", addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("failed to start SFTP on %s: %w", addr, err)
+	}
+
+	remoteDir := config.RemoteDir
+	if remoteDir == "" {
+		remoteDir = "."
+	}
+	if err := client.MkdirAll(remoteDir); err != nil {
+		client.Close()
+		sshClient.Close()
+		return nil, fmt.Errorf("failed to create remote folder %s: %w", remoteDir, err)
+	}
+
+	return &sftpUploader{ssh: sshClient, client: client, remoteDir: remoteDir}, nil
+}
+
+// sftpAuth uses the private key when one is set, otherwise the password
+func sftpAuth(config Config) ([]ssh.AuthMethod, error) {
+	if config.KeyPath == "" {
+		return []ssh.AuthMethod{ssh.Password(config.Password)}, nil
+	}
+
+	key, err := os.ReadFile(config.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if _, encrypted := err.(*ssh.PassphraseMissingError); encrypted {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(config.Password))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH key: %w", err)
+	}
+	return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+}
+
+// sftpHostKeyCallback checks the server key against the pinned fingerprint,
+// or the user's known_hosts file when none is pinned
+func sftpHostKeyCallback(config Config) (ssh.HostKeyCallback, error) {
+	if config.HostKeyFingerprint != "" {
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if fingerprint := ssh.FingerprintSHA256(key); fingerprint != config.HostKeyFingerprint {
+				return fmt.Errorf("host key %s does not match the pinned fingerprint", fingerprint)
+			}
+			return nil
+		}, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	callback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("no host key fingerprint is set and known_hosts could not be read: %w", err)
+	}
+	return callback, nil
+}
+
+// Upload writes to a temporary name and renames it into place, so readers of
+// the remote folder never see a partial file
+func (u *sftpUploader) Upload(ctx context.Context, localPath, name string) (string, error) {
+	local, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer local.Close()
+
+	remotePath := path.Join(u.remoteDir, name)
+	partPath := remotePath + ".part"
+	remote, err := u.client.Create(partPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", partPath, err)
+	}
+
+	// Closing the connection is the only way to interrupt a transfer
+	stop := context.AfterFunc(ctx, func() { u.ssh.Close() })
+	_, err = io.Copy(remote, local)
+	if closeErr := remote.Close(); err == nil {
+		err = closeErr
+	}
+	stop()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil {
+		u.client.Remove(partPath)
+		return "", fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+
+	if err := u.client.PosixRename(partPath, remotePath); err != nil {
+		// Servers without the posix-rename extension refuse to replace files
+		u.client.Remove(remotePath)
+		if err := u.client.Rename(partPath, remotePath); err != nil {
+			u.client.Remove(partPath)
+			return "", fmt.Errorf("failed to rename %s: %w", partPath, err)
+		}
+	}
+	return remotePath, nil
+}
+
+func (u *sftpUploader) Close() error {
+	u.client.Close()
+	return u.ssh.Close()
+}
@@ -0,0 +1,113 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// webdavUploader uploads with HTTP PUT, creating folders with MKCOL
+type webdavUploader struct {
+	client *http.Client
+	config Config
+	base   *url.URL
+}
+
+// openWebDAV checks the server is reachable and creates the remote folder
+func openWebDAV(ctx context.Context, config Config) (*webdavUploader, error) {
+	base, err := url.Parse(strings.TrimSuffix(config.URL, "/") + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	u := &webdavUploader{
+		client: &http.Client{},
+		config: config,
+		base:   base,
+	}
+	if err := u.mkdirAll(ctx, config.RemoteDir); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// resolve builds the URL of a path under the server root
+func (u *webdavUploader) resolve(elem ...string) *url.URL {
+	return u.base.JoinPath(elem...)
+}
+
+// do sends an authenticated request
+func (u *webdavUploader) do(req *http.Request) (*http.Response, error) {
+	if u.config.Username != "" || u.config.Password != "" {
+		req.SetBasicAuth(u.config.Username, u.config.Password)
+	}
+	return u.client.Do(req)
+}
+
+// mkdirAll creates each folder of dir in turn. Existing folders answer 405.
+func (u *webdavUploader) mkdirAll(ctx context.Context, dir string) error {
+	var created []string
+	for _, part := range strings.Split(dir, "/") {
+		if part == "" {
+			continue
+		}
+		created = append(created, part)
+		// Collections are addressed with a trailing slash
+		target := u.resolve(strings.Join(created, "/") + "/")
+
+		req, err := http.NewRequestWithContext(ctx, "MKCOL", target.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := u.do(req)
+		if err != nil {
+			return fmt.Errorf("failed to reach WebDAV server: %w", err)
+		}
+		resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusCreated, http.StatusMethodNotAllowed:
+		default:
+			return fmt.Errorf("failed to create remote folder %s: %s", strings.Join(created, "/"), resp.Status)
+		}
+	}
+	return nil
+}
+
+func (u *webdavUploader) Upload(ctx context.Context, localPath, name string) (string, error) {
+	local, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer local.Close()
+	info, err := local.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	target := u.resolve(u.config.RemoteDir, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), local)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/pdf")
+
+	resp, err := u.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to upload %s: %s", name, resp.Status)
+	}
+	return target.String(), nil
+}
+
+func (u *webdavUploader) Close() error {
+	u.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,234 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"kleinpdf/internal/common"
+)
+
+const (
+	// SecuritySTARTTLS upgrades a plain connection, usually on port 587
+	SecuritySTARTTLS = "starttls"
+	// SecurityTLS connects with implicit TLS, usually on port 465
+	SecurityTLS = "tls"
+	// SecurityNone sends in the clear; only for relays on the local machine
+	SecurityNone = "none"
+
+	// sendTimeout bounds the whole SMTP conversation
+	sendTimeout = 2 * time.Minute
+)
+
+// Config holds the SMTP server settings from the preferences
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Security string
+}
+
+// Message is an email with PDF attachments
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []string
+}
+
+// Validate checks that the server settings are complete
+func (c Config) Validate() error {
+	if c.Host == "" {
+		return common.NewError(common.ErrInvalidRequest, "no SMTP server is configured")
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return common.NewError(common.ErrInvalidRequest, fmt.Sprintf("invalid sender address %q", c.From))
+	}
+	switch c.Security {
+	case "", SecuritySTARTTLS, SecurityTLS, SecurityNone:
+	default:
+		return common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown SMTP security %q", c.Security))
+	}
+	return nil
+}
+
+// Send delivers a message through the SMTP server
+func Send(ctx context.Context, config Config, message Message) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	from, _ := mail.ParseAddress(config.From)
+	if len(message.To) == 0 {
+		return common.NewError(common.ErrInvalidRequest, "no recipients given")
+	}
+	var recipients []string
+	for _, to := range message.To {
+		address, err := mail.ParseAddress(to)
+		if err != nil {
+			return common.NewError(common.ErrInvalidRequest, fmt.Sprintf("invalid recipient %q", to))
+		}
+		recipients = append(recipients, address.Address)
+	}
+
+	body, err := buildMessage(from, message)
+	if err != nil {
+		return err
+	}
+
+	client, err := dial(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", config.Username, config.Password, config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP server rejected the sender: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("SMTP server rejected %s: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(body); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected the message: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial connects to the server with the configured transport security
+func dial(ctx context.Context, config Config) (*smtp.Client, error) {
+	port := config.Port
+	if port == 0 {
+		switch config.Security {
+		case SecurityTLS:
+			port = 465
+		case SecurityNone:
+			port = 25
+		default:
+			port = 587
+		}
+	}
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(port))
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	tlsConfig := &tls.Config{ServerName: config.Host}
+	if config.Security == SecurityTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if config.Security == "" || config.Security == SecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("%s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// buildMessage encodes the message as multipart MIME with base64 attachments
+func buildMessage(from *mail.Address, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + strings.Join(message.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + writer.Boundary(),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	text, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(text, []byte(message.Body))
+
+	for _, path := range message.Attachments {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// FormatMediaType encodes non-ASCII filenames as RFC 2231 parameters
+		name := map[string]string{"filename": filepath.Base(path)}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType("application/pdf", map[string]string{"name": name["filename"]})},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", name)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(part, data)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes data as base64 in 76-character lines, as MIME requires
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
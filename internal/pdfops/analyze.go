@@ -0,0 +1,129 @@
+package pdfops
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"kleinpdf/internal/compression"
+)
+
+// Analysis describes the structure of a PDF
+type Analysis struct {
+	PageCount    int            `json:"page_count"`
+	PDFVersion   string         `json:"pdf_version"`
+	Encrypted    bool           `json:"encrypted"`
+	Fonts        []FontInfo     `json:"fonts"`
+	ImageCount   int            `json:"image_count"`
+	DPIHistogram map[string]int `json:"dpi_histogram"`
+	LikelyScan   bool           `json:"likely_scan"`
+}
+
+// FontInfo describes a font used by the document
+type FontInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Embedded bool   `json:"embedded"`
+}
+
+// dpiBuckets are the histogram buckets for effective image resolution
+var dpiBuckets = []struct {
+	label string
+	max   float64
+}{
+	{"<=72", 72},
+	{"73-150", 150},
+	{"151-300", 300},
+	{">300", 0},
+}
+
+// newPdfcpuConfiguration returns a pdfcpu configuration that never touches the user config dir
+func newPdfcpuConfiguration() *model.Configuration {
+	api.DisableConfigDir()
+	return model.NewDefaultConfiguration()
+}
+
+// Analyze inspects a PDF. Encrypted documents only report their encryption status.
+func (p *Processor) Analyze(inputPath string) (*Analysis, error) {
+	encrypted, err := compression.IsEncrypted(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return &Analysis{Encrypted: true, DPIHistogram: map[string]int{}}, nil
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := api.PDFInfo(file, inputPath, nil, true, newPdfcpuConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	analysis := &Analysis{
+		PageCount:    info.PageCount,
+		PDFVersion:   info.Version,
+		Encrypted:    info.Encrypted,
+		DPIHistogram: make(map[string]int, len(dpiBuckets)),
+	}
+	for _, font := range info.Fonts {
+		analysis.Fonts = append(analysis.Fonts, FontInfo{
+			Name:     font.Name,
+			Type:     font.Type,
+			Embedded: font.Embedded,
+		})
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	pageImages, err := api.Images(file, nil, newPdfcpuConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read images: %v", err)
+	}
+
+	pagesWithImages := make(map[int]bool)
+	for _, images := range pageImages {
+		for _, image := range images {
+			if image.Thumb || image.IsImgMask {
+				continue
+			}
+			analysis.ImageCount++
+			pagesWithImages[image.PageNr] = true
+
+			if image.PageNr < 1 || image.PageNr > len(info.PageBoundaries) {
+				continue
+			}
+			mediaBox := info.PageBoundaries[image.PageNr-1].MediaBox()
+			if mediaBox == nil || mediaBox.Width() <= 0 {
+				continue
+			}
+			// Assume the image spans the page width, which holds for scans
+			dpi := float64(image.Width) / (mediaBox.Width() / 72)
+			analysis.DPIHistogram[dpiBucket(dpi)]++
+		}
+	}
+
+	// A scan has an image on (nearly) every page and little or no real text
+	analysis.LikelyScan = info.PageCount > 0 &&
+		len(info.Fonts) == 0 &&
+		float64(len(pagesWithImages)) >= 0.8*float64(info.PageCount)
+
+	return analysis, nil
+}
+
+// dpiBucket returns the histogram label for a resolution
+func dpiBucket(dpi float64) string {
+	for _, bucket := range dpiBuckets {
+		if bucket.max == 0 || dpi <= bucket.max {
+			return bucket.label
+		}
+	}
+	return dpiBuckets[len(dpiBuckets)-1].label
+}
@@ -0,0 +1,134 @@
+package pdfops
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// maxAttachmentRenames bounds the search for a free attachment file name
+const maxAttachmentRenames = 1000
+
+// ExtractedAttachment is one embedded file written by ExtractAttachments
+type ExtractedAttachment struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	Description string `json:"description,omitempty"`
+}
+
+// ExtractAttachments writes the files embedded in a PDF to outputDir and
+// returns what was written. Names are reduced to their base name so an
+// attachment cannot escape outputDir, and existing files are never replaced;
+// a counter is appended instead. A document without attachments returns an
+// empty list.
+func (p *Processor) ExtractAttachments(inputPath, outputDir string) ([]ExtractedAttachment, error) {
+	encrypted, err := compression.IsEncrypted(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, common.NewError(common.ErrEncryptedInput, "file is password protected")
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	conf.Cmd = model.EXTRACTATTACHMENTS
+	ctx, err := api.ReadAndValidate(file, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	extracted := []ExtractedAttachment{}
+	if ctx.Names["EmbeddedFiles"] == nil {
+		return extracted, nil
+	}
+
+	attachments, err := ctx.ExtractAttachments(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachments: %v", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+
+	for i, attachment := range attachments {
+		name := attachmentFileName(attachment, i+1)
+		path, size, err := writeAttachment(outputDir, name, attachment)
+		if err != nil {
+			for _, written := range extracted {
+				os.Remove(written.Path)
+			}
+			return nil, fmt.Errorf("failed to extract %s: %v", name, err)
+		}
+		extracted = append(extracted, ExtractedAttachment{
+			Name:        name,
+			Path:        path,
+			Size:        size,
+			Description: attachment.Desc,
+		})
+		p.logger.Debug("Extracted attachment", "file", inputPath, "attachment", name, "size", size)
+	}
+
+	return extracted, nil
+}
+
+// attachmentFileName returns a safe file name for an attachment, falling back
+// to its ID or position when the PDF gives no usable name
+func attachmentFileName(attachment model.Attachment, position int) string {
+	for _, name := range []string{attachment.FileName, attachment.ID} {
+		// Names may use either separator whatever the platform
+		name = name[strings.LastIndexAny(name, `/\`)+1:]
+		if name != "" && name != "." && name != ".." {
+			return name
+		}
+	}
+	return fmt.Sprintf("attachment_%d", position)
+}
+
+// writeAttachment copies an attachment to the first free name in dir and
+// returns the path and the number of bytes written
+func writeAttachment(dir, name string, data io.Reader) (string, int64, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	path := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			if i > maxAttachmentRenames {
+				return "", 0, fmt.Errorf("no free file name in %s", dir)
+			}
+			path = filepath.Join(dir, fmt.Sprintf("%s_%d%s", base, i, ext))
+			continue
+		}
+		if err != nil {
+			return "", 0, err
+		}
+
+		size, err := io.Copy(out, data)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", 0, err
+		}
+		return path, size, nil
+	}
+}
@@ -0,0 +1,123 @@
+package pdfops
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// FontReport lists the fonts of a document and how they are stored
+type FontReport struct {
+	Fonts         []FontDetail `json:"fonts"`
+	Embedded      int          `json:"embedded"`
+	NotEmbedded   int          `json:"not_embedded"`
+	Subsets       int          `json:"subsets"`
+	EmbeddedBytes int64        `json:"embedded_bytes"`
+}
+
+// FontDetail describes one font object. Fonts that are not embedded are
+// substituted by the viewer, so the document may render differently elsewhere.
+type FontDetail struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Encoding string `json:"encoding"`
+	Embedded bool   `json:"embedded"`
+	Subset   bool   `json:"subset"`
+	Size     int64  `json:"size"` // Bytes of the embedded font program
+}
+
+// ListFonts reports which fonts a PDF embeds in full, as a subset or not at all
+func (p *Processor) ListFonts(inputPath string) (*FontReport, error) {
+	encrypted, err := compression.IsEncrypted(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, common.NewError(common.ErrEncryptedInput, "file is password protected")
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	conf.Cmd = model.LISTINFO
+	ctx, err := api.ReadAndValidate(file, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %v", err)
+	}
+	// Optimizing collects the font objects and detects embedding
+	if err := api.OptimizeContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read fonts: %v", err)
+	}
+
+	var objNrs []int
+	for objNr := range ctx.Optimize.FontObjects {
+		objNrs = append(objNrs, objNr)
+	}
+	sort.Ints(objNrs)
+
+	report := &FontReport{Fonts: []FontDetail{}}
+	for _, objNr := range objNrs {
+		font := ctx.Optimize.FontObjects[objNr]
+		detail := FontDetail{
+			Name:     font.FontName,
+			Type:     font.SubType(),
+			Encoding: font.Encoding(),
+			Embedded: font.Embedded,
+			Subset:   font.Prefix != "", // Subsets carry a six-letter tag such as ABCDEF+
+		}
+		if detail.Embedded {
+			detail.Size = fontProgramSize(ctx, font.FontDict)
+			report.Embedded++
+			report.EmbeddedBytes += detail.Size
+		} else {
+			report.NotEmbedded++
+		}
+		if detail.Subset {
+			report.Subsets++
+		}
+		report.Fonts = append(report.Fonts, detail)
+	}
+
+	sort.SliceStable(report.Fonts, func(i, j int) bool {
+		return report.Fonts[i].Name < report.Fonts[j].Name
+	})
+	return report, nil
+}
+
+// fontProgramSize returns the stored size of the font file embedded for a
+// font dict, following Type0 fonts to their descendant font
+func fontProgramSize(ctx *model.Context, fontDict types.Dict) int64 {
+	if descendants, err := ctx.DereferenceArray(fontDict["DescendantFonts"]); err == nil && len(descendants) > 0 {
+		if descendant, err := ctx.DereferenceDict(descendants[0]); err == nil && descendant != nil {
+			fontDict = descendant
+		}
+	}
+
+	descriptor, err := ctx.DereferenceDict(fontDict["FontDescriptor"])
+	if err != nil || descriptor == nil {
+		return 0
+	}
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		obj, found := descriptor.Find(key)
+		if !found {
+			continue
+		}
+		stream, _, err := ctx.DereferenceStreamDict(obj)
+		if err != nil || stream == nil || stream.StreamLength == nil {
+			continue
+		}
+		return *stream.StreamLength
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package pdfops
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// QuickInfo is the basic information about a PDF shown next to each file
+type QuickInfo struct {
+	PageCount  int    `json:"page_count"`
+	PDFVersion string `json:"pdf_version"`
+	IsScanned  bool   `json:"is_scanned"`
+}
+
+// QuickInfo reads the page count, version and whether the document looks
+// like a scan. It only looks at page resources, without validating the file
+// or decoding any stream, so it is cheap enough to run on every input.
+func (p *Processor) QuickInfo(inputPath, password string) (*QuickInfo, error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	if password != "" {
+		conf.UserPW = password
+		conf.OwnerPW = password
+	}
+	ctx, err := api.ReadContext(file, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %v", err)
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("failed to read page count: %v", err)
+	}
+
+	info := &QuickInfo{
+		PageCount:  ctx.PageCount,
+		PDFVersion: ctx.VersionString(),
+	}
+
+	// Like Analyze, a scan has an image on (nearly) every page and no fonts
+	pagesWithImages := 0
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		page, _, inherited, err := ctx.PageDict(pageNr, false)
+		if err != nil || page == nil {
+			continue
+		}
+		resources, _ := ctx.DereferenceDict(page["Resources"])
+		if resources == nil && inherited != nil {
+			resources = inherited.Resources
+		}
+		fonts, images := resourceUsage(ctx, resources, map[int]bool{})
+		if fonts {
+			return info, nil
+		}
+		if images {
+			pagesWithImages++
+		}
+	}
+	info.IsScanned = ctx.PageCount > 0 && float64(pagesWithImages) >= 0.8*float64(ctx.PageCount)
+
+	return info, nil
+}
+
+// resourceUsage reports whether a resource dictionary, or a form XObject it
+// uses, declares fonts or images
+func resourceUsage(ctx *model.Context, resources types.Dict, visited map[int]bool) (fonts, images bool) {
+	if resources == nil {
+		return false, false
+	}
+	if font, _ := ctx.DereferenceDict(resources["Font"]); len(font) > 0 {
+		return true, false
+	}
+
+	xobjects, _ := ctx.DereferenceDict(resources["XObject"])
+	for _, obj := range xobjects {
+		ref, ok := obj.(types.IndirectRef)
+		if !ok || visited[ref.ObjectNumber.Value()] {
+			continue
+		}
+		visited[ref.ObjectNumber.Value()] = true
+
+		stream, _, err := ctx.DereferenceStreamDict(ref)
+		if err != nil || stream == nil {
+			continue
+		}
+		switch subtype := stream.Dict.Subtype(); {
+		case subtype == nil:
+		case *subtype == "Image":
+			images = true
+		case *subtype == "Form":
+			formResources, _ := ctx.DereferenceDict(stream.Dict["Resources"])
+			formFonts, formImages := resourceUsage(ctx, formResources, visited)
+			if formFonts {
+				return true, images
+			}
+			images = images || formImages
+		}
+	}
+	return false, images
+}
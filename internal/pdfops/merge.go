@@ -0,0 +1,265 @@
+package pdfops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// MergeOptions controls the navigation added to a merged document
+type MergeOptions struct {
+	// Outline adds a bookmark per source file, with the file's own
+	// bookmarks nested beneath it
+	Outline bool `json:"outline"`
+	// TOCPage inserts a contents page listing each source file and the
+	// page it starts on, linked to that page
+	TOCPage bool `json:"toc_page"`
+}
+
+// Layout of the contents page, in points on an A4 sheet
+const (
+	tocWidth      = 595.0
+	tocHeight     = 842.0
+	tocMargin     = 72.0
+	tocTitleSize  = 18
+	tocEntrySize  = 11
+	tocLineHeight = 18.0
+	tocFont       = "Helvetica"
+)
+
+// tocEntry is one source file listed on the contents page
+type tocEntry struct {
+	title string
+	page  int
+}
+
+// Merge concatenates inputs in order into outputPath and returns the page
+// count of the result, including any contents pages.
+func (p *Processor) Merge(ctx context.Context, inputs []string, outputPath string, options MergeOptions) (int, error) {
+	if len(inputs) < 2 {
+		return 0, common.NewError(common.ErrInvalidRequest, "at least two files are needed to merge")
+	}
+
+	entries := make([]tocEntry, 0, len(inputs))
+	next := 1
+	for _, input := range inputs {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		encrypted, err := compression.IsEncrypted(input)
+		if err != nil {
+			return 0, err
+		}
+		if encrypted {
+			return 0, common.NewError(common.ErrEncryptedInput, fmt.Sprintf("%s is password protected", filepath.Base(input)))
+		}
+		pageCount, err := api.PageCountFile(input)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read page count of %s: %v", filepath.Base(input), err)
+		}
+		entries = append(entries, tocEntry{title: strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)), page: next})
+		next += pageCount
+	}
+
+	conf := newPdfcpuConfiguration()
+	conf.CreateBookmarks = options.Outline
+
+	var merged bytes.Buffer
+	if err := api.Merge("", inputs, &merged, conf, false); err != nil {
+		return 0, fmt.Errorf("failed to merge PDFs: %v", err)
+	}
+
+	if !options.TOCPage {
+		if err := os.WriteFile(outputPath, merged.Bytes(), 0644); err != nil {
+			return 0, err
+		}
+		return next - 1, nil
+	}
+
+	pdf, err := api.ReadAndValidate(bytes.NewReader(merged.Bytes()), newPdfcpuConfiguration())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read merged PDF: %v", err)
+	}
+	tocPages, err := insertContentsPages(pdf, entries)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add contents page: %v", err)
+	}
+	if err := api.WriteContextFile(pdf, outputPath); err != nil {
+		os.Remove(outputPath)
+		return 0, fmt.Errorf("failed to write merged PDF: %v", err)
+	}
+
+	p.logger.Debug("Merged PDFs", "files", len(inputs), "contents_pages", tocPages, "output", outputPath)
+	return next - 1 + tocPages, nil
+}
+
+// insertContentsPages puts contents pages in front of the merged document and
+// returns how many were added. Each entry links to its first page, and the
+// listed numbers count the contents pages too.
+func insertContentsPages(ctx *model.Context, entries []tocEntry) (int, error) {
+	perPage := int(tocHeight-2*tocMargin)/int(tocLineHeight) - 2
+	pageCount := (len(entries) + perPage - 1) / perPage
+
+	// Resolve the targets before the page numbers shift
+	targets := make([]types.IndirectRef, len(entries))
+	for i, entry := range entries {
+		_, ref, _, err := ctx.PageDict(entry.page, false)
+		if err != nil || ref == nil {
+			return 0, fmt.Errorf("page %d not found", entry.page)
+		}
+		targets[i] = *ref
+	}
+
+	rootRef, err := ctx.Pages()
+	if err != nil {
+		return 0, err
+	}
+	root, err := ctx.DereferenceDict(*rootRef)
+	if err != nil || root == nil {
+		return 0, fmt.Errorf("page tree not found")
+	}
+
+	fontRef, err := ctx.IndRefForNewObject(types.Dict(map[string]types.Object{
+		"Type":     types.Name("Font"),
+		"Subtype":  types.Name("Type1"),
+		"BaseFont": types.Name(tocFont),
+		"Encoding": types.Name("WinAnsiEncoding"),
+	}))
+	if err != nil {
+		return 0, err
+	}
+
+	var kids types.Array
+	for n := 0; n < pageCount; n++ {
+		end := min((n+1)*perPage, len(entries))
+		page, err := contentsPage(ctx, *rootRef, *fontRef, entries[n*perPage:end], targets[n*perPage:end], pageCount, n == 0)
+		if err != nil {
+			return 0, err
+		}
+		kids = append(kids, *page)
+	}
+
+	existing, err := ctx.DereferenceArray(root["Kids"])
+	if err != nil {
+		return 0, err
+	}
+	root["Kids"] = append(kids, existing...)
+	if count := root.IntEntry("Count"); count != nil {
+		root["Count"] = types.Integer(*count + pageCount)
+	}
+	ctx.PageCount += pageCount
+
+	return pageCount, nil
+}
+
+// contentsPage builds one contents page listing entries with their page
+// numbers right-aligned, and a link over each line
+func contentsPage(ctx *model.Context, parent, fontRef types.IndirectRef, entries []tocEntry, targets []types.IndirectRef, offset int, heading bool) (*types.IndirectRef, error) {
+	var content strings.Builder
+	var annots types.Array
+	right := tocWidth - tocMargin
+	y := tocHeight - tocMargin
+
+	if heading {
+		fmt.Fprintf(&content, "BT /F1 %d Tf %.2f %.2f Td (Contents) Tj ET\n", tocTitleSize, tocMargin, y-tocTitleSize)
+	}
+	y -= 2 * tocLineHeight
+
+	for i, entry := range entries {
+		number := fmt.Sprintf("%d", entry.page+offset)
+		numberWidth := font.TextWidth(number, tocFont, tocEntrySize)
+		title := fitText(winAnsiText(entry.title), right-tocMargin-numberWidth-tocLineHeight)
+
+		fmt.Fprintf(&content, "BT /F1 %d Tf %.2f %.2f Td (%s) Tj ET\n", tocEntrySize, tocMargin, y, escapePDFString(title))
+		fmt.Fprintf(&content, "BT /F1 %d Tf %.2f %.2f Td (%s) Tj ET\n", tocEntrySize, right-numberWidth, y, number)
+
+		link, err := ctx.IndRefForNewObject(types.Dict(map[string]types.Object{
+			"Type":    types.Name("Annot"),
+			"Subtype": types.Name("Link"),
+			"Rect":    types.NewNumberArray(tocMargin, y-4, right, y+tocEntrySize),
+			"Border":  types.NewIntegerArray(0, 0, 0),
+			"Dest":    types.Array{targets[i], types.Name("Fit")},
+		}))
+		if err != nil {
+			return nil, err
+		}
+		annots = append(annots, *link)
+		y -= tocLineHeight
+	}
+
+	contents, err := ctx.StreamDictIndRef([]byte(content.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	page := types.Dict(map[string]types.Object{
+		"Type":     types.Name("Page"),
+		"Parent":   parent,
+		"MediaBox": types.NewNumberArray(0, 0, tocWidth, tocHeight),
+		"CropBox":  types.NewNumberArray(0, 0, tocWidth, tocHeight),
+		"Rotate":   types.Integer(0),
+		"Resources": types.Dict(map[string]types.Object{
+			"Font": types.Dict(map[string]types.Object{"F1": fontRef}),
+		}),
+		"Contents": *contents,
+	})
+	if len(annots) > 0 {
+		page["Annots"] = annots
+	}
+	return ctx.IndRefForNewObject(page)
+}
+
+// fitText shortens text with an ellipsis until it fits in width
+func fitText(text string, width float64) string {
+	if font.TextWidth(text, tocFont, tocEntrySize) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		shortened := string(runes) + "..."
+		if font.TextWidth(shortened, tocFont, tocEntrySize) <= width {
+			return shortened
+		}
+	}
+	return ""
+}
+
+// winAnsiText replaces characters the standard Helvetica encoding cannot show
+func winAnsiText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0xFF {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+// escapePDFString escapes a value for a PDF literal string, writing Latin-1
+// characters as octal codes
+func escapePDFString(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r > 0x7E:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
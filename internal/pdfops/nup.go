@@ -0,0 +1,16 @@
+package pdfops
+
+import (
+	"context"
+
+	"kleinpdf/internal/compression"
+)
+
+// NUp places n pages on each sheet, e.g. for handouts
+func (p *Processor) NUp(ctx context.Context, inputPath, outputPath string, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return compression.NUpFile(inputPath, outputPath, n, "")
+}
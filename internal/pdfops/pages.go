@@ -0,0 +1,115 @@
+package pdfops
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var pageRangeSyntax = regexp.MustCompile(`^(\d+)(-(\d*))?$`)
+
+// ValidatePageRange checks a page range such as "3", "1-5" or "6-" (to the last page)
+func ValidatePageRange(pageRange string) error {
+	m := pageRangeSyntax.FindStringSubmatch(pageRange)
+	if m == nil {
+		return fmt.Errorf("invalid page range %q", pageRange)
+	}
+
+	first, _ := strconv.Atoi(m[1])
+	if first < 1 {
+		return fmt.Errorf("invalid page range %q: pages start at 1", pageRange)
+	}
+
+	if m[3] != "" {
+		last, _ := strconv.Atoi(m[3])
+		if last < first {
+			return fmt.Errorf("invalid page range %q: end before start", pageRange)
+		}
+	}
+
+	return nil
+}
+
+// PageList converts page numbers into a Ghostscript page list such as "1,3,5"
+func PageList(pages []int) (string, error) {
+	if len(pages) == 0 {
+		return "", fmt.Errorf("no pages provided")
+	}
+
+	parts := make([]string, len(pages))
+	for i, page := range pages {
+		if page < 1 {
+			return "", fmt.Errorf("invalid page number %d: pages start at 1", page)
+		}
+		parts[i] = strconv.Itoa(page)
+	}
+
+	return strings.Join(parts, ","), nil
+}
+
+// RemainingPages returns the pages of a document with the given pages removed
+func RemainingPages(totalPages int, deleted []int) ([]int, error) {
+	if len(deleted) == 0 {
+		return nil, fmt.Errorf("no pages provided")
+	}
+
+	remove := make(map[int]bool, len(deleted))
+	for _, page := range deleted {
+		if page < 1 || page > totalPages {
+			return nil, fmt.Errorf("invalid page number %d: document has %d pages", page, totalPages)
+		}
+		remove[page] = true
+	}
+
+	var remaining []int
+	for page := 1; page <= totalPages; page++ {
+		if !remove[page] {
+			remaining = append(remaining, page)
+		}
+	}
+
+	if len(remaining) == 0 {
+		return nil, fmt.Errorf("cannot delete every page of the document")
+	}
+
+	return remaining, nil
+}
+
+// ValidatePageOrder checks that an ordering lists every page of the document exactly once
+func ValidatePageOrder(totalPages int, order []int) error {
+	if len(order) != totalPages {
+		return fmt.Errorf("page order lists %d pages, document has %d", len(order), totalPages)
+	}
+
+	seen := make(map[int]bool, len(order))
+	for _, page := range order {
+		if page < 1 || page > totalPages {
+			return fmt.Errorf("invalid page number %d: document has %d pages", page, totalPages)
+		}
+		if seen[page] {
+			return fmt.Errorf("page %d listed more than once", page)
+		}
+		seen[page] = true
+	}
+
+	return nil
+}
+
+// ExtractPages writes the pages selected by a Ghostscript page list (e.g. "1-5,8,10-") to a new PDF.
+// Pages are written in list order, so the same call also reorders pages.
+func (p *Processor) ExtractPages(ctx context.Context, inputPath, outputPath, pageList string) error {
+	args := []string{
+		"-sDEVICE=pdfwrite",
+		"-dNOPAUSE",
+		"-dQUIET",
+		"-dBATCH",
+		"-dAutoRotatePages=/None",
+		"-sPageList=" + pageList,
+		"-sOutputFile=" + outputPath,
+		inputPath,
+	}
+
+	return p.runGhostscript(ctx, args, outputPath)
+}
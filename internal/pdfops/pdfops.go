@@ -0,0 +1,119 @@
+package pdfops
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"kleinpdf/internal/common"
+)
+
+// Processor handles page-level PDF operations such as split and extract
+type Processor struct {
+	logger *slog.Logger
+
+	settingsMu      sync.RWMutex
+	ghostscriptPath string
+	limits          common.ResourceLimits
+}
+
+// NewProcessor creates a new page operations processor
+func NewProcessor(ghostscriptPath string, logger *slog.Logger) *Processor {
+	return &Processor{
+		ghostscriptPath: ghostscriptPath,
+		logger:          logger,
+	}
+}
+
+// SetResourceLimits sets the priority and memory limits for Ghostscript
+func (p *Processor) SetResourceLimits(limits common.ResourceLimits) {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	p.limits = limits
+}
+
+// SetGhostscriptPath switches to another Ghostscript executable
+func (p *Processor) SetGhostscriptPath(path string) {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	p.ghostscriptPath = path
+}
+
+// ghostscript returns the active Ghostscript executable
+func (p *Processor) ghostscript() string {
+	p.settingsMu.RLock()
+	defer p.settingsMu.RUnlock()
+	return p.ghostscriptPath
+}
+
+// resourceLimits returns the limits applied to Ghostscript
+func (p *Processor) resourceLimits() common.ResourceLimits {
+	p.settingsMu.RLock()
+	defer p.settingsMu.RUnlock()
+	return p.limits
+}
+
+// runGhostscript executes Ghostscript with the given arguments and verifies the output file
+func (p *Processor) runGhostscript(ctx context.Context, args []string, outputPath string) error {
+	ghostscriptPath := p.ghostscript()
+	if ghostscriptPath == "" {
+		return common.NewError(common.ErrGhostscriptMissing, "ghostscript not found. Please install ghostscript to use this application")
+	}
+
+	cmd := common.GhostscriptCommand(ctx, ghostscriptPath, args...)
+	output, err := common.RunCommand(cmd, p.resourceLimits())
+	if err != nil {
+		if ctx.Err() != nil {
+			os.Remove(outputPath)
+			return ctx.Err()
+		}
+		return common.NewError(common.ErrGhostscriptCrash, fmt.Sprintf("ghostscript failed: %v, output: %s", err, string(output)))
+	}
+
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		return fmt.Errorf("ghostscript did not create output file")
+	}
+
+	return nil
+}
+
+// PageCount returns the number of pages in a PDF
+func (p *Processor) PageCount(ctx context.Context, inputPath string) (int, error) {
+	ghostscriptPath := p.ghostscript()
+	if ghostscriptPath == "" {
+		return 0, common.NewError(common.ErrGhostscriptMissing, "ghostscript not found. Please install ghostscript to use this application")
+	}
+
+	script := fmt.Sprintf("(%s) (r) file runpdfbegin pdfpagecount = quit", escapePostScriptString(inputPath))
+	args := []string{
+		"-q",
+		"-dNODISPLAY",
+		"-dNOPAUSE",
+		"-dBATCH",
+		"--permit-file-read=" + inputPath,
+		"-c", script,
+	}
+
+	cmd := common.GhostscriptCommand(ctx, ghostscriptPath, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read page count: %v", err)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected page count output: %q", string(output))
+	}
+
+	return count, nil
+}
+
+// escapePostScriptString escapes a value for use inside a PostScript string literal
+func escapePostScriptString(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return replacer.Replace(value)
+}
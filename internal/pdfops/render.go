@@ -0,0 +1,29 @@
+package pdfops
+
+import (
+	"context"
+	"fmt"
+)
+
+// RenderPage renders a single page to a PNG at the given resolution
+func (p *Processor) RenderPage(ctx context.Context, inputPath, outputPath string, page, dpi int) error {
+	if page < 1 {
+		return fmt.Errorf("invalid page number %d: pages start at 1", page)
+	}
+
+	args := []string{
+		"-sDEVICE=png16m",
+		"-dNOPAUSE",
+		"-dQUIET",
+		"-dBATCH",
+		"-dTextAlphaBits=4",
+		"-dGraphicsAlphaBits=4",
+		fmt.Sprintf("-r%d", dpi),
+		fmt.Sprintf("-dFirstPage=%d", page),
+		fmt.Sprintf("-dLastPage=%d", page),
+		"-sOutputFile=" + outputPath,
+		inputPath,
+	}
+
+	return p.runGhostscript(ctx, args, outputPath)
+}
@@ -0,0 +1,105 @@
+package pdfops
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"kleinpdf/internal/common"
+)
+
+// RepairReport describes how a document was repaired
+type RepairReport struct {
+	Method string   `json:"method"`
+	Issues []string `json:"issues"`
+}
+
+// Repair rewrites a damaged or non-conformant PDF. Ghostscript is tried first
+// since it recovers the most; pdfcpu's relaxed reader is the fallback.
+func (p *Processor) Repair(ctx context.Context, inputPath, outputPath string) (*RepairReport, error) {
+	report, gsErr := p.repairWithGhostscript(ctx, inputPath, outputPath)
+	if gsErr == nil {
+		return report, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	p.logger.Warn("Ghostscript repair failed, trying pdfcpu", "file", inputPath, "error", gsErr)
+
+	conf := newPdfcpuConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	if err := api.OptimizeFile(inputPath, outputPath, conf); err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("could not repair PDF: %v; pdfcpu: %v", gsErr, err)
+	}
+
+	return &RepairReport{
+		Method: "pdfcpu",
+		Issues: []string{"Rebuilt document structure after Ghostscript could not read it"},
+	}, nil
+}
+
+// repairWithGhostscript rewrites the document through pdfwrite and collects
+// the errors and warnings Ghostscript reports while recovering it
+func (p *Processor) repairWithGhostscript(ctx context.Context, inputPath, outputPath string) (*RepairReport, error) {
+	ghostscriptPath := p.ghostscript()
+	if ghostscriptPath == "" {
+		return nil, common.NewError(common.ErrGhostscriptMissing, "ghostscript not found. Please install ghostscript to use this application")
+	}
+
+	args := []string{
+		"-sDEVICE=pdfwrite",
+		"-dNOPAUSE",
+		"-dBATCH",
+		"-dAutoRotatePages=/None",
+		"-sOutputFile=" + outputPath,
+		inputPath,
+	}
+
+	cmd := common.GhostscriptCommand(ctx, ghostscriptPath, args...)
+	output, err := common.RunCommand(cmd, p.resourceLimits())
+	if err != nil {
+		os.Remove(outputPath)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, common.NewError(common.ErrGhostscriptCrash, fmt.Sprintf("ghostscript failed: %v, output: %s", err, string(output)))
+	}
+
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("ghostscript did not create output file")
+	}
+
+	return &RepairReport{
+		Method: "ghostscript",
+		Issues: ghostscriptIssues(output),
+	}, nil
+}
+
+// ghostscriptIssues extracts the distinct "**** Error" and "**** Warning" messages from Ghostscript output
+func ghostscriptIssues(output []byte) []string {
+	issues := []string{}
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "**** Error") && !strings.HasPrefix(line, "**** Warning") {
+			continue
+		}
+		issue := strings.TrimSpace(strings.TrimPrefix(line, "****"))
+		if !seen[issue] {
+			seen[issue] = true
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,28 @@
+package pdfops
+
+import (
+	"context"
+
+	"kleinpdf/internal/compression"
+)
+
+// ResizePages fits every page onto the target media (A4, Letter, ...) or scales
+// pages by a percentage such as "50%"
+func (p *Processor) ResizePages(ctx context.Context, inputPath, outputPath, target string) error {
+	resizeArgs, err := compression.ResizeArgs(inputPath, target, "")
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"-sDEVICE=pdfwrite",
+		"-dNOPAUSE",
+		"-dQUIET",
+		"-dBATCH",
+		"-dAutoRotatePages=/None",
+	}
+	args = append(args, resizeArgs...)
+	args = append(args, "-sOutputFile="+outputPath, inputPath)
+
+	return p.runGhostscript(ctx, args, outputPath)
+}
@@ -0,0 +1,230 @@
+package pdfops
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// SizeBreakdown attributes the bytes of a PDF to the kinds of object holding them
+type SizeBreakdown struct {
+	FileSize    int64        `json:"file_size"`
+	Images      SizeCategory `json:"images"`
+	Fonts       SizeCategory `json:"fonts"`
+	Content     SizeCategory `json:"content"`
+	Attachments SizeCategory `json:"attachments"`
+	Metadata    SizeCategory `json:"metadata"`
+	Other       SizeCategory `json:"other"` // Structure, annotations and cross-reference data
+
+	// ImageBytesByDPI splits the image bytes by effective resolution, using
+	// the same buckets as the Analysis DPI histogram
+	ImageBytesByDPI map[string]int64 `json:"image_bytes_by_dpi"`
+}
+
+// SizeCategory is the share of the file taken by one kind of object
+type SizeCategory struct {
+	Bytes   int64   `json:"bytes"`
+	Percent float64 `json:"percent"`
+	Objects int     `json:"objects"`
+}
+
+// add counts one stream of the given stored size
+func (s *SizeCategory) add(size int64) {
+	s.Bytes += size
+	s.Objects++
+}
+
+// AnalyzeSize walks the objects of a PDF and attributes their stored stream
+// sizes to images, fonts, page content, attachments and metadata. Bytes not
+// held by any of those streams are reported as other.
+func (p *Processor) AnalyzeSize(inputPath string) (*SizeBreakdown, error) {
+	encrypted, err := compression.IsEncrypted(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, common.NewError(common.ErrEncryptedInput, "file is password protected")
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	conf := newPdfcpuConfiguration()
+	conf.Cmd = model.LISTINFO
+	ctx, err := api.ReadAndValidate(file, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	fontFiles, contents := referencedStreams(ctx)
+
+	breakdown := &SizeBreakdown{
+		FileSize:        stat.Size(),
+		ImageBytesByDPI: make(map[string]int64, len(dpiBuckets)),
+	}
+	imageSizes := make(map[int]int64)
+	for objNr, entry := range ctx.Table {
+		if entry == nil || entry.Free || entry.Compressed {
+			continue
+		}
+		stream, ok := entry.Object.(types.StreamDict)
+		if !ok || stream.StreamLength == nil {
+			continue
+		}
+		size := *stream.StreamLength
+
+		switch {
+		case isStreamType(stream, "XObject", "Image"):
+			breakdown.Images.add(size)
+			imageSizes[objNr] = size
+		case fontFiles[objNr]:
+			breakdown.Fonts.add(size)
+		case contents[objNr] || isStreamType(stream, "XObject", "Form"):
+			breakdown.Content.add(size)
+		case isStreamType(stream, "EmbeddedFile", ""):
+			breakdown.Attachments.add(size)
+		case isStreamType(stream, "Metadata", ""):
+			breakdown.Metadata.add(size)
+		default:
+			breakdown.Other.add(size)
+		}
+	}
+
+	// The remainder is dictionaries, object streams and the xref section
+	attributed := breakdown.Images.Bytes + breakdown.Fonts.Bytes + breakdown.Content.Bytes +
+		breakdown.Attachments.Bytes + breakdown.Metadata.Bytes + breakdown.Other.Bytes
+	if rest := breakdown.FileSize - attributed; rest > 0 {
+		breakdown.Other.Bytes += rest
+	}
+
+	if len(imageSizes) > 0 {
+		if err := imageBytesByDPI(ctx, file, imageSizes, breakdown.ImageBytesByDPI); err != nil {
+			p.logger.Debug("Failed to measure image resolution", "file", inputPath, "error", err)
+		}
+	}
+
+	for _, category := range []*SizeCategory{
+		&breakdown.Images, &breakdown.Fonts, &breakdown.Content,
+		&breakdown.Attachments, &breakdown.Metadata, &breakdown.Other,
+	} {
+		if breakdown.FileSize > 0 {
+			category.Percent = float64(category.Bytes) / float64(breakdown.FileSize) * 100
+		}
+	}
+
+	return breakdown, nil
+}
+
+// referencedStreams returns the object numbers of embedded font programs and
+// of page content streams, which carry no type of their own
+func referencedStreams(ctx *model.Context) (map[int]bool, map[int]bool) {
+	fontFiles := make(map[int]bool)
+	for _, entry := range ctx.Table {
+		if entry == nil || entry.Free {
+			continue
+		}
+		dict, ok := entry.Object.(types.Dict)
+		if !ok || dict.Type() == nil || *dict.Type() != "FontDescriptor" {
+			continue
+		}
+		for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+			if ref := dict.IndirectRefEntry(key); ref != nil {
+				fontFiles[ref.ObjectNumber.Value()] = true
+			}
+		}
+	}
+
+	contents := make(map[int]bool)
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		page, _, _, err := ctx.PageDict(pageNr, false)
+		if err != nil || page == nil {
+			continue
+		}
+		switch obj := page["Contents"].(type) {
+		case types.IndirectRef:
+			contents[obj.ObjectNumber.Value()] = true
+			// Contents may also point at an array of streams
+			if refs, err := ctx.DereferenceArray(obj); err == nil {
+				addRefs(contents, refs)
+			}
+		case types.Array:
+			addRefs(contents, obj)
+		}
+	}
+
+	return fontFiles, contents
+}
+
+// addRefs records the object numbers of the indirect references in refs
+func addRefs(set map[int]bool, refs types.Array) {
+	for _, obj := range refs {
+		if ref, ok := obj.(types.IndirectRef); ok {
+			set[ref.ObjectNumber.Value()] = true
+		}
+	}
+}
+
+// isStreamType reports whether a stream has the given Type and, when set, Subtype
+func isStreamType(stream types.StreamDict, typ, subtype string) bool {
+	t := stream.Type()
+	if t != nil && *t != typ {
+		return false
+	}
+	// Type is optional on XObjects, which are told apart by Subtype alone
+	if t == nil && typ != "XObject" {
+		return false
+	}
+	if subtype == "" {
+		return true
+	}
+	st := stream.Subtype()
+	return st != nil && *st == subtype
+}
+
+// imageBytesByDPI adds the stored size of each image to the bucket for its
+// effective resolution, assuming the image spans the page width as in Analyze
+func imageBytesByDPI(ctx *model.Context, file *os.File, sizes map[int]int64, buckets map[string]int64) error {
+	boundaries, err := ctx.PageBoundaries(nil)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return err
+	}
+	pageImages, err := api.Images(file, nil, newPdfcpuConfiguration())
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int]bool)
+	for _, images := range pageImages {
+		for _, image := range images {
+			size, ok := sizes[image.ObjNr]
+			if !ok || seen[image.ObjNr] || image.PageNr < 1 || image.PageNr > len(boundaries) {
+				continue
+			}
+			mediaBox := boundaries[image.PageNr-1].MediaBox()
+			if mediaBox == nil || mediaBox.Width() <= 0 {
+				continue
+			}
+			seen[image.ObjNr] = true
+			dpi := float64(image.Width) / (mediaBox.Width() / 72)
+			buckets[dpiBucket(dpi)] += size
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package pdfops
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"kleinpdf/internal/common"
+	"kleinpdf/internal/compression"
+)
+
+// SizePart is one file written by SplitBySize
+type SizePart struct {
+	Path      string `json:"path"`
+	FirstPage int    `json:"first_page"`
+	LastPage  int    `json:"last_page"`
+	Size      int64  `json:"size"`
+}
+
+// countingWriter discards what is written and counts the bytes
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// SplitBySize divides a PDF into consecutive page ranges whose files each fit
+// in maxBytes, writing part n to partPath(n). Pages are copied with pdfcpu, so
+// already compressed streams are kept as they are. A page that does not fit
+// on its own is an error.
+func (p *Processor) SplitBySize(inputPath string, maxBytes int64, partPath func(part int) string) ([]SizePart, error) {
+	if maxBytes <= 0 {
+		return nil, common.NewError(common.ErrInvalidRequest, "size limit must be positive")
+	}
+
+	encrypted, err := compression.IsEncrypted(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, common.NewError(common.ErrEncryptedInput, "file is password protected")
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conf := newPdfcpuConfiguration()
+	conf.Cmd = model.EXTRACTPAGES
+	ctx, err := api.ReadValidateAndOptimize(file, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	var parts []SizePart
+	for first := 1; first <= ctx.PageCount; {
+		size, err := partSize(ctx, first, first)
+		if err != nil {
+			return nil, err
+		}
+		if size > maxBytes {
+			removeParts(parts)
+			return nil, common.NewError(common.ErrInvalidRequest,
+				fmt.Sprintf("page %d alone is %.1f MB, over the %.1f MB limit", first, megabytes(size), megabytes(maxBytes)))
+		}
+
+		// Shared fonts and images make sizes grow unevenly, but never shrink
+		// as pages are added, so search for the longest range that fits
+		last := first
+		low, high := first+1, ctx.PageCount
+		for low <= high {
+			mid := (low + high) / 2
+			size, err := partSize(ctx, first, mid)
+			if err != nil {
+				removeParts(parts)
+				return nil, err
+			}
+			if size <= maxBytes {
+				last = mid
+				low = mid + 1
+			} else {
+				high = mid - 1
+			}
+		}
+
+		part, err := writePart(ctx, first, last, partPath(len(parts)+1))
+		if err != nil {
+			removeParts(parts)
+			return nil, err
+		}
+		parts = append(parts, *part)
+		p.logger.Debug("Wrote size-limited part", "file", part.Path, "pages", fmt.Sprintf("%d-%d", first, last), "size", part.Size)
+		first = last + 1
+	}
+
+	return parts, nil
+}
+
+// extractRange copies pages first to last into a new document
+func extractRange(ctx *model.Context, first, last int) (*model.Context, error) {
+	pageNrs := make([]int, 0, last-first+1)
+	for pageNr := first; pageNr <= last; pageNr++ {
+		pageNrs = append(pageNrs, pageNr)
+	}
+	part, err := pdfcpu.ExtractPages(ctx, pageNrs, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract pages %d-%d: %v", first, last, err)
+	}
+	return part, nil
+}
+
+// partSize returns the file size pages first to last would have on their own
+func partSize(ctx *model.Context, first, last int) (int64, error) {
+	part, err := extractRange(ctx, first, last)
+	if err != nil {
+		return 0, err
+	}
+	var counter countingWriter
+	if err := api.WriteContext(part, &counter); err != nil {
+		return 0, fmt.Errorf("failed to measure pages %d-%d: %v", first, last, err)
+	}
+	return counter.n, nil
+}
+
+// writePart writes pages first to last to path
+func writePart(ctx *model.Context, first, last int, path string) (*SizePart, error) {
+	part, err := extractRange(ctx, first, last)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.WriteContextFile(part, path); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write pages %d-%d: %v", first, last, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &SizePart{Path: path, FirstPage: first, LastPage: last, Size: info.Size()}, nil
+}
+
+// removeParts deletes the parts written before a split failed
+func removeParts(parts []SizePart) {
+	for _, part := range parts {
+		os.Remove(part.Path)
+	}
+}
+
+// megabytes converts a byte count for messages
+func megabytes(size int64) float64 {
+	return float64(size) / (1024 * 1024)
+}
@@ -0,0 +1,80 @@
+package pdfops
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"kleinpdf/internal/common"
+)
+
+// StampOptions configures page number and Bates stamps. A Bates stamp is a
+// page number with a prefix and zero padding, e.g. "ACME000123".
+type StampOptions struct {
+	Prefix   string `json:"prefix"`
+	Start    int    `json:"start"`
+	Digits   int    `json:"digits"`
+	Position string `json:"position"`
+	FontSize int    `json:"font_size"`
+}
+
+// stampOffsets are the pdfcpu anchor offsets that keep stamps clear of the page edge
+var stampOffsets = map[string]string{
+	"tl": "20 -20",
+	"tc": "0 -20",
+	"tr": "-20 -20",
+	"bl": "20 20",
+	"bc": "0 20",
+	"br": "-20 20",
+}
+
+// Label formats the stamp for the given sequence number
+func (o StampOptions) Label(number int) string {
+	return fmt.Sprintf("%s%0*d", o.Prefix, o.Digits, number)
+}
+
+// StampPages stamps every page with consecutive labels starting at first and
+// returns the number of pages stamped, so batches can continue the sequence.
+func (p *Processor) StampPages(ctx context.Context, inputPath, outputPath string, options StampOptions, first int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	position := options.Position
+	if position == "" {
+		position = "br"
+	}
+	offset, ok := stampOffsets[position]
+	if !ok {
+		return 0, common.NewError(common.ErrInvalidRequest, fmt.Sprintf("unknown stamp position %q", options.Position))
+	}
+
+	fontSize := options.FontSize
+	if fontSize <= 0 {
+		fontSize = 10
+	}
+
+	pageCount, err := api.PageCountFile(inputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read page count: %v", err)
+	}
+
+	desc := fmt.Sprintf("fontname:Helvetica, points:%d, scalefactor:1 abs, rotation:0, opacity:1, fillcolor:#000000, position:%s, offset:%s", fontSize, position, offset)
+	watermarks := make(map[int]*model.Watermark, pageCount)
+	for page := 1; page <= pageCount; page++ {
+		wm, err := api.TextWatermark(options.Label(first+page-1), desc, true, false, types.POINTS)
+		if err != nil {
+			return 0, common.WrapError(common.ErrInvalidRequest, "invalid stamp", err)
+		}
+		watermarks[page] = wm
+	}
+
+	if err := api.AddWatermarksMapFile(inputPath, outputPath, watermarks, newPdfcpuConfiguration()); err != nil {
+		return 0, fmt.Errorf("failed to stamp pages: %v", err)
+	}
+
+	return pageCount, nil
+}
@@ -0,0 +1,39 @@
+package pdfops
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// RenderThumbnail renders the first page to a PNG whose longest side is at most maxPx pixels
+func (p *Processor) RenderThumbnail(ctx context.Context, inputPath, outputPath string, maxPx int) error {
+	if maxPx < 1 {
+		return fmt.Errorf("invalid thumbnail size %d", maxPx)
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	dims, err := api.PageDims(file, newPdfcpuConfiguration())
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read page size: %v", err)
+	}
+	if len(dims) == 0 {
+		return fmt.Errorf("document has no pages")
+	}
+
+	// Page dimensions are in points (1/72 inch)
+	longest := math.Max(dims[0].Width, dims[0].Height)
+	dpi := int(float64(maxPx) * 72 / longest)
+	if dpi < 1 {
+		dpi = 1
+	}
+
+	return p.RenderPage(ctx, inputPath, outputPath, 1, dpi)
+}
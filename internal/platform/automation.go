@@ -0,0 +1,54 @@
+package platform
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// ScriptCommand is an AppleScript command sent to the app
+type ScriptCommand struct {
+	Name         string   `json:"command"`
+	Files        []string `json:"files"`
+	Text         string   `json:"text"`
+	Level        string   `json:"level"`
+	OutputFolder string   `json:"outputFolder"`
+}
+
+var (
+	automationHandlerMu sync.RWMutex
+	automationHandler   func(command ScriptCommand) (interface{}, error)
+)
+
+// SetAutomationHandler sets the function that runs AppleScript commands. Its
+// result is returned to the script; record results are converted using the
+// json field names, which must match the cocoa keys in KleinPDF.sdef.
+func SetAutomationHandler(handler func(command ScriptCommand) (interface{}, error)) {
+	automationHandlerMu.Lock()
+	defer automationHandlerMu.Unlock()
+	automationHandler = handler
+}
+
+// handleAutomationCommand decodes a command, runs it and encodes its result
+func handleAutomationCommand(commandJSON string) (string, error) {
+	automationHandlerMu.RLock()
+	handler := automationHandler
+	automationHandlerMu.RUnlock()
+
+	var command ScriptCommand
+	if err := json.Unmarshal([]byte(commandJSON), &command); err != nil {
+		return "", err
+	}
+	if handler == nil {
+		return "null", nil
+	}
+
+	result, err := handler(command)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
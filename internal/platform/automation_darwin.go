@@ -0,0 +1,119 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+#include <stdlib.h>
+
+extern void goScriptCommand(uintptr_t handle, char *commandJSON);
+
+// scriptValue converts an evaluated AppleScript argument into JSON values
+static id scriptValue(id value) {
+	if ([value isKindOfClass:[NSURL class]]) {
+		return [(NSURL *)value path];
+	}
+	if ([value isKindOfClass:[NSArray class]]) {
+		NSMutableArray *values = [NSMutableArray array];
+		for (id item in (NSArray *)value) {
+			id converted = scriptValue(item);
+			if (converted != nil) {
+				[values addObject:converted];
+			}
+		}
+		return values;
+	}
+	if ([value isKindOfClass:[NSString class]] || [value isKindOfClass:[NSNumber class]]) {
+		return value;
+	}
+	return nil;
+}
+
+// KleinPDFScriptCommand runs every command in KleinPDF.sdef. Commands are
+// suspended while Go works so a long compression never blocks the main thread.
+@interface KleinPDFScriptCommand : NSScriptCommand
+@end
+
+@implementation KleinPDFScriptCommand
+- (id)performDefaultImplementation {
+	NSMutableDictionary *command = [NSMutableDictionary dictionary];
+	command[@"command"] = self.commandDescription.commandName;
+
+	id direct = scriptValue(self.directParameter);
+	if ([direct isKindOfClass:[NSArray class]]) {
+		command[@"files"] = direct;
+	} else if ([self.directParameter isKindOfClass:[NSURL class]]) {
+		command[@"files"] = @[direct];
+	} else if ([direct isKindOfClass:[NSString class]]) {
+		command[@"text"] = direct;
+	}
+	[self.evaluatedArguments enumerateKeysAndObjectsUsingBlock:^(NSString *key, id value, BOOL *stop) {
+		id converted = scriptValue(value);
+		if (converted != nil && ![key isEqualToString:@""]) {
+			command[key] = converted;
+		}
+	}];
+
+	NSData *data = [NSJSONSerialization dataWithJSONObject:command options:0 error:nil];
+	NSString *json = [[[NSString alloc] initWithData:data encoding:NSUTF8StringEncoding] autorelease];
+
+	[self suspendExecution];
+	[self retain]; // Released by resumeScriptCommand
+	goScriptCommand((uintptr_t)self, (char *)json.UTF8String);
+	return nil;
+}
+@end
+
+// resumeScriptCommand returns a result or error to a suspended command
+static void resumeScriptCommand(uintptr_t handle, const char *resultJSON, const char *errorMessage) {
+	@autoreleasepool {
+		NSString *result = resultJSON ? [NSString stringWithUTF8String:resultJSON] : nil;
+		NSString *error = errorMessage ? [NSString stringWithUTF8String:errorMessage] : nil;
+		dispatch_async(dispatch_get_main_queue(), ^{
+			KleinPDFScriptCommand *command = (KleinPDFScriptCommand *)handle;
+			id value = nil;
+			if (error != nil) {
+				command.scriptErrorNumber = -2700; // errOSAGeneralError
+				command.scriptErrorString = error;
+			} else if (result != nil) {
+				value = [NSJSONSerialization JSONObjectWithData:[result dataUsingEncoding:NSUTF8StringEncoding]
+				                                        options:NSJSONReadingFragmentsAllowed
+				                                          error:nil];
+				if (value == [NSNull null]) {
+					value = nil;
+				}
+			}
+			[command resumeExecutionWithResult:value];
+			[command release];
+		});
+	}
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+//export goScriptCommand
+func goScriptCommand(handle C.uintptr_t, commandJSON *C.char) {
+	command := C.GoString(commandJSON)
+
+	// Run off the main thread; the command stays suspended until resumed
+	go func() {
+		result, err := handleAutomationCommand(command)
+		if err != nil {
+			cError := C.CString(err.Error())
+			defer C.free(unsafe.Pointer(cError))
+			C.resumeScriptCommand(handle, nil, cError)
+			return
+		}
+
+		cResult := C.CString(result)
+		defer C.free(unsafe.Pointer(cResult))
+		C.resumeScriptCommand(handle, cResult, nil)
+	}()
+}
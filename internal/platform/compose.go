@@ -0,0 +1,8 @@
+package platform
+
+import (
+	"errors"
+)
+
+// ErrComposeUnsupported is returned where no mail client can be handed attachments
+var ErrComposeUnsupported = errors.New("composing email with attachments is not supported on this platform")
@@ -0,0 +1,93 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+#include <stdlib.h>
+
+// composeEmail opens a draft in the default mail client. It returns 0 when
+// no mail client can compose messages.
+static int composeEmail(char **recipients, int recipientCount, const char *subject, const char *body, char **attachments, int attachmentCount) {
+	@autoreleasepool {
+		NSSharingService *service = [NSSharingService sharingServiceNamed:NSSharingServiceNameComposeEmail];
+		if (service == nil) {
+			return 0;
+		}
+
+		NSMutableArray *to = [NSMutableArray array];
+		for (int i = 0; i < recipientCount; i++) {
+			[to addObject:[NSString stringWithUTF8String:recipients[i]]];
+		}
+		NSMutableArray *items = [NSMutableArray array];
+		NSString *text = [NSString stringWithUTF8String:body];
+		if (text.length > 0) {
+			[items addObject:text];
+		}
+		for (int i = 0; i < attachmentCount; i++) {
+			[items addObject:[NSURL fileURLWithPath:[NSString stringWithUTF8String:attachments[i]]]];
+		}
+		if (![service canPerformWithItems:items]) {
+			return 0;
+		}
+		NSString *title = [NSString stringWithUTF8String:subject];
+
+		dispatch_async(dispatch_get_main_queue(), ^{
+			service.recipients = to;
+			service.subject = title;
+			[service performWithItems:items];
+		});
+		return 1;
+	}
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// ComposeEmail opens a draft with the files attached in the default mail client
+func ComposeEmail(to []string, subject, body string, attachments []string) error {
+	cRecipients := cStrings(to)
+	defer freeCStrings(cRecipients)
+	cAttachments := cStrings(attachments)
+	defer freeCStrings(cAttachments)
+	cSubject := C.CString(subject)
+	defer C.free(unsafe.Pointer(cSubject))
+	cBody := C.CString(body)
+	defer C.free(unsafe.Pointer(cBody))
+
+	if C.composeEmail(cArray(cRecipients), C.int(len(to)), cSubject, cBody, cArray(cAttachments), C.int(len(attachments))) == 0 {
+		return ErrComposeUnsupported
+	}
+	return nil
+}
+
+// cStrings copies strings into C memory that must be released with freeCStrings
+func cStrings(values []string) []*C.char {
+	result := make([]*C.char, len(values))
+	for i, value := range values {
+		result[i] = C.CString(value)
+	}
+	return result
+}
+
+// freeCStrings releases strings allocated by cStrings
+func freeCStrings(values []*C.char) {
+	for _, value := range values {
+		C.free(unsafe.Pointer(value))
+	}
+}
+
+// cArray passes a Go slice of C strings as a char** without copying. The
+// strings themselves live in C memory, so cgo pointer rules are satisfied.
+func cArray(values []*C.char) **C.char {
+	if len(values) == 0 {
+		return nil
+	}
+	return &values[0]
+}
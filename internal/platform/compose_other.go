@@ -0,0 +1,24 @@
+//go:build !darwin && !windows
+
+package platform
+
+import (
+	"os/exec"
+)
+
+// ComposeEmail opens a draft with the files attached using xdg-email
+func ComposeEmail(to []string, subject, body string, attachments []string) error {
+	if _, err := exec.LookPath("xdg-email"); err != nil {
+		return ErrComposeUnsupported
+	}
+
+	args := []string{"--subject", subject}
+	if body != "" {
+		args = append(args, "--body", body)
+	}
+	for _, attachment := range attachments {
+		args = append(args, "--attach", attachment)
+	}
+	args = append(args, to...)
+	return exec.Command("xdg-email", args...).Start()
+}
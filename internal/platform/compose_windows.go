@@ -0,0 +1,9 @@
+//go:build windows
+
+package platform
+
+// ComposeEmail reports that attachments cannot be handed to a mail client;
+// callers fall back to a mailto: link
+func ComposeEmail(to []string, subject, body string, attachments []string) error {
+	return ErrComposeUnsupported
+}
@@ -0,0 +1,59 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+#include <stdlib.h>
+
+// revealFile selects a file in a Finder window
+static void revealFile(const char *path) {
+	@autoreleasepool {
+		NSURL *url = [NSURL fileURLWithPath:[NSString stringWithUTF8String:path]];
+		dispatch_async(dispatch_get_main_queue(), ^{
+			[[NSWorkspace sharedWorkspace] activateFileViewerSelectingURLs:@[url]];
+		});
+	}
+}
+
+// trashFile moves a file to the Trash, returning an error message the caller
+// must free, or NULL on success
+static char *trashFile(const char *path) {
+	@autoreleasepool {
+		NSURL *url = [NSURL fileURLWithPath:[NSString stringWithUTF8String:path]];
+		NSError *error = nil;
+		if (![[NSFileManager defaultManager] trashItemAtURL:url resultingItemURL:nil error:&error]) {
+			return strdup(error.localizedDescription.UTF8String);
+		}
+		return NULL;
+	}
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// RevealInFileManager shows a file selected in Finder
+func RevealInFileManager(path string) error {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+	C.revealFile(cPath)
+	return nil
+}
+
+// MoveToTrash moves a file to the Trash so it can be put back from Finder
+func MoveToTrash(path string) error {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+	if cError := C.trashFile(cPath); cError != nil {
+		defer C.free(unsafe.Pointer(cError))
+		return errors.New(C.GoString(cError))
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !darwin && !windows
+
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// RevealInFileManager opens the folder containing a file. Desktop file
+// managers have no common way to select the file itself.
+func RevealInFileManager(path string) error {
+	return exec.Command("xdg-open", filepath.Dir(path)).Start()
+}
+
+// MoveToTrash moves a file to the desktop trash using gio
+func MoveToTrash(path string) error {
+	output, err := exec.Command("gio", "trash", "--", path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gio trash failed: %v, output: %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+//go:build windows
+
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	foDelete          = 0x0003
+	fofSilent         = 0x0004
+	fofNoConfirmation = 0x0010
+	fofAllowUndo      = 0x0040
+	fofNoErrorUI      = 0x0400
+)
+
+// shFileOpStruct mirrors SHFILEOPSTRUCTW, which is not packed on 64-bit Windows
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+var procSHFileOperationW = windows.NewLazySystemDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// RevealInFileManager shows a file selected in Explorer
+func RevealInFileManager(path string) error {
+	cmd := exec.Command("explorer.exe")
+	// Explorer parses its own command line and needs the path quoted after /select,
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: fmt.Sprintf(`explorer.exe /select,"%s"`, path)}
+	// Explorer exits with status 1 even when it succeeds, so only start it
+	return cmd.Start()
+}
+
+// MoveToTrash moves a file to the Recycle Bin so it can be restored
+func MoveToTrash(path string) error {
+	// pFrom is a list of paths ending with an extra NUL
+	from, err := windows.UTF16FromString(path)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0)
+
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI,
+	}
+	result, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op)))
+	if result != 0 {
+		return fmt.Errorf("failed to move %s to the Recycle Bin: error 0x%x", path, result)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return fmt.Errorf("moving %s to the Recycle Bin was cancelled", path)
+	}
+	return nil
+}
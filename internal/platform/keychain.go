@@ -0,0 +1,8 @@
+package platform
+
+import (
+	"errors"
+)
+
+// ErrKeychainUnsupported is returned where certificates cannot be read from a system keychain
+var ErrKeychainUnsupported = errors.New("reading certificates from the keychain is not supported on this platform")
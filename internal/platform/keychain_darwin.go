@@ -0,0 +1,89 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Foundation -framework Security
+
+#import <Foundation/Foundation.h>
+#import <Security/Security.h>
+#include <stdlib.h>
+#include <string.h>
+
+// exportIdentity exports the first identity whose certificate subject contains
+// name as PKCS#12 data in a malloc'd buffer the caller frees
+static OSStatus exportIdentity(const char *name, const char *passphrase, void **data, long *length) {
+	@autoreleasepool {
+		NSDictionary *query = @{
+			(id)kSecClass: (id)kSecClassIdentity,
+			(id)kSecMatchSubjectContains: [NSString stringWithUTF8String:name],
+			(id)kSecMatchLimit: (id)kSecMatchLimitOne,
+			(id)kSecReturnRef: @YES,
+		};
+		CFTypeRef identity = NULL;
+		OSStatus status = SecItemCopyMatching((CFDictionaryRef)query, &identity);
+		if (status != errSecSuccess) {
+			return status;
+		}
+
+		SecItemImportExportKeyParameters params;
+		memset(&params, 0, sizeof(params));
+		params.version = SEC_KEY_IMPORT_EXPORT_PARAMS_VERSION;
+		params.passphrase = (CFStringRef)[NSString stringWithUTF8String:passphrase];
+
+		CFDataRef exported = NULL;
+		status = SecItemExport(identity, kSecFormatPKCS12, 0, &params, &exported);
+		CFRelease(identity);
+		if (status != errSecSuccess) {
+			return status;
+		}
+
+		*length = CFDataGetLength(exported);
+		*data = malloc(*length);
+		memcpy(*data, CFDataGetBytePtr(exported), *length);
+		CFRelease(exported);
+		return errSecSuccess;
+	}
+}
+
+// statusMessage describes a Security framework status in a malloc'd string
+static char *statusMessage(OSStatus status) {
+	@autoreleasepool {
+		CFStringRef message = SecCopyErrorMessageString(status, NULL);
+		if (message == NULL) {
+			return strdup("unknown keychain error");
+		}
+		char *result = strdup([(NSString *)message UTF8String]);
+		CFRelease(message);
+		return result;
+	}
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// ExportKeychainIdentity exports the Keychain identity whose certificate
+// subject contains name as PKCS#12 data protected by passphrase. macOS asks
+// the user to allow the export the first time.
+func ExportKeychainIdentity(name, passphrase string) ([]byte, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cPassphrase := C.CString(passphrase)
+	defer C.free(unsafe.Pointer(cPassphrase))
+
+	var data unsafe.Pointer
+	var length C.long
+	if status := C.exportIdentity(cName, cPassphrase, &data, &length); status != C.errSecSuccess {
+		message := C.statusMessage(status)
+		defer C.free(unsafe.Pointer(message))
+		return nil, fmt.Errorf("keychain identity %q: %s", name, C.GoString(message))
+	}
+	defer C.free(data)
+
+	return C.GoBytes(data, C.int(length)), nil
+}
@@ -0,0 +1,9 @@
+//go:build !darwin
+
+package platform
+
+// ExportKeychainIdentity reports that there is no keychain to read from;
+// callers load a certificate file instead
+func ExportKeychainIdentity(name, passphrase string) ([]byte, error) {
+	return nil, ErrKeychainUnsupported
+}
@@ -0,0 +1,53 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Foundation -framework UserNotifications
+
+#import <Foundation/Foundation.h>
+#import <UserNotifications/UserNotifications.h>
+#include <stdlib.h>
+
+// postNotification shows a notification, asking for permission the first time
+static void postNotification(const char *title, const char *body) {
+	@autoreleasepool {
+		// The notification center is only available inside an app bundle
+		if (NSBundle.mainBundle.bundleIdentifier == nil) {
+			return;
+		}
+
+		NSString *notificationTitle = [NSString stringWithUTF8String:title];
+		NSString *notificationBody = [NSString stringWithUTF8String:body];
+		UNUserNotificationCenter *center = [UNUserNotificationCenter currentNotificationCenter];
+		[center requestAuthorizationWithOptions:UNAuthorizationOptionAlert
+		                      completionHandler:^(BOOL granted, NSError *error) {
+			if (!granted) {
+				return;
+			}
+			UNMutableNotificationContent *content = [[[UNMutableNotificationContent alloc] init] autorelease];
+			content.title = notificationTitle;
+			content.body = notificationBody;
+			UNNotificationRequest *request = [UNNotificationRequest requestWithIdentifier:NSUUID.UUID.UUIDString
+			                                                                      content:content
+			                                                                      trigger:nil];
+			[center addNotificationRequest:request withCompletionHandler:nil];
+		}];
+	}
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// Notify shows a system notification
+func Notify(title, body string) {
+	cTitle := C.CString(title)
+	defer C.free(unsafe.Pointer(cTitle))
+	cBody := C.CString(body)
+	defer C.free(unsafe.Pointer(cBody))
+	C.postNotification(cTitle, cBody)
+}
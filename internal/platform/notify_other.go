@@ -0,0 +1,6 @@
+//go:build !darwin
+
+package platform
+
+// Notify is a no-op on platforms without notification support
+func Notify(title, body string) {}
@@ -0,0 +1,14 @@
+package platform
+
+import "errors"
+
+// secretService names the app's entries in the system keychain
+const secretService = "com.kleinpdf.app"
+
+var (
+	// ErrSecretNotFound is returned when the keychain holds no entry for a key
+	ErrSecretNotFound = errors.New("password not found in the keychain")
+
+	// ErrSecretsUnsupported is returned where passwords cannot be kept in a system keychain
+	ErrSecretsUnsupported = errors.New("saving passwords needs the system keychain, which is not supported on this platform")
+)
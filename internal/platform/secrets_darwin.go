@@ -0,0 +1,140 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Foundation -framework Security
+
+#import <Foundation/Foundation.h>
+#import <Security/Security.h>
+#include <stdlib.h>
+#include <string.h>
+
+// secretQuery matches the generic password stored for account
+static NSDictionary *secretQuery(const char *service, const char *account) {
+	return @{
+		(id)kSecClass: (id)kSecClassGenericPassword,
+		(id)kSecAttrService: [NSString stringWithUTF8String:service],
+		(id)kSecAttrAccount: [NSString stringWithUTF8String:account],
+	};
+}
+
+// setSecret stores data for account, replacing any earlier value
+static OSStatus setSecret(const char *service, const char *account, const void *data, long length) {
+	@autoreleasepool {
+		NSDictionary *query = secretQuery(service, account);
+		NSData *secret = [NSData dataWithBytes:data length:length];
+		OSStatus status = SecItemUpdate((CFDictionaryRef)query, (CFDictionaryRef)@{(id)kSecValueData: secret});
+		if (status == errSecItemNotFound) {
+			NSMutableDictionary *item = [query mutableCopy];
+			item[(id)kSecValueData] = secret;
+			status = SecItemAdd((CFDictionaryRef)item, NULL);
+		}
+		return status;
+	}
+}
+
+// getSecret copies the data stored for account into a malloc'd buffer the caller frees
+static OSStatus getSecret(const char *service, const char *account, void **data, long *length) {
+	@autoreleasepool {
+		NSMutableDictionary *query = [secretQuery(service, account) mutableCopy];
+		query[(id)kSecReturnData] = @YES;
+		query[(id)kSecMatchLimit] = (id)kSecMatchLimitOne;
+
+		CFTypeRef result = NULL;
+		OSStatus status = SecItemCopyMatching((CFDictionaryRef)query, &result);
+		if (status != errSecSuccess) {
+			return status;
+		}
+
+		*length = CFDataGetLength((CFDataRef)result);
+		*data = malloc(*length > 0 ? *length : 1);
+		memcpy(*data, CFDataGetBytePtr((CFDataRef)result), *length);
+		CFRelease(result);
+		return errSecSuccess;
+	}
+}
+
+// deleteSecret removes the entry for account
+static OSStatus deleteSecret(const char *service, const char *account) {
+	@autoreleasepool {
+		return SecItemDelete((CFDictionaryRef)secretQuery(service, account));
+	}
+}
+
+// secretStatusMessage describes a Security framework status in a malloc'd string
+static char *secretStatusMessage(OSStatus status) {
+	@autoreleasepool {
+		CFStringRef message = SecCopyErrorMessageString(status, NULL);
+		if (message == NULL) {
+			return strdup("unknown keychain error");
+		}
+		char *result = strdup([(NSString *)message UTF8String]);
+		CFRelease(message);
+		return result;
+	}
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// SetSecret stores secret under key in the login keychain
+func SetSecret(key, secret string) error {
+	cService := C.CString(secretService)
+	defer C.free(unsafe.Pointer(cService))
+	cKey := C.CString(key)
+	defer C.free(unsafe.Pointer(cKey))
+	cSecret := C.CBytes([]byte(secret))
+	defer C.free(cSecret)
+
+	if status := C.setSecret(cService, cKey, cSecret, C.long(len(secret))); status != C.errSecSuccess {
+		return secretError(key, status)
+	}
+	return nil
+}
+
+// GetSecret reads the secret stored under key, or ErrSecretNotFound
+func GetSecret(key string) (string, error) {
+	cService := C.CString(secretService)
+	defer C.free(unsafe.Pointer(cService))
+	cKey := C.CString(key)
+	defer C.free(unsafe.Pointer(cKey))
+
+	var data unsafe.Pointer
+	var length C.long
+	status := C.getSecret(cService, cKey, &data, &length)
+	if status == C.errSecItemNotFound {
+		return "", ErrSecretNotFound
+	}
+	if status != C.errSecSuccess {
+		return "", secretError(key, status)
+	}
+	defer C.free(data)
+
+	return string(C.GoBytes(data, C.int(length))), nil
+}
+
+// DeleteSecret removes the secret stored under key; a missing one is not an error
+func DeleteSecret(key string) error {
+	cService := C.CString(secretService)
+	defer C.free(unsafe.Pointer(cService))
+	cKey := C.CString(key)
+	defer C.free(unsafe.Pointer(cKey))
+
+	if status := C.deleteSecret(cService, cKey); status != C.errSecSuccess && status != C.errSecItemNotFound {
+		return secretError(key, status)
+	}
+	return nil
+}
+
+// secretError describes a failed keychain call
+func secretError(key string, status C.OSStatus) error {
+	message := C.secretStatusMessage(status)
+	defer C.free(unsafe.Pointer(message))
+	return fmt.Errorf("keychain entry %q: %s", key, C.GoString(message))
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !windows
+
+package platform
+
+// SetSecret reports that there is no keychain to store passwords in
+func SetSecret(key, secret string) error {
+	return ErrSecretsUnsupported
+}
+
+// GetSecret reports that no password is stored
+func GetSecret(key string) (string, error) {
+	return "", ErrSecretNotFound
+}
+
+// DeleteSecret has nothing to remove without a keychain
+func DeleteSecret(key string) error {
+	return nil
+}
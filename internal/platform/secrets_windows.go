@@ -0,0 +1,102 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+// credential mirrors CREDENTIALW
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+var (
+	advapi32        = windows.NewLazySystemDLL("advapi32.dll")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+// secretTarget names the Credential Manager entry for key
+func secretTarget(key string) (*uint16, error) {
+	return windows.UTF16PtrFromString(secretService + "/" + key)
+}
+
+// SetSecret stores secret under key in the Windows Credential Manager
+func SetSecret(key, secret string) error {
+	target, err := secretTarget(key)
+	if err != nil {
+		return err
+	}
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(secret)),
+		Persist:            credPersistLocalMachine,
+	}
+	blob := []byte(secret)
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	if result, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); result == 0 {
+		return fmt.Errorf("credential %q: %v", key, err)
+	}
+	return nil
+}
+
+// GetSecret reads the secret stored under key, or ErrSecretNotFound
+func GetSecret(key string) (string, error) {
+	target, err := secretTarget(key)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	result, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if result == 0 {
+		if errors.Is(err, windows.ERROR_NOT_FOUND) {
+			return "", ErrSecretNotFound
+		}
+		return "", fmt.Errorf("credential %q: %v", key, err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+// DeleteSecret removes the secret stored under key; a missing one is not an error
+func DeleteSecret(key string) error {
+	target, err := secretTarget(key)
+	if err != nil {
+		return err
+	}
+	result, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if result == 0 && !errors.Is(err, windows.ERROR_NOT_FOUND) {
+		return fmt.Errorf("credential %q: %v", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package platform
+
+import (
+	"sync"
+)
+
+var (
+	serviceHandlerMu sync.RWMutex
+	serviceHandler   func(paths []string)
+)
+
+// SetServiceHandler sets the function that receives files sent through the
+// "Compress with KleinPDF" service
+func SetServiceHandler(handler func(paths []string)) {
+	serviceHandlerMu.Lock()
+	defer serviceHandlerMu.Unlock()
+	serviceHandler = handler
+}
+
+// handleServiceFiles passes files from the service to the registered handler
+func handleServiceFiles(paths []string) {
+	serviceHandlerMu.RLock()
+	handler := serviceHandler
+	serviceHandlerMu.RUnlock()
+
+	if handler != nil && len(paths) > 0 {
+		handler(paths)
+	}
+}
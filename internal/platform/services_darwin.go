@@ -0,0 +1,71 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+
+extern void goServiceFiles(char **paths, int count);
+
+// KleinPDFServiceProvider handles the NSServices entry in Info.plist. The
+// selector name must match its NSMessage, compressFiles.
+@interface KleinPDFServiceProvider : NSObject
+- (void)compressFiles:(NSPasteboard *)pboard userData:(NSString *)userData error:(NSString **)error;
+@end
+
+@implementation KleinPDFServiceProvider
+- (void)compressFiles:(NSPasteboard *)pboard userData:(NSString *)userData error:(NSString **)error {
+	NSArray<NSURL *> *urls = [pboard readObjectsForClasses:@[[NSURL class]]
+	                                               options:@{NSPasteboardURLReadingFileURLsOnlyKey: @YES}];
+	if (urls.count == 0) {
+		*error = @"No files were selected.";
+		return;
+	}
+
+	char **paths = malloc(sizeof(char *) * urls.count);
+	int count = 0;
+	for (NSURL *url in urls) {
+		paths[count++] = strdup(url.path.fileSystemRepresentation);
+	}
+	goServiceFiles(paths, count);
+	for (int i = 0; i < count; i++) {
+		free(paths[i]);
+	}
+	free(paths);
+}
+@end
+
+static void registerServicesProvider(void) {
+	dispatch_async(dispatch_get_main_queue(), ^{
+		static KleinPDFServiceProvider *provider;
+		provider = [[KleinPDFServiceProvider alloc] init];
+		[NSApp setServicesProvider:provider];
+		NSUpdateDynamicServices();
+	});
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// RegisterServices makes the app the provider for its macOS Services menu entry
+func RegisterServices() {
+	C.registerServicesProvider()
+}
+
+//export goServiceFiles
+func goServiceFiles(paths **C.char, count C.int) {
+	cPaths := unsafe.Slice(paths, int(count))
+	files := make([]string, 0, len(cPaths))
+	for _, path := range cPaths {
+		files = append(files, C.GoString(path))
+	}
+
+	// Compression takes a while; return to the Services caller straight away
+	go handleServiceFiles(files)
+}
@@ -0,0 +1,6 @@
+//go:build !darwin
+
+package platform
+
+// RegisterServices is a no-op on platforms without a Services menu
+func RegisterServices() {}
@@ -0,0 +1,52 @@
+package platform
+
+import (
+	"sync"
+)
+
+// StatusItemHandlers receive events from the menu bar icon
+type StatusItemHandlers struct {
+	// OnDrop receives files dropped on the icon
+	OnDrop func(paths []string)
+	// OnShow is called when "Show KleinPDF" is chosen from the icon's menu
+	OnShow func()
+	// OnQuit is called when "Quit KleinPDF" is chosen from the icon's menu
+	OnQuit func()
+}
+
+var (
+	statusItemMu       sync.RWMutex
+	statusItemHandlers StatusItemHandlers
+)
+
+// SetStatusItemHandlers sets the functions that receive menu bar icon events
+func SetStatusItemHandlers(handlers StatusItemHandlers) {
+	statusItemMu.Lock()
+	defer statusItemMu.Unlock()
+	statusItemHandlers = handlers
+}
+
+// handleStatusItemDrop passes files dropped on the icon to the handler
+func handleStatusItemDrop(paths []string) {
+	statusItemMu.RLock()
+	handler := statusItemHandlers.OnDrop
+	statusItemMu.RUnlock()
+
+	if handler != nil && len(paths) > 0 {
+		handler(paths)
+	}
+}
+
+// handleStatusItemMenu runs the handler for a menu item
+func handleStatusItemMenu(show bool) {
+	statusItemMu.RLock()
+	handler := statusItemHandlers.OnQuit
+	if show {
+		handler = statusItemHandlers.OnShow
+	}
+	statusItemMu.RUnlock()
+
+	if handler != nil {
+		handler()
+	}
+}
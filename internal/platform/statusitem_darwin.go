@@ -0,0 +1,146 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+
+extern void goStatusItemDrop(char **paths, int count);
+extern void goStatusItemMenu(int show);
+
+// KleinPDFStatusItemController accepts drops on the menu bar icon. The icon's
+// window forwards dragging destination messages to its delegate.
+@interface KleinPDFStatusItemController : NSObject <NSWindowDelegate, NSDraggingDestination>
+@end
+
+@implementation KleinPDFStatusItemController
+- (NSArray<NSURL *> *)fileURLs:(id<NSDraggingInfo>)sender {
+	return [sender.draggingPasteboard readObjectsForClasses:@[[NSURL class]]
+	                                                options:@{NSPasteboardURLReadingFileURLsOnlyKey: @YES}];
+}
+
+- (NSDragOperation)draggingEntered:(id<NSDraggingInfo>)sender {
+	return [self fileURLs:sender].count > 0 ? NSDragOperationCopy : NSDragOperationNone;
+}
+
+- (BOOL)performDragOperation:(id<NSDraggingInfo>)sender {
+	NSArray<NSURL *> *urls = [self fileURLs:sender];
+	if (urls.count == 0) {
+		return NO;
+	}
+
+	char **paths = malloc(sizeof(char *) * urls.count);
+	int count = 0;
+	for (NSURL *url in urls) {
+		paths[count++] = strdup(url.path.fileSystemRepresentation);
+	}
+	goStatusItemDrop(paths, count);
+	for (int i = 0; i < count; i++) {
+		free(paths[i]);
+	}
+	free(paths);
+	return YES;
+}
+
+- (void)showWindow:(id)sender {
+	goStatusItemMenu(1);
+	[NSApp activateIgnoringOtherApps:YES];
+}
+
+- (void)quit:(id)sender {
+	goStatusItemMenu(0);
+}
+@end
+
+static NSStatusItem *statusItem;
+static KleinPDFStatusItemController *statusItemController;
+
+// showStatusItem adds the menu bar icon and hides the Dock icon
+static void showStatusItem(void) {
+	dispatch_async(dispatch_get_main_queue(), ^{
+		if (statusItem != nil) {
+			return;
+		}
+		if (statusItemController == nil) {
+			statusItemController = [[KleinPDFStatusItemController alloc] init];
+		}
+
+		statusItem = [[[NSStatusBar systemStatusBar] statusItemWithLength:NSSquareStatusItemLength] retain];
+		NSStatusBarButton *button = statusItem.button;
+		NSImage *image = nil;
+		if (@available(macOS 11.0, *)) {
+			image = [NSImage imageWithSystemSymbolName:@"arrow.down.doc" accessibilityDescription:@"KleinPDF"];
+		}
+		if (image != nil) {
+			image.template = YES;
+			button.image = image;
+		} else {
+			button.title = @"PDF";
+		}
+		button.toolTip = @"Drop PDFs here to compress them";
+		[button.window registerForDraggedTypes:@[NSPasteboardTypeFileURL]];
+		button.window.delegate = statusItemController;
+
+		NSMenu *menu = [[[NSMenu alloc] init] autorelease];
+		NSMenuItem *show = [menu addItemWithTitle:@"Show KleinPDF" action:@selector(showWindow:) keyEquivalent:@""];
+		show.target = statusItemController;
+		[menu addItem:[NSMenuItem separatorItem]];
+		NSMenuItem *quit = [menu addItemWithTitle:@"Quit KleinPDF" action:@selector(quit:) keyEquivalent:@"q"];
+		quit.target = statusItemController;
+		statusItem.menu = menu;
+
+		[NSApp setActivationPolicy:NSApplicationActivationPolicyAccessory];
+	});
+}
+
+// hideStatusItem removes the menu bar icon and brings back the Dock icon
+static void hideStatusItem(void) {
+	dispatch_async(dispatch_get_main_queue(), ^{
+		if (statusItem == nil) {
+			return;
+		}
+		[[NSStatusBar systemStatusBar] removeStatusItem:statusItem];
+		[statusItem release];
+		statusItem = nil;
+
+		[NSApp setActivationPolicy:NSApplicationActivationPolicyRegular];
+		[NSApp activateIgnoringOtherApps:YES];
+	});
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// ShowStatusItem adds the menu bar icon and hides the Dock icon
+func ShowStatusItem() error {
+	C.showStatusItem()
+	return nil
+}
+
+// HideStatusItem removes the menu bar icon and restores the Dock icon
+func HideStatusItem() {
+	C.hideStatusItem()
+}
+
+//export goStatusItemDrop
+func goStatusItemDrop(paths **C.char, count C.int) {
+	cPaths := unsafe.Slice(paths, int(count))
+	files := make([]string, 0, len(cPaths))
+	for _, path := range cPaths {
+		files = append(files, C.GoString(path))
+	}
+
+	// Let the drop animation finish while the files compress
+	go handleStatusItemDrop(files)
+}
+
+//export goStatusItemMenu
+func goStatusItemMenu(show C.int) {
+	go handleStatusItemMenu(show != 0)
+}
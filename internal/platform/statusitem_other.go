@@ -0,0 +1,15 @@
+//go:build !darwin
+
+package platform
+
+import (
+	"errors"
+)
+
+// ShowStatusItem reports that the menu bar icon is only available on macOS
+func ShowStatusItem() error {
+	return errors.New("menu bar mode is only available on macOS")
+}
+
+// HideStatusItem is a no-op on platforms without a menu bar icon
+func HideStatusItem() {}
@@ -0,0 +1,56 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Identity is a signing certificate with its private key and issuing chain
+type Identity struct {
+	Certificate *x509.Certificate
+	Chain       []*x509.Certificate
+	Key         crypto.Signer
+}
+
+// LoadPKCS12 decodes a PKCS#12 bundle, as exported from Keychain Access or
+// OpenSSL, into a signing identity
+func LoadPKCS12(data []byte, password string) (*Identity, error) {
+	key, certificate, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %v", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("certificate key of type %T cannot sign", key)
+	}
+	switch signer.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+	default:
+		return nil, fmt.Errorf("PDF signatures need an RSA or ECDSA key, not %T", key)
+	}
+
+	now := time.Now()
+	if now.Before(certificate.NotBefore) || now.After(certificate.NotAfter) {
+		return nil, fmt.Errorf("certificate %q is not valid until %s or expired on %s",
+			certificate.Subject.CommonName, certificate.NotBefore.Format(time.DateOnly), certificate.NotAfter.Format(time.DateOnly))
+	}
+
+	return &Identity{Certificate: certificate, Chain: chain, Key: signer}, nil
+}
+
+// LoadPKCS12File reads a .p12 or .pfx file into a signing identity
+func LoadPKCS12File(path, password string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPKCS12(data, password)
+}
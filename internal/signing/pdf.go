@@ -0,0 +1,218 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+const (
+	// signatureSize is the space reserved for the DER signature, enough for a
+	// 4096-bit key with a few chain certificates
+	signatureSize = 16384
+
+	// byteRangePlaceholder is replaced by the real offsets once they are known
+	byteRangePlaceholder = 9999999999
+)
+
+// Options describe the signature dictionary entries shown by PDF readers
+type Options struct {
+	Name     string
+	Reason   string
+	Location string
+}
+
+// SignFile signs the PDF at path in place with an invisible signature on the
+// first page. The document is rewritten once with space reserved for the
+// signature, which is then filled in over the bytes around it.
+func SignFile(path string, identity *Identity, opts Options) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	api.DisableConfigDir()
+	conf := model.NewDefaultConfiguration()
+	// The signature dictionary must be written as a plain object so its
+	// placeholders can be found and patched in the output
+	conf.WriteObjectStream = false
+	conf.WriteXRefStream = false
+
+	ctx, err := api.ReadValidateAndOptimize(file, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %v", err)
+	}
+	if ctx.Encrypt != nil {
+		return fmt.Errorf("encrypted PDFs cannot be signed")
+	}
+
+	now := time.Now()
+	if err := addSignatureField(ctx, identity, opts, now); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := api.WriteContext(ctx, &buf); err != nil {
+		return fmt.Errorf("failed to write PDF: %v", err)
+	}
+	file.Close()
+
+	signed, err := fillSignature(buf.Bytes(), identity, now)
+	if err != nil {
+		return err
+	}
+
+	// Read the result back before it replaces the file, so a mistake in the
+	// patched offsets or the signature is caught here rather than by a reader
+	if err := verifySignature(signed, identity); err != nil {
+		return fmt.Errorf("signed PDF failed verification: %v", err)
+	}
+	if err := api.Validate(bytes.NewReader(signed), conf); err != nil {
+		return fmt.Errorf("signed PDF is not valid: %v", err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.signing")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+	if _, err := tempFile.Write(signed); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// addSignatureField adds a signature dictionary with placeholder contents and
+// an invisible widget on the first page that refers to it
+func addSignatureField(ctx *model.Context, identity *Identity, opts Options, now time.Time) error {
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return fmt.Errorf("failed to read catalog: %v", err)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = identity.Certificate.Subject.CommonName
+	}
+
+	signature := types.Dict{
+		"Type":      types.Name("Sig"),
+		"Filter":    types.Name("Adobe.PPKLite"),
+		"SubFilter": types.Name("adbe.pkcs7.detached"),
+		"ByteRange": placeholderByteRange(),
+		"Contents":  types.HexLiteral(strings.Repeat("0", signatureSize*2)),
+		"M":         types.StringLiteral(types.DateString(now)),
+	}
+	for key, value := range map[string]string{"Name": name, "Reason": opts.Reason, "Location": opts.Location} {
+		if value != "" {
+			signature[key] = types.StringLiteral(types.EncodeUTF16String(value))
+		}
+	}
+	signatureRef, err := ctx.IndRefForNewObject(signature)
+	if err != nil {
+		return err
+	}
+
+	page, pageRef, _, err := ctx.PageDict(1, false)
+	if err != nil || page == nil {
+		return fmt.Errorf("failed to read first page: %v", err)
+	}
+
+	// Find or create the interactive form that lists the signature field
+	form := types.Dict{}
+	if obj, found := catalog.Find("AcroForm"); found {
+		form, err = ctx.DereferenceDict(obj)
+		if err != nil || form == nil {
+			return fmt.Errorf("failed to read form: %v", err)
+		}
+	} else {
+		catalog["AcroForm"] = form
+	}
+	fields, err := ctx.DereferenceArray(form["Fields"])
+	if err != nil {
+		return fmt.Errorf("failed to read form fields: %v", err)
+	}
+
+	widget := types.Dict{
+		"Type":    types.Name("Annot"),
+		"Subtype": types.Name("Widget"),
+		"FT":      types.Name("Sig"),
+		"T":       types.StringLiteral(fmt.Sprintf("Signature%d", len(fields)+1)),
+		"V":       *signatureRef,
+		"F":       types.Integer(132), // Print and Locked
+		"Rect":    types.Array{types.Integer(0), types.Integer(0), types.Integer(0), types.Integer(0)},
+		"P":       *pageRef,
+	}
+	widgetRef, err := ctx.IndRefForNewObject(widget)
+	if err != nil {
+		return err
+	}
+
+	form["Fields"] = append(fields, *widgetRef)
+	form["SigFlags"] = types.Integer(3) // SignaturesExist and AppendOnly
+
+	annots, err := ctx.DereferenceArray(page["Annots"])
+	if err != nil {
+		return fmt.Errorf("failed to read page annotations: %v", err)
+	}
+	page["Annots"] = append(annots, *widgetRef)
+
+	return nil
+}
+
+// placeholderByteRange returns a ByteRange wide enough for any real offsets
+func placeholderByteRange() types.Array {
+	return types.Array{types.Integer(0), types.Integer(byteRangePlaceholder), types.Integer(byteRangePlaceholder), types.Integer(byteRangePlaceholder)}
+}
+
+// fillSignature patches the byte range and signature into a written PDF.
+// The signature covers every byte except the hex string that holds it.
+func fillSignature(pdf []byte, identity *Identity, now time.Time) ([]byte, error) {
+	placeholder := []byte(placeholderByteRange().PDFString())
+	rangeStart := bytes.Index(pdf, placeholder)
+	contents := []byte("<" + strings.Repeat("0", signatureSize*2) + ">")
+	contentsStart := bytes.Index(pdf, contents)
+	if rangeStart < 0 || contentsStart < 0 {
+		return nil, fmt.Errorf("failed to locate signature placeholder")
+	}
+	contentsEnd := contentsStart + len(contents)
+
+	byteRange := fmt.Sprintf("[0 %d %d %d]", contentsStart, contentsEnd, len(pdf)-contentsEnd)
+	byteRange += strings.Repeat(" ", len(placeholder)-len(byteRange))
+	copy(pdf[rangeStart:], byteRange)
+
+	hash := sha256.New()
+	hash.Write(pdf[:contentsStart])
+	hash.Write(pdf[contentsEnd:])
+
+	signature, err := signDetached(identity, hash.Sum(nil), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %v", err)
+	}
+	if len(signature) > signatureSize {
+		return nil, fmt.Errorf("signature is %d bytes, more than the %d reserved", len(signature), signatureSize)
+	}
+
+	hex.Encode(pdf[contentsStart+1:], signature)
+	return pdf, nil
+}
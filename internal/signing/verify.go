@@ -0,0 +1,115 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// byteRangePattern matches a written signature byte range
+var byteRangePattern = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+
+// verifySignature reads back the signature written by fillSignature and
+// checks that its byte range covers the whole file except the signature, that
+// the digest it signs matches those bytes and that the identity's key made it
+func verifySignature(pdf []byte, identity *Identity) error {
+	ranges, err := signedByteRange(pdf)
+	if err != nil {
+		return err
+	}
+	contentsStart, contentsEnd := ranges[1], ranges[2]
+	if pdf[contentsStart] != '<' || pdf[contentsEnd-1] != '>' {
+		return fmt.Errorf("byte range does not exclude exactly the signature contents")
+	}
+
+	der := make([]byte, hex.DecodedLen(contentsEnd-contentsStart-2))
+	if _, err := hex.Decode(der, pdf[contentsStart+1:contentsEnd-1]); err != nil {
+		return fmt.Errorf("failed to decode signature contents: %v", err)
+	}
+
+	var info contentInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil || !info.ContentType.Equal(oidSignedData) {
+		return fmt.Errorf("signature contents are not a CMS signed data structure")
+	}
+	var data signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &data); err != nil || len(data.SignerInfos) != 1 {
+		return fmt.Errorf("failed to read CMS signed data: %v", err)
+	}
+	signer := data.SignerInfos[0]
+	if signer.SID.SerialNumber == nil || signer.SID.SerialNumber.Cmp(identity.Certificate.SerialNumber) != 0 {
+		return fmt.Errorf("signature does not name the signing certificate")
+	}
+
+	hash := sha256.New()
+	hash.Write(pdf[:contentsStart])
+	hash.Write(pdf[contentsEnd:])
+	digest, err := signedDigest(signer.SignedAttributes.Bytes)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(digest, hash.Sum(nil)) {
+		return fmt.Errorf("signed digest does not match the document")
+	}
+
+	// The signature covers the attributes re-encoded as a SET
+	attributes, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: signer.SignedAttributes.Bytes})
+	if err != nil {
+		return err
+	}
+	algorithm := x509.SHA256WithRSA
+	if _, ok := identity.Key.(*ecdsa.PrivateKey); ok {
+		algorithm = x509.ECDSAWithSHA256
+	}
+	if err := identity.Certificate.CheckSignature(algorithm, attributes, signer.Signature); err != nil {
+		return fmt.Errorf("signature does not verify: %v", err)
+	}
+	return nil
+}
+
+// signedByteRange finds the one byte range that starts the file and reaches
+// its end, which is the signature just written. A signature kept from the
+// input never covers the rewritten file.
+func signedByteRange(pdf []byte) ([4]int, error) {
+	var found [][4]int
+	for _, match := range byteRangePattern.FindAllSubmatch(pdf, -1) {
+		var ranges [4]int
+		for i := range ranges {
+			ranges[i], _ = strconv.Atoi(string(match[i+1]))
+		}
+		if ranges[0] == 0 && ranges[1] < ranges[2] && ranges[2]+ranges[3] == len(pdf) {
+			found = append(found, ranges)
+		}
+	}
+	if len(found) != 1 {
+		return [4]int{}, fmt.Errorf("expected one byte range covering the signed file, found %d", len(found))
+	}
+	return found[0], nil
+}
+
+// signedDigest returns the message digest attribute from the contents of the
+// signed attributes
+func signedDigest(attributes []byte) ([]byte, error) {
+	for len(attributes) > 0 {
+		var attr attribute
+		rest, err := asn1.Unmarshal(attributes, &attr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signed attributes: %v", err)
+		}
+		attributes = rest
+		if !attr.Type.Equal(oidMessageDigest) {
+			continue
+		}
+		var digest []byte
+		if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+			return nil, fmt.Errorf("failed to read message digest: %v", err)
+		}
+		return digest, nil
+	}
+	return nil, fmt.Errorf("signature has no message digest")
+}
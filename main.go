@@ -2,18 +2,25 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"kleinpdf/internal/app"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"github.com/wailsapp/wails/v2/pkg/options/mac"
 )
 
 //go:embed all:frontend/dist
 var assets embed.FS
 
 func main() {
+	// "kleinpdf benchmark" tunes max_concurrency without opening the window
+	if len(os.Args) > 1 && os.Args[1] == "benchmark" {
+		os.Exit(app.RunBenchmarkCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// Create an instance of the app structure
 	application := app.NewApp()
 
@@ -24,10 +31,23 @@ func main() {
 		Height: 600,
 
 		AssetServer: &assetserver.Options{
-			Assets: assets,
+			Assets:  assets,
+			Handler: app.NewUploadHandler(application),
 		},
 
-		OnStartup: application.OnStartup,
+		OnStartup:     application.OnStartup,
+		OnDomReady:    application.OnDomReady,
+		OnShutdown:    application.OnShutdown,
+		OnBeforeClose: application.OnBeforeClose,
+		Logger:        app.NewWailsLogger(application),
+		SingleInstanceLock: &options.SingleInstanceLock{
+			UniqueId:               "com.kleinpdf.app",
+			OnSecondInstanceLaunch: application.OnSecondInstanceLaunch,
+		},
+		Mac: &mac.Options{
+			OnFileOpen: application.OnFileOpen,
+			OnUrlOpen:  application.OnUrlOpen,
+		},
 		Bind: []interface{}{
 			application,
 		},